@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+
+	"github.com/lancekrogers/go-token-counter/internal/errors"
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+)
+
+type batchOptions struct {
+	jsonOutput bool
+}
+
+func newBatchCmd() *cobra.Command {
+	opts := &batchOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "batch [file.jsonl]",
+		Short: "Count input tokens in an OpenAI Batch API file",
+		Long: `Count the input tokens of every request in an OpenAI Batch API JSONL file.
+
+Each line's body is counted with the encoding of its "model" field: chat
+completions and Responses bodies including per-message overhead and tools,
+and embeddings bodies by their input strings. Dated snapshots such as
+gpt-4o-2024-08-06 use their base model's context window and pricing.
+
+Requests are totalled per model, priced at the Batch API discount of the
+standard input price, and flagged when they exceed the model's context
+window. Lines that cannot be counted are listed instead of stopping the run.`,
+		Example: `  tcount batch requests.jsonl          # Per-model summary table
+  tcount batch --json requests.jsonl   # Output as JSON`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBatch(cmd.Context(), args[0], opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+
+	return cmd
+}
+
+func runBatch(ctx context.Context, path string, opts *batchOptions) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.IO("opening batch file", err).WithField("path", path)
+	}
+	defer func() { _ = file.Close() }()
+
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{})
+	if err != nil {
+		return errors.Wrap(err, "creating token counter")
+	}
+
+	result, err := counter.CountBatch(ctx, file)
+	if err != nil {
+		return errors.IO("reading batch file", err).WithField("path", path)
+	}
+
+	if opts.jsonOutput {
+		return outputJSON(result)
+	}
+
+	return outputBatchTable(path, result)
+}
+
+func outputBatchTable(path string, result *tokenizer.BatchResult) error {
+	titleStyle, sectionStyle, labelStyle, valStyle := styles()
+
+	fmt.Println(titleStyle.Render("Batch Token Count for: " + path))
+	fmt.Println()
+
+	rows := make([][]string, 0, len(result.Models))
+	for _, m := range result.Models {
+		window := "-"
+		if m.ContextWindow > 0 {
+			window = formatInt(m.ContextWindow)
+		}
+		rows = append(rows, []string{
+			m.Model,
+			formatInt(m.Requests),
+			formatInt(m.InputTokens),
+			formatInt(m.MaxTokens),
+			window,
+			formatInt(m.OverContext),
+			fmt.Sprintf("$%.4f", m.BatchCost),
+		})
+	}
+
+	if len(rows) > 0 {
+		purple := lipgloss.Color("99")
+		headerStyle := lipgloss.NewStyle().Bold(true).Foreground(purple).Align(lipgloss.Center)
+		cellStyle := lipgloss.NewStyle().PaddingLeft(1).PaddingRight(1)
+		numberCellStyle := cellStyle.Align(lipgloss.Right)
+
+		t := table.New().
+			Border(lipgloss.RoundedBorder()).
+			BorderStyle(lipgloss.NewStyle().Foreground(purple)).
+			Headers("Model", "Requests", "Input Tokens", "Largest", "Context", "Over", "Batch Cost").
+			Rows(rows...).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == table.HeaderRow {
+					return headerStyle
+				}
+				if col == 0 {
+					return cellStyle
+				}
+				return numberCellStyle
+			})
+
+		fmt.Println(sectionStyle.Render("Tokens by Model"))
+		fmt.Println(t)
+		fmt.Println()
+	}
+
+	fmt.Println(sectionStyle.Render("Totals"))
+	fmt.Printf("  %s %s\n", labelStyle.Render("Requests:"), valStyle.Render(formatInt(result.Requests)))
+	fmt.Printf("  %s %s\n", labelStyle.Render("Input tokens:"), valStyle.Render(formatInt(result.TotalTokens)))
+	fmt.Printf("  %s $%.4f\n", labelStyle.Render("Standard cost:"), result.StandardCost)
+	fmt.Printf("  %s $%.4f (%.0f%% discount)\n", labelStyle.Render("Batch cost:"), result.BatchCost, (1-tokenizer.BatchDiscount)*100)
+
+	if len(result.OverContext) > 0 {
+		fmt.Println()
+		fmt.Println(sectionStyle.Render("Over Context Window"))
+		for _, issue := range result.OverContext {
+			fmt.Printf("  %s %s: %s tokens > %s (%s)\n",
+				labelStyle.Render(fmt.Sprintf("line %d", issue.Line)), issueID(issue),
+				formatInt(issue.Tokens), formatInt(issue.ContextWindow), issue.Model)
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Println()
+		fmt.Println(sectionStyle.Render("Errors"))
+		for _, issue := range result.Errors {
+			fmt.Printf("  %s %s: %s\n", labelStyle.Render(fmt.Sprintf("line %d", issue.Line)), issueID(issue), issue.Message)
+		}
+	}
+
+	return nil
+}
+
+// issueID returns a batch request's custom_id, or "-" when it has none.
+func issueID(issue tokenizer.BatchIssue) string {
+	if issue.CustomID == "" {
+		return "-"
+	}
+	return issue.CustomID
+}
@@ -0,0 +1,291 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+
+	"github.com/lancekrogers/go-token-counter/internal/errors"
+	"github.com/lancekrogers/go-token-counter/internal/ui"
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+)
+
+// defaultChatModel is used when neither --model nor the request names a model.
+const defaultChatModel = "gpt-4o"
+
+// validChatFormats lists accepted values for the chat --format flag.
+var validChatFormats = []string{"auto", "openai", "anthropic"}
+
+type chatOptions struct {
+	model      string
+	format     string
+	template   string
+	jsonOutput bool
+}
+
+func newChatCmd() *cobra.Command {
+	opts := &chatOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "chat [request.json]",
+		Short: "Count tokens in a chat request payload",
+		Long: `Count the prompt tokens of an OpenAI-style chat request.
+
+Accepts a chat completions body with a "messages" array, a Responses API body
+with "input" items, or a bare JSON array of messages. Per-message framing and
+reply-priming overheads for the model's encoding are included in the total.
+
+Tool definitions ("tools" or legacy "functions", in OpenAI or Anthropic shape)
+are counted using the serialization of the model's provider, and the total is
+broken down into system, messages and tools.
+
+Anthropic /v1/messages bodies (system prompt, content blocks, tool_use and
+tool_result blocks, documents and images) are estimated with the Claude
+approximator plus per-message and per-block overheads. The format is chosen
+from the model's provider unless --format is given.
+
+Open-weight models (Llama, Qwen, Mistral, Phi, DeepSeek) are counted by
+rendering the messages through their chat template, so role headers and turn
+delimiters such as <|start_header_id|> or <|im_start|> are included. Use
+--template to pick a built-in template, load the chat_template of a
+HuggingFace tokenizer_config.json, or disable templates with "none".
+
+The model is taken from --model, then the request's "model" field, and
+defaults to ` + defaultChatModel + `.`,
+		Example: `  tcount chat request.json                                  # Count using the request's model
+  tcount chat --model gpt-4.1 request.json                  # Override the model
+  tcount chat --model claude-sonnet-4.6 messages.json       # Anthropic Messages API body
+  tcount chat --model qwen-2.5-7b request.json              # Qwen chat template
+  tcount chat --template tokenizer_config.json request.json # HuggingFace chat_template
+  tcount chat --json request.json                           # Output as JSON`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runChat(cmd.Context(), args[0], opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.model, "model", "", "model to count for (overrides the request's model field)")
+	cmd.Flags().StringVar(&opts.format, "format", "auto", "request format (auto, openai, anthropic)")
+	cmd.Flags().StringVar(&opts.template, "template", "auto", "chat template (auto, none, "+strings.Join(tokenizer.ChatTemplateNames(), ", ")+", or a tokenizer_config.json path)")
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+
+	return cmd
+}
+
+func runChat(ctx context.Context, path string, opts *chatOptions) error {
+	display := ui.New(noColor, verbose)
+
+	if !isValidChatFormat(opts.format) {
+		return fmt.Errorf("invalid format %q, valid options: %s", opts.format, strings.Join(validChatFormats, ", "))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.IO("reading chat request", err).WithField("path", path)
+	}
+
+	model := opts.model
+	if model == "" {
+		model = requestModel(data)
+	}
+	if model == "" {
+		model = defaultChatModel
+	}
+	if err := checkModel(display, model); err != nil {
+		return err
+	}
+	model = canonicalModel(model)
+
+	counterOpts := tokenizer.CounterOptions{VocabFile: vocabFileForModel(model, "")}
+	switch {
+	case opts.template == "auto":
+	case opts.template == "none":
+		counterOpts.NoChatTemplate = true
+	case isBuiltinChatTemplate(opts.template):
+		if counterOpts.ChatTemplate, err = tokenizer.BuiltinChatTemplate(opts.template); err != nil {
+			return errors.Wrap(err, "loading chat template")
+		}
+	default:
+		if counterOpts.ChatTemplate, err = tokenizer.LoadChatTemplate(opts.template); err != nil {
+			return errors.IO("loading chat template", err).WithField("path", opts.template)
+		}
+	}
+
+	counter, err := tokenizer.NewCounter(counterOpts)
+	if err != nil {
+		return errors.Wrap(err, "creating token counter")
+	}
+
+	var result *tokenizer.ChatCountResult
+	var toolCount int
+	if resolveChatFormat(opts.format, model) == "anthropic" {
+		req, err := tokenizer.ParseAnthropicRequest(data)
+		if err != nil {
+			return errors.Parse("invalid messages request", err).WithField("path", path)
+		}
+		result, err = counter.CountAnthropicRequest(ctx, req, model)
+		if err != nil {
+			return errors.Wrap(err, "counting chat tokens")
+		}
+		toolCount = len(req.Tools)
+	} else {
+		req, err := tokenizer.ParseChatRequest(data)
+		if err != nil {
+			return errors.Parse("invalid chat request", err).WithField("path", path)
+		}
+		result, err = counter.CountChatRequest(ctx, req, model)
+		if err != nil {
+			return errors.Wrap(err, "counting chat tokens")
+		}
+		toolCount = len(req.Tools)
+	}
+
+	if opts.jsonOutput {
+		return outputJSON(result)
+	}
+
+	return outputChatTable(path, result, toolCount)
+}
+
+// isValidChatFormat checks if a chat format name is valid.
+func isValidChatFormat(format string) bool {
+	for _, valid := range validChatFormats {
+		if format == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// isBuiltinChatTemplate checks if a --template value names a built-in template.
+func isBuiltinChatTemplate(name string) bool {
+	for _, builtin := range tokenizer.ChatTemplateNames() {
+		if name == builtin {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveChatFormat picks the request format for "auto" from the model's
+// provider. Unregistered claude-* names are treated as Anthropic.
+func resolveChatFormat(format, model string) string {
+	if format != "auto" {
+		return format
+	}
+	if tokenizer.GetProviderForModel(model) == tokenizer.ProviderAnthropic || strings.HasPrefix(model, "claude-") {
+		return "anthropic"
+	}
+	return "openai"
+}
+
+// requestModel returns the "model" field of a request body, or "" when the
+// body has none (for example a bare messages array).
+func requestModel(data []byte) string {
+	var head struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return ""
+	}
+	return head.Model
+}
+
+func outputChatTable(path string, result *tokenizer.ChatCountResult, toolCount int) error {
+	titleStyle, sectionStyle, labelStyle, valStyle := styles()
+
+	fmt.Println(titleStyle.Render("Chat Token Count for: " + path))
+	fmt.Println()
+
+	fmt.Println(sectionStyle.Render("Request"))
+	fmt.Printf("  %s %s\n", labelStyle.Render("Model:"), valStyle.Render(result.Model))
+	fmt.Printf("  %s %s\n", labelStyle.Render("Encoding:"), valStyle.Render(result.Encoding))
+	if result.Template != "" {
+		fmt.Printf("  %s %s\n", labelStyle.Render("Template:"), valStyle.Render(result.Template))
+	}
+	fmt.Printf("  %s %s\n", labelStyle.Render("Messages:"), valStyle.Render(formatInt(len(result.Messages))))
+	if toolCount > 0 {
+		fmt.Printf("  %s %s\n", labelStyle.Render("Tools:"), valStyle.Render(formatInt(toolCount)))
+	}
+	fmt.Println()
+
+	rows := make([][]string, 0, len(result.Messages))
+	for _, m := range result.Messages {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", m.Index),
+			m.Role,
+			formatInt(m.ContentTokens),
+			formatInt(m.OverheadTokens),
+			formatInt(m.Tokens),
+		})
+	}
+
+	purple := lipgloss.Color("99")
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(purple).Align(lipgloss.Center)
+	cellStyle := lipgloss.NewStyle().PaddingLeft(1).PaddingRight(1)
+	numberCellStyle := cellStyle.Align(lipgloss.Right)
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(purple)).
+		Headers("#", "Role", "Content", "Overhead", "Tokens").
+		Rows(rows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			if col == 1 {
+				return cellStyle
+			}
+			return numberCellStyle
+		})
+
+	fmt.Println(sectionStyle.Render("Tokens by Message"))
+	fmt.Println(t)
+	fmt.Println()
+
+	accuracy := "Approx"
+	if result.IsExact {
+		accuracy = "Exact"
+	}
+	fmt.Println(sectionStyle.Render("Totals"))
+	fmt.Printf("  %s %s\n", labelStyle.Render("System:"), valStyle.Render(formatInt(result.Breakdown.System)))
+	fmt.Printf("  %s %s\n", labelStyle.Render("Messages:"), valStyle.Render(formatInt(result.Breakdown.Messages)))
+	fmt.Printf("  %s %s\n", labelStyle.Render("Tools:"), valStyle.Render(formatInt(result.Breakdown.Tools)))
+	if result.Breakdown.Images > 0 {
+		fmt.Printf("  %s %s\n", labelStyle.Render("Images:"), valStyle.Render(formatInt(result.Breakdown.Images)))
+	}
+	if result.Breakdown.Documents > 0 {
+		fmt.Printf("  %s %s\n", labelStyle.Render("Documents:"), valStyle.Render(formatInt(result.Breakdown.Documents)))
+	}
+	primingLabel := "Reply priming:"
+	if result.Template != "" {
+		primingLabel = "Generation prompt:"
+	}
+	fmt.Printf("  %s %s\n", labelStyle.Render(primingLabel), valStyle.Render(formatInt(result.ReplyPriming)))
+	fmt.Printf("  %s %s (%s)\n", labelStyle.Render("Total tokens:"), valStyle.Render(formatInt(result.TotalTokens)), accuracy)
+	if result.ContextWindow > 0 {
+		pct := float64(result.TotalTokens) / float64(result.ContextWindow) * 100
+		fmt.Printf("  %s %.1f%% of %s\n", labelStyle.Render("Context usage:"), pct, formatInt(result.ContextWindow))
+		fmt.Printf("  %s %s tokens\n", labelStyle.Render("Output headroom:"), valStyle.Render(formatInt(result.OutputHeadroom)))
+	}
+	if result.InputCost > 0 {
+		fmt.Printf("  %s $%.4f\n", labelStyle.Render("Input cost:"), result.InputCost)
+	}
+
+	if len(result.Notes) > 0 {
+		fmt.Println()
+		fmt.Println(sectionStyle.Render("Notes"))
+		for _, note := range result.Notes {
+			fmt.Printf("  %s %s\n", labelStyle.Render("-"), note)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+
+	"github.com/lancekrogers/go-token-counter/internal/errors"
+	"github.com/lancekrogers/go-token-counter/internal/ui"
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+)
+
+// defaultFinetuneModel is used when --model is not given.
+const defaultFinetuneModel = "gpt-4o-mini"
+
+type finetuneOptions struct {
+	model      string
+	epochs     int
+	jsonOutput bool
+}
+
+func newFinetuneCmd() *cobra.Command {
+	opts := &finetuneOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "finetune [file.jsonl]",
+		Short: "Analyze a chat-format fine-tuning dataset",
+		Long: `Validate a chat-format fine-tuning JSONL file and estimate its training cost.
+
+Every line must be an object with a "messages" array containing at least one
+assistant message. Invalid lines are listed with the reason and left out of
+the statistics.
+
+Each example is counted like a chat request for --model. The report shows
+the distribution of tokens per example, split into prompt tokens and
+assistant tokens, and flags examples longer than the model's training limit,
+which are truncated. Training tokens are the billed tokens per epoch times
+--epochs; without --epochs the number OpenAI picks by default is used.`,
+		Example: `  tcount finetune data.jsonl                                # gpt-4o-mini, default epochs
+  tcount finetune data.jsonl --model gpt-4.1-mini --epochs 3
+  tcount finetune --json data.jsonl                         # Output as JSON`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFinetune(cmd.Context(), args[0], opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.model, "model", defaultFinetuneModel, "model to fine-tune")
+	cmd.Flags().IntVar(&opts.epochs, "epochs", 0, "number of training epochs (0 uses OpenAI's default)")
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+
+	return cmd
+}
+
+func runFinetune(ctx context.Context, path string, opts *finetuneOptions) error {
+	display := ui.New(noColor, verbose)
+
+	if opts.epochs < 0 {
+		return fmt.Errorf("invalid epochs %d, must be 0 or greater", opts.epochs)
+	}
+	if err := checkModel(display, opts.model); err != nil {
+		return err
+	}
+	opts.model = canonicalModel(opts.model)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.IO("opening training file", err).WithField("path", path)
+	}
+	defer func() { _ = file.Close() }()
+
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{VocabFile: vocabFileForModel(opts.model, "")})
+	if err != nil {
+		return errors.Wrap(err, "creating token counter")
+	}
+
+	result, err := counter.CountFinetune(ctx, file, tokenizer.FinetuneOptions{Model: opts.model, Epochs: opts.epochs})
+	if err != nil {
+		return errors.IO("reading training file", err).WithField("path", path)
+	}
+
+	if opts.jsonOutput {
+		return outputJSON(result)
+	}
+
+	return outputFinetuneTable(path, result)
+}
+
+func outputFinetuneTable(path string, result *tokenizer.FinetuneResult) error {
+	titleStyle, sectionStyle, labelStyle, valStyle := styles()
+
+	fmt.Println(titleStyle.Render("Fine-tuning Dataset: " + path))
+	fmt.Println()
+
+	method := "exact"
+	if !result.IsExact {
+		method = "approximate"
+	}
+	fmt.Printf("  %s %s\n", labelStyle.Render("Model:"), valStyle.Render(result.Model))
+	fmt.Printf("  %s %s (%s)\n", labelStyle.Render("Encoding:"), valStyle.Render(result.Encoding), method)
+	fmt.Printf("  %s %s\n", labelStyle.Render("Examples:"), valStyle.Render(formatInt(result.Examples)))
+	if result.ExampleLimit > 0 {
+		fmt.Printf("  %s %s tokens\n", labelStyle.Render("Example limit:"), valStyle.Render(formatInt(result.ExampleLimit)))
+	}
+	fmt.Println()
+
+	if result.Examples > 0 {
+		purple := lipgloss.Color("99")
+		headerStyle := lipgloss.NewStyle().Bold(true).Foreground(purple).Align(lipgloss.Center)
+		cellStyle := lipgloss.NewStyle().PaddingLeft(1).PaddingRight(1)
+		numberCellStyle := cellStyle.Align(lipgloss.Right)
+
+		row := func(name string, s tokenizer.TokenStats) []string {
+			return []string{
+				name,
+				formatInt(s.Min),
+				formatInt(s.Median),
+				fmt.Sprintf("%.1f", s.Mean),
+				formatInt(s.P90),
+				formatInt(s.Max),
+				formatInt(s.Total),
+			}
+		}
+
+		t := table.New().
+			Border(lipgloss.RoundedBorder()).
+			BorderStyle(lipgloss.NewStyle().Foreground(purple)).
+			Headers("Tokens", "Min", "Median", "Mean", "P90", "Max", "Total").
+			Rows(
+				row("Per example", result.ExampleTokens),
+				row("Prompt", result.PromptTokens),
+				row("Assistant", result.AssistantTokens),
+			).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == table.HeaderRow {
+					return headerStyle
+				}
+				if col == 0 {
+					return cellStyle
+				}
+				return numberCellStyle
+			})
+
+		fmt.Println(sectionStyle.Render("Token Distribution"))
+		fmt.Println(t)
+		fmt.Println()
+	}
+
+	epochs := formatInt(result.Epochs)
+	if result.AutoEpochs {
+		epochs += " (default)"
+	}
+	fmt.Println(sectionStyle.Render("Training Estimate"))
+	fmt.Printf("  %s %s\n", labelStyle.Render("Billed tokens per epoch:"), valStyle.Render(formatInt(result.BilledPerEpoch)))
+	fmt.Printf("  %s %s\n", labelStyle.Render("Epochs:"), valStyle.Render(epochs))
+	fmt.Printf("  %s %s\n", labelStyle.Render("Training tokens:"), valStyle.Render(formatInt(result.TrainingTokens)))
+	if result.TrainingCost > 0 {
+		fmt.Printf("  %s $%.4f\n", labelStyle.Render("Training cost:"), result.TrainingCost)
+	}
+	for _, note := range result.Notes {
+		fmt.Printf("  %s %s\n", labelStyle.Render("Note:"), note)
+	}
+
+	if result.MissingSystem > 0 || result.MissingUser > 0 {
+		fmt.Println()
+		fmt.Println(sectionStyle.Render("Warnings"))
+		if result.MissingSystem > 0 {
+			fmt.Printf("  %s %s\n", labelStyle.Render("Examples without a system message:"), valStyle.Render(formatInt(result.MissingSystem)))
+		}
+		if result.MissingUser > 0 {
+			fmt.Printf("  %s %s\n", labelStyle.Render("Examples without a user message:"), valStyle.Render(formatInt(result.MissingUser)))
+		}
+	}
+
+	if len(result.OverLimit) > 0 {
+		fmt.Println()
+		fmt.Println(sectionStyle.Render("Over Example Limit"))
+		for _, issue := range result.OverLimit {
+			fmt.Printf("  %s %s tokens, %s\n",
+				labelStyle.Render(fmt.Sprintf("line %d", issue.Line)), formatInt(issue.Tokens), issue.Message)
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Println()
+		fmt.Println(sectionStyle.Render("Errors"))
+		for _, issue := range result.Errors {
+			fmt.Printf("  %s %s\n", labelStyle.Render(fmt.Sprintf("line %d", issue.Line)), issue.Message)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,530 @@
+package commands
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+
+	"github.com/lancekrogers/go-token-counter/internal/errors"
+	"github.com/lancekrogers/go-token-counter/internal/ui"
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+)
+
+// defaultMaxPricingAge is the age in days after which built-in pricing is
+// reported as stale.
+const defaultMaxPricingAge = 180
+
+type modelsOptions struct {
+	provider      string
+	encoding      string
+	sortBy        string
+	maxPricingAge int
+	priceHistory  string
+	jsonOutput    bool
+	csvOutput     bool
+}
+
+// validModelSorts lists accepted values for the models --sort flag.
+var validModelSorts = []string{"name", "provider", "encoding", "context", "max-output", "input-price", "output-price"}
+
+// isValidModelSort checks if a models sort key is valid.
+func isValidModelSort(key string) bool {
+	for _, valid := range validModelSorts {
+		if key == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// validModelEncodings lists accepted values for the models --encoding flag.
+var validModelEncodings = []string{"o200k_base", "cl100k_base", "claude_approx", "spm"}
+
+// isValidModelEncoding checks if a models encoding filter is valid.
+func isValidModelEncoding(encoding string) bool {
+	for _, valid := range validModelEncodings {
+		if encoding == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// modelsReport is the JSON output of the models command.
+type modelsReport struct {
+	PricingUpdated string      `json:"pricing_updated"`
+	RegistryFiles  []string    `json:"registry_files,omitempty"`
+	Models         []modelInfo `json:"models"`
+}
+
+// modelInfo is one registry entry with the fields set by overrides.
+type modelInfo struct {
+	Name                 string   `json:"name"`
+	Provider             string   `json:"provider"`
+	Encoding             string   `json:"encoding"`
+	Tokenizer            string   `json:"tokenizer"`
+	Accuracy             string   `json:"accuracy"`
+	ContextWindow        int      `json:"context_window"`
+	MaxOutputTokens      int      `json:"max_output_tokens"`
+	LongContextWindow    int      `json:"long_context_window,omitempty"`
+	InputPricePer1M      float64  `json:"input_price_per_1m"`
+	OutputPricePer1M     float64  `json:"output_price_per_1m"`
+	CacheWritePricePer1M float64  `json:"cache_write_price_per_1m"`
+	CacheReadPricePer1M  float64  `json:"cache_read_price_per_1m"`
+	Modalities           []string `json:"modalities"`
+	KnowledgeCutoff      string   `json:"knowledge_cutoff,omitempty"`
+	Status               string   `json:"status"`
+	ReleaseDate          string   `json:"release_date,omitempty"`
+	DeprecationDate      string   `json:"deprecation_date,omitempty"`
+	RetirementDate       string   `json:"retirement_date,omitempty"`
+	Overridden           []string `json:"overridden,omitempty"`
+}
+
+// priceHistoryReport is the JSON output of models --price-history.
+type priceHistoryReport struct {
+	Model      string        `json:"model"`
+	Periods    []pricePeriod `json:"periods"`
+	Overridden bool          `json:"overridden,omitempty"`
+}
+
+// pricePeriod is one span of a model's prices. From is empty for the first
+// period when the release date is not tracked, and Until for the current
+// prices.
+type pricePeriod struct {
+	From                 string  `json:"from,omitempty"`
+	Until                string  `json:"until,omitempty"`
+	InputPricePer1M      float64 `json:"input_price_per_1m"`
+	OutputPricePer1M     float64 `json:"output_price_per_1m"`
+	CacheWritePricePer1M float64 `json:"cache_write_price_per_1m"`
+	CacheReadPricePer1M  float64 `json:"cache_read_price_per_1m"`
+}
+
+// formatDate formats a registry date, or returns "" for the zero time.
+func formatDate(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+func newModelsCmd() *cobra.Command {
+	opts := &modelsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "models",
+		Short: "List registered models with their tokenizers, limits, prices and sources",
+		Long: `List the model registry: provider, encoding, tokenizer backend and how
+exact its counts are, context window, maximum output tokens, and input,
+output and prompt-cache prices per 1M tokens. Deprecated and retired models
+are marked; JSON and CSV also include the input modalities, knowledge
+cutoff and lifecycle dates.
+
+Accuracy is "exact" for a model's own tokenizer, "proxy" for open-weight
+models counted with an OpenAI encoding, and "estimated" for the Claude
+approximation. Models can be filtered by provider and encoding, sorted by
+--sort (name, provider and encoding ascending; context and max-output
+largest first; prices cheapest first), and written as a table, JSON or CSV.
+
+Registry values can be overridden or extended without a new release by a
+YAML or JSON file. tcount applies, in order, the first of models.yaml,
+models.yml or models.json in the user config directory (for example
+~/.config/tcount/), the first of .tcount.yaml, .tcount.yml or .tcount.json
+in the working directory, and the --registry file. The Source column names
+the fields each model takes from an override.
+
+The built-in prices carry the date they were last checked; a warning is
+printed when they are older than --max-pricing-age days. --price-history
+shows the dated prices of one model, as used by tcount --as-of; override
+files can set them with price_history.`,
+		Example: `  tcount models                                   # Every registered model
+  tcount models --provider anthropic              # One provider
+  tcount models --encoding o200k_base             # Models counted with one encoding
+  tcount models --sort input-price                # Cheapest input first
+  tcount models --registry prices.yaml            # Show the effect of an override file
+  tcount models --price-history gpt-4o            # Earlier and current prices of one model
+  tcount models --json                            # Output as JSON
+  tcount models --csv > models.csv                # Output as CSV`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runModels(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.provider, "provider", "all", "filter models by provider (openai, anthropic, meta, deepseek, alibaba, microsoft, a custom model's provider, all)")
+	cmd.Flags().StringVar(&opts.encoding, "encoding", "", "filter models by encoding ("+strings.Join(validModelEncodings, ", ")+")")
+	cmd.Flags().StringVar(&opts.sortBy, "sort", "name", "sort by "+strings.Join(validModelSorts, ", "))
+	cmd.Flags().StringVar(&opts.priceHistory, "price-history", "", "show the dated price history of one model")
+	cmd.Flags().IntVar(&opts.maxPricingAge, "max-pricing-age", defaultMaxPricingAge, "warn when built-in pricing is older than this many days (0 disables)")
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	cmd.Flags().BoolVar(&opts.csvOutput, "csv", false, "output in CSV format")
+	cmd.MarkFlagsMutuallyExclusive("json", "csv")
+
+	return cmd
+}
+
+func runModels(opts *modelsOptions) error {
+	display := ui.New(noColor, verbose)
+
+	if !isValidProvider(opts.provider) {
+		return fmt.Errorf("invalid provider %q, valid options: %s", opts.provider, strings.Join(validProviders, ", "))
+	}
+	if !isValidModelSort(opts.sortBy) {
+		return errors.Validation("invalid --sort key").
+			WithField("sort", opts.sortBy).
+			WithHint("use one of " + strings.Join(validModelSorts, ", "))
+	}
+	if opts.encoding != "" && !isValidModelEncoding(opts.encoding) {
+		return errors.Validation("invalid --encoding "+strconv.Quote(opts.encoding)).
+			WithField("encoding", opts.encoding).
+			WithHint("use one of " + strings.Join(validModelEncodings, ", "))
+	}
+
+	if opts.priceHistory != "" {
+		return runPriceHistory(opts)
+	}
+
+	now := time.Now()
+	if age := int(tokenizer.PricingAge(now).Hours() / 24); opts.maxPricingAge > 0 && age > opts.maxPricingAge {
+		display.Warning("Built-in pricing was last updated %s (%d days ago); use a registry override file for current prices",
+			tokenizer.PricingUpdated.Format(time.DateOnly), age)
+	}
+
+	report := modelsReport{
+		PricingUpdated: tokenizer.PricingUpdated.Format(time.DateOnly),
+		RegistryFiles:  loadedRegistryFiles,
+		Models:         []modelInfo{},
+	}
+	for _, name := range tokenizer.ListModels() {
+		meta := tokenizer.GetModelMetadata(name)
+		if opts.provider != "all" && string(meta.Provider) != opts.provider {
+			continue
+		}
+		if opts.encoding != "" && meta.Encoding != opts.encoding {
+			continue
+		}
+		backend, accuracy := meta.TokenizerBackend()
+		modalities := []string{string(tokenizer.ModalityText)}
+		if meta.Modalities != nil {
+			modalities = modalities[:0]
+			for _, m := range meta.Modalities {
+				modalities = append(modalities, string(m))
+			}
+		}
+		report.Models = append(report.Models, modelInfo{
+			Name:                 meta.Name,
+			Provider:             string(meta.Provider),
+			Encoding:             meta.Encoding,
+			Tokenizer:            backend,
+			Accuracy:             accuracy,
+			ContextWindow:        meta.ContextWindow,
+			MaxOutputTokens:      meta.MaxOutputTokens,
+			LongContextWindow:    meta.LongContextWindow,
+			InputPricePer1M:      meta.InputPricePer1M,
+			OutputPricePer1M:     meta.OutputPricePer1M,
+			CacheWritePricePer1M: meta.CacheWritePricePer1M,
+			CacheReadPricePer1M:  meta.CacheReadPricePer1M,
+			Modalities:           modalities,
+			KnowledgeCutoff:      formatDate(meta.KnowledgeCutoff, "2006-01"),
+			Status:               string(meta.Status(now)),
+			ReleaseDate:          formatDate(meta.ReleaseDate, time.DateOnly),
+			DeprecationDate:      formatDate(meta.DeprecationDate, time.DateOnly),
+			RetirementDate:       formatDate(meta.RetirementDate, time.DateOnly),
+			Overridden:           tokenizer.OverriddenFields(name),
+		})
+	}
+	sortModels(report.Models, opts.sortBy)
+
+	if opts.jsonOutput {
+		return outputJSON(report)
+	}
+	if opts.csvOutput {
+		return outputModelsCSV(report.Models)
+	}
+
+	outputModelsTable(report)
+	return nil
+}
+
+// runPriceHistory prints the price periods of one model, oldest first and
+// ending with the current prices.
+func runPriceHistory(opts *modelsOptions) error {
+	meta := tokenizer.GetModelMetadata(opts.priceHistory)
+	if meta == nil {
+		return unknownModelError(opts.priceHistory)
+	}
+
+	report := priceHistoryReport{
+		Model:   meta.Name,
+		Periods: make([]pricePeriod, 0, len(meta.PriceHistory)+1),
+	}
+	from := formatDate(meta.ReleaseDate, time.DateOnly)
+	for _, p := range meta.PriceHistory {
+		until := p.Until.Format(time.DateOnly)
+		report.Periods = append(report.Periods, pricePeriod{
+			From:                 from,
+			Until:                until,
+			InputPricePer1M:      p.InputPricePer1M,
+			OutputPricePer1M:     p.OutputPricePer1M,
+			CacheWritePricePer1M: p.CacheWritePricePer1M,
+			CacheReadPricePer1M:  p.CacheReadPricePer1M,
+		})
+		from = until
+	}
+	report.Periods = append(report.Periods, pricePeriod{
+		From:                 from,
+		InputPricePer1M:      meta.InputPricePer1M,
+		OutputPricePer1M:     meta.OutputPricePer1M,
+		CacheWritePricePer1M: meta.CacheWritePricePer1M,
+		CacheReadPricePer1M:  meta.CacheReadPricePer1M,
+	})
+	for _, field := range tokenizer.OverriddenFields(meta.Name) {
+		if field == "price_history" {
+			report.Overridden = true
+		}
+	}
+
+	if opts.jsonOutput {
+		return outputJSON(report)
+	}
+	if opts.csvOutput {
+		return outputPriceHistoryCSV(report)
+	}
+
+	outputPriceHistoryTable(report)
+	return nil
+}
+
+// sortModels orders models by key. Names, providers and encodings sort
+// ascending, context and max output largest first, and prices cheapest
+// first; models without the value (zero) go last. Ties keep name order.
+func sortModels(models []modelInfo, key string) {
+	value := func(m modelInfo) float64 {
+		switch key {
+		case "context":
+			return float64(m.ContextWindow)
+		case "max-output":
+			return float64(m.MaxOutputTokens)
+		case "input-price":
+			return m.InputPricePer1M
+		case "output-price":
+			return m.OutputPricePer1M
+		}
+		return 0
+	}
+	descending := key == "context" || key == "max-output"
+
+	sort.SliceStable(models, func(i, j int) bool {
+		a, b := models[i], models[j]
+		switch key {
+		case "provider":
+			return a.Provider < b.Provider
+		case "encoding":
+			return a.Encoding < b.Encoding
+		case "name":
+			return a.Name < b.Name
+		}
+		va, vb := value(a), value(b)
+		if va == 0 || vb == 0 {
+			return va != 0 && vb == 0
+		}
+		if descending {
+			return va > vb
+		}
+		return va < vb
+	})
+}
+
+// outputModelsCSV writes models as CSV with the JSON field names as the
+// header. Modalities and overridden fields are joined with semicolons.
+func outputModelsCSV(models []modelInfo) error {
+	w := csv.NewWriter(os.Stdout)
+	header := []string{"name", "provider", "encoding", "tokenizer", "accuracy", "context_window", "max_output_tokens",
+		"input_price_per_1m", "output_price_per_1m", "cache_write_price_per_1m", "cache_read_price_per_1m",
+		"modalities", "knowledge_cutoff", "status", "release_date", "deprecation_date", "retirement_date", "overridden"}
+	if err := w.Write(header); err != nil {
+		return errors.IO("writing CSV", err)
+	}
+	price := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	for _, m := range models {
+		record := []string{m.Name, m.Provider, m.Encoding, m.Tokenizer, m.Accuracy,
+			strconv.Itoa(m.ContextWindow), strconv.Itoa(m.MaxOutputTokens),
+			price(m.InputPricePer1M), price(m.OutputPricePer1M), price(m.CacheWritePricePer1M), price(m.CacheReadPricePer1M),
+			strings.Join(m.Modalities, ";"), m.KnowledgeCutoff, m.Status, m.ReleaseDate, m.DeprecationDate, m.RetirementDate,
+			strings.Join(m.Overridden, ";")}
+		if err := w.Write(record); err != nil {
+			return errors.IO("writing CSV", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return errors.IO("writing CSV", err)
+	}
+	return nil
+}
+
+// outputPriceHistoryCSV writes a model's price periods as CSV with the JSON
+// field names as the header.
+func outputPriceHistoryCSV(report priceHistoryReport) error {
+	w := csv.NewWriter(os.Stdout)
+	header := []string{"model", "from", "until", "input_price_per_1m", "output_price_per_1m", "cache_write_price_per_1m", "cache_read_price_per_1m"}
+	if err := w.Write(header); err != nil {
+		return errors.IO("writing CSV", err)
+	}
+	price := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	for _, p := range report.Periods {
+		record := []string{report.Model, p.From, p.Until,
+			price(p.InputPricePer1M), price(p.OutputPricePer1M), price(p.CacheWritePricePer1M), price(p.CacheReadPricePer1M)}
+		if err := w.Write(record); err != nil {
+			return errors.IO("writing CSV", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return errors.IO("writing CSV", err)
+	}
+	return nil
+}
+
+func outputPriceHistoryTable(report priceHistoryReport) {
+	titleStyle, sectionStyle, labelStyle, valStyle := styles()
+
+	fmt.Println(titleStyle.Render("Price History"))
+	fmt.Println()
+	fmt.Printf("  %s %s\n", labelStyle.Render("Model:"), valStyle.Render(report.Model))
+	source := "built-in"
+	if report.Overridden {
+		source = "override"
+	}
+	fmt.Printf("  %s %s\n", labelStyle.Render("History:"), valStyle.Render(source))
+	fmt.Println()
+
+	price := func(v float64) string {
+		if v == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("$%.2f", v)
+	}
+	day := func(d, missing string) string {
+		if d == "" {
+			return missing
+		}
+		return d
+	}
+
+	rows := make([][]string, 0, len(report.Periods))
+	for _, p := range report.Periods {
+		rows = append(rows, []string{
+			day(p.From, "release"), day(p.Until, "current"),
+			price(p.InputPricePer1M), price(p.OutputPricePer1M), price(p.CacheWritePricePer1M), price(p.CacheReadPricePer1M),
+		})
+	}
+
+	purple := lipgloss.Color("99")
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(purple).Align(lipgloss.Center)
+	cellStyle := lipgloss.NewStyle().PaddingLeft(1).PaddingRight(1)
+	numberCellStyle := cellStyle.Align(lipgloss.Right)
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(purple)).
+		Headers("From", "Until", "Input $/1M", "Output $/1M", "Cache Write", "Cache Read").
+		Rows(rows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			if col >= 2 {
+				return numberCellStyle
+			}
+			return cellStyle
+		})
+
+	fmt.Println(sectionStyle.Render("Prices"))
+	fmt.Println(t)
+	fmt.Println("  Each period ends the day before its Until date.")
+}
+
+func outputModelsTable(report modelsReport) {
+	titleStyle, sectionStyle, labelStyle, valStyle := styles()
+
+	fmt.Println(titleStyle.Render("Model Registry"))
+	fmt.Println()
+	fmt.Printf("  %s %s\n", labelStyle.Render("Built-in pricing:"), valStyle.Render(report.PricingUpdated))
+	for _, path := range report.RegistryFiles {
+		fmt.Printf("  %s %s\n", labelStyle.Render("Overrides:"), valStyle.Render(path))
+	}
+	fmt.Println()
+
+	price := func(v float64) string {
+		switch {
+		case v == 0:
+			return "-"
+		case v < 0.01:
+			return fmt.Sprintf("$%.3f", v)
+		}
+		return fmt.Sprintf("$%.2f", v)
+	}
+
+	rows := make([][]string, 0, len(report.Models))
+	for _, m := range report.Models {
+		source := "built-in"
+		switch {
+		case len(m.Overridden) > 0 && m.Overridden[0] == "model":
+			source = "override: new model"
+		case len(m.Overridden) > 0:
+			source = "override: " + strings.Join(m.Overridden, ", ")
+		}
+		limit := func(v int) string {
+			if v == 0 {
+				return "-"
+			}
+			return formatInt(v)
+		}
+		name := m.Name
+		if m.Status != string(tokenizer.ModelStatusActive) {
+			name += " (" + m.Status + ")"
+		}
+		rows = append(rows, []string{
+			name, m.Provider, m.Encoding, m.Tokenizer, m.Accuracy, limit(m.ContextWindow), limit(m.MaxOutputTokens),
+			price(m.InputPricePer1M), price(m.OutputPricePer1M), price(m.CacheWritePricePer1M), price(m.CacheReadPricePer1M), source,
+		})
+	}
+
+	purple := lipgloss.Color("99")
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(purple).Align(lipgloss.Center)
+	cellStyle := lipgloss.NewStyle().PaddingLeft(1).PaddingRight(1)
+	numberCellStyle := cellStyle.Align(lipgloss.Right)
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(purple)).
+		Headers("Model", "Provider", "Encoding", "Tokenizer", "Accuracy", "Context", "Max Output", "Input $/1M", "Output $/1M", "Cache Write", "Cache Read", "Source").
+		Rows(rows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			if col >= 5 && col <= 10 {
+				return numberCellStyle
+			}
+			if col == 11 && row >= 0 && row < len(rows) && rows[row][11] != "built-in" {
+				return cellStyle.Foreground(lipgloss.Color("11"))
+			}
+			return cellStyle
+		})
+
+	fmt.Println(sectionStyle.Render("Models"))
+	fmt.Println(t)
+}
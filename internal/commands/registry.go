@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/lancekrogers/go-token-counter/internal/errors"
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+)
+
+// registryFileNames are the override file names looked up in the user
+// config directory (under tcount/) and the working directory.
+var (
+	userRegistryFileNames    = []string{"models.yaml", "models.yml", "models.json"}
+	projectRegistryFileNames = []string{".tcount.yaml", ".tcount.yml", ".tcount.json"}
+)
+
+// loadedRegistryFiles lists the override files applied, in order.
+var loadedRegistryFiles []string
+
+// loadRegistryOverrides applies registry override files: the first user
+// file found in the config directory, then the first project file in the
+// working directory, then the --registry file. Later files win.
+func loadRegistryOverrides(explicit string) error {
+	var paths []string
+	if dir, err := os.UserConfigDir(); err == nil {
+		if path := firstExisting(filepath.Join(dir, "tcount"), userRegistryFileNames); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if path := firstExisting(".", projectRegistryFileNames); path != "" {
+		paths = append(paths, path)
+	}
+	if explicit != "" {
+		paths = append(paths, explicit)
+	}
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return errors.IO("reading registry overrides", err).WithField("path", path)
+		}
+		overrides, err := tokenizer.LoadRegistryOverrides(path)
+		if err != nil {
+			return errors.Parse("invalid registry overrides", err).WithField("path", path)
+		}
+		if err := tokenizer.ApplyRegistryOverrides(overrides); err != nil {
+			return errors.Wrap(err, "applying registry overrides").WithField("path", path)
+		}
+		loadedRegistryFiles = append(loadedRegistryFiles, path)
+	}
+	return nil
+}
+
+// vocabFileForModel returns the SentencePiece vocab file to load for model:
+// the explicit --vocab-file if given, else the custom model's own.
+func vocabFileForModel(model, explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+	if meta := tokenizer.GetModelMetadata(model); meta != nil {
+		return meta.VocabFile
+	}
+	return ""
+}
+
+// firstExisting returns the first of names that exists in dir, or "".
+func firstExisting(dir string, names []string) string {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+
+	"github.com/lancekrogers/go-token-counter/internal/errors"
+	"github.com/lancekrogers/go-token-counter/internal/ui"
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+)
+
+type replayOptions struct {
+	model      string
+	cache      bool
+	cacheRead  float64
+	cacheWrite float64
+	jsonOutput bool
+}
+
+func newReplayCmd() *cobra.Command {
+	opts := &replayOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "replay [transcript]",
+		Short: "Replay a chat transcript and total the cost of every turn",
+		Long: `Walk a chat transcript turn by turn and total what it cost to produce.
+
+Agents resend the whole history on every call, so each assistant message is
+counted as one request: the messages before it (plus tools) are its input
+and the message itself is its output. The report lists the input and output
+tokens of every step, the cumulative spend, and the first step whose input
+and output exceed the model's context window.
+
+The transcript may be a chat request body or JSONL with one message per
+line; messages wrapped in a "message" field are unwrapped and lines without
+a role are skipped.
+
+With --cache, each request's prompt is assumed to be cached, so the next
+step reads the previous prompt from the cache and pays the write price only
+for the new tokens. Prompts under 1,024 tokens are not cached. Cache prices
+come from the model's registered cache-read and cache-write rates;
+--cache-read and --cache-write override them as fractions of the input
+price.`,
+		Example: `  tcount replay transcript.jsonl                      # Per-step input, output and cost
+  tcount replay --model claude-sonnet-4.6 --cache transcript.jsonl
+  tcount replay --cache --cache-read 0.25 transcript.jsonl
+  tcount replay --json transcript.jsonl               # Output as JSON`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReplay(cmd.Context(), args[0], opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.model, "model", "", "model to price the transcript for (overrides the transcript's model field)")
+	cmd.Flags().BoolVar(&opts.cache, "cache", false, "assume each request's prompt is cached for the next step")
+	cmd.Flags().Float64Var(&opts.cacheRead, "cache-read", 0, "cache read price as a fraction of the input price (0 uses the model's cache price)")
+	cmd.Flags().Float64Var(&opts.cacheWrite, "cache-write", 0, "cache write price as a fraction of the input price (0 uses the model's cache price)")
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+
+	return cmd
+}
+
+func runReplay(ctx context.Context, path string, opts *replayOptions) error {
+	display := ui.New(noColor, verbose)
+
+	if opts.cacheRead < 0 || opts.cacheWrite < 0 {
+		return fmt.Errorf("cache price multipliers must not be negative")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.IO("reading transcript", err).WithField("path", path)
+	}
+
+	req, err := tokenizer.ParseTranscript(data)
+	if err != nil {
+		return errors.Parse("invalid transcript", err).WithField("path", path)
+	}
+
+	model := opts.model
+	if model == "" {
+		model = req.Model
+	}
+	if model == "" {
+		model = defaultChatModel
+	}
+	if err := checkModel(display, model); err != nil {
+		return err
+	}
+	model = canonicalModel(model)
+
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{VocabFile: vocabFileForModel(model, "")})
+	if err != nil {
+		return errors.Wrap(err, "creating token counter")
+	}
+
+	result, err := counter.CountReplay(ctx, req, tokenizer.ReplayOptions{
+		Model:      model,
+		Cache:      opts.cache,
+		CacheRead:  opts.cacheRead,
+		CacheWrite: opts.cacheWrite,
+	})
+	if err != nil {
+		return errors.Wrap(err, "counting transcript tokens")
+	}
+
+	if opts.jsonOutput {
+		return outputJSON(result)
+	}
+
+	return outputReplayTable(path, result)
+}
+
+func outputReplayTable(path string, result *tokenizer.ReplayResult) error {
+	titleStyle, sectionStyle, labelStyle, valStyle := styles()
+
+	fmt.Println(titleStyle.Render("Transcript Replay for: " + path))
+	fmt.Println()
+
+	method := "exact"
+	if !result.IsExact {
+		method = "approximate"
+	}
+	fmt.Printf("  %s %s\n", labelStyle.Render("Model:"), valStyle.Render(result.Model))
+	fmt.Printf("  %s %s (%s)\n", labelStyle.Render("Encoding:"), valStyle.Render(result.Encoding), method)
+	fmt.Printf("  %s %s\n", labelStyle.Render("Messages:"), valStyle.Render(formatInt(result.Messages)))
+	fmt.Printf("  %s %s tokens\n", labelStyle.Render("Final transcript:"), valStyle.Render(formatInt(result.TranscriptTokens)))
+	fmt.Println()
+
+	cached := result.CacheRead > 0
+	if len(result.Steps) > 0 {
+		purple := lipgloss.Color("99")
+		headerStyle := lipgloss.NewStyle().Bold(true).Foreground(purple).Align(lipgloss.Center)
+		cellStyle := lipgloss.NewStyle().PaddingLeft(1).PaddingRight(1)
+		numberCellStyle := cellStyle.Align(lipgloss.Right)
+
+		headers := []string{"Step", "Input"}
+		if cached {
+			headers = append(headers, "Cached")
+		}
+		headers = append(headers, "Output", "Total Input", "Cost", "Total Cost")
+		if result.ContextWindow > 0 {
+			headers = append(headers, "Context")
+		}
+
+		rows := make([][]string, 0, len(result.Steps))
+		for _, s := range result.Steps {
+			row := []string{formatInt(s.Step), formatInt(s.InputTokens)}
+			if cached {
+				row = append(row, formatInt(s.CachedTokens))
+			}
+			row = append(row,
+				formatInt(s.OutputTokens),
+				formatInt(s.CumulativeInput),
+				fmt.Sprintf("$%.4f", s.Cost),
+				fmt.Sprintf("$%.4f", s.CumulativeCost),
+			)
+			if result.ContextWindow > 0 {
+				usage := fmt.Sprintf("%.1f%%", float64(s.InputTokens+s.OutputTokens)/float64(result.ContextWindow)*100)
+				if s.Overflow {
+					usage += " !"
+				}
+				row = append(row, usage)
+			}
+			rows = append(rows, row)
+		}
+
+		t := table.New().
+			Border(lipgloss.RoundedBorder()).
+			BorderStyle(lipgloss.NewStyle().Foreground(purple)).
+			Headers(headers...).
+			Rows(rows...).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == table.HeaderRow {
+					return headerStyle
+				}
+				return numberCellStyle
+			})
+
+		fmt.Println(sectionStyle.Render("Steps"))
+		fmt.Println(t)
+		fmt.Println()
+	}
+
+	fmt.Println(sectionStyle.Render("Totals"))
+	fmt.Printf("  %s %s\n", labelStyle.Render("Steps:"), valStyle.Render(formatInt(len(result.Steps))))
+	fmt.Printf("  %s %s\n", labelStyle.Render("Input tokens:"), valStyle.Render(formatInt(result.TotalInputTokens)))
+	if cached {
+		fmt.Printf("  %s %s (%.2fx read, %.2fx write)\n", labelStyle.Render("Cached tokens:"),
+			valStyle.Render(formatInt(result.CachedTokens)), result.CacheRead, result.CacheWrite)
+	}
+	fmt.Printf("  %s %s\n", labelStyle.Render("Output tokens:"), valStyle.Render(formatInt(result.TotalOutputTokens)))
+	fmt.Printf("  %s $%.4f\n", labelStyle.Render("Input cost:"), result.InputCost)
+	fmt.Printf("  %s $%.4f\n", labelStyle.Render("Output cost:"), result.OutputCost)
+	fmt.Printf("  %s $%.4f\n", labelStyle.Render("Total cost:"), result.TotalCost)
+	if cached {
+		fmt.Printf("  %s $%.4f\n", labelStyle.Render("Without caching:"), result.UncachedCost)
+	}
+
+	if result.ContextWindow > 0 {
+		if result.OverflowStep > 0 {
+			fmt.Printf("  %s step %d exceeds the %s token context window\n",
+				labelStyle.Render("Overflow:"), result.OverflowStep, formatInt(result.ContextWindow))
+		} else {
+			fmt.Printf("  %s fits the %s token context window\n", labelStyle.Render("Overflow:"), formatInt(result.ContextWindow))
+		}
+	}
+
+	return nil
+}
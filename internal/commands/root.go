@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
@@ -19,8 +22,10 @@ import (
 )
 
 var (
-	noColor bool
-	verbose bool
+	noColor      bool
+	verbose      bool
+	registryFile string
+	strictModels bool
 )
 
 type countOptions struct {
@@ -32,15 +37,31 @@ type countOptions struct {
 	showCost      bool
 	showModels    bool
 	recursive     bool
+	includeImages bool
+	includeAudio  bool
+	imageDetail   string
+	pdfOverhead   bool
+	noExtract     bool
 	charsPerToken float64
 	wordsPerToken float64
+	outputTokens  int
+	outputRatio   float64
+	cachedTokens  int
+	cacheHitRatio float64
+	serviceTier   string
+	costModels    []string
+	costAll       bool
+	asOf          string
+	maxTokens     int
+	maxCost       float64
+	maxContextPct float64
 }
 
 // Execute runs the root command with the given version string.
 func Execute(version string) {
 	if err := newRootCmd(version).Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(errors.ExitCode(err))
 	}
 }
 
@@ -62,21 +83,57 @@ Anthropic Claude models (Opus 4.6, Sonnet 4.6, Haiku 4.5, and earlier).
 When counting a directory with --recursive, the command:
   - Respects .gitignore files
   - Skips binary files automatically
-  - Returns aggregated totals for all text files`,
+  - Returns aggregated totals for all text files
+
+With --include-images, image files are sized locally and estimated with
+OpenAI's tile-based rules and Anthropic's width×height/750 formula.
+With --include-audio, audio durations are read from container headers and
+priced for every audio-capable model.
+PDF, Office (docx, xlsx, pptx), ODF and EPUB documents are counted from
+their extracted text unless --no-extract is set; --pdf-overhead adds the
+per-page image tokens Claude charges for PDF documents.
+
+--output-tokens or --output-ratio estimates the response as well: the cost
+table then prices input, output and the full request for every model.
+--cached-tokens or --cache-hit-ratio prices a cached prompt prefix with each
+model's cache-write and cache-read rates and shows the savings.
+--tier batch, flex or priority prices the request at that service tier
+alongside the standard estimate.
+
+The cost table prices the selected model and the main models of the
+--provider filter. --cost-models prices an explicit list instead, and
+--cost-all prices every model with pricing, cheapest first. The Source
+column shows whether each model's count is exact, an exact count from
+another encoding (proxy), or an estimate.
+
+--max-tokens, --max-cost and --max-context-percent set a budget for the
+selected model, or for every model in the cost table when no model is
+selected. Over budget, the report lists each limit exceeded and tcount
+exits with status 3.`,
 		Example: `  tcount document.md                                       # Count tokens in a file
   tcount --model gpt-4o doc.md                             # Use GPT-4o tokenizer
   tcount --model gpt-5 doc.md                              # Use GPT-5 tokenizer
   tcount --model claude-sonnet-4.6 doc.md                   # Use Claude Sonnet 4.6
   tcount --model llama-3.1-8b --vocab-file tokenizer.model doc.md  # SentencePiece
   tcount --all --cost doc.md                               # Show all methods with costs
+  tcount --cost --output-tokens 500 prompt.md              # Input, output and total cost
+  tcount --cost --cache-hit-ratio 0.8 prompt.md            # Prompt-caching line items
+  tcount --tier batch prompt.md                            # Batch pricing next to standard
+  tcount --cost-models gpt-4.1-mini,claude-haiku-4.5 doc.md # Price chosen models
+  tcount --cost-all --output-tokens 500 prompt.md          # Every priced model, cheapest first
+  tcount --model gpt-4o --max-tokens 8000 prompt.md        # Exit 3 if the prompt is over budget
   tcount --json doc.md                                     # Output as JSON
   tcount -r ./src                                          # Count all files in directory
+  tcount -r --include-images ./docs                        # Include image token estimates
+  tcount --include-audio meeting.mp3                       # Estimate audio input tokens
+  tcount --pdf-overhead spec.pdf                           # Count PDF text plus Claude page overhead
   tcount -r --models ./project                             # Show encoding→model lookup`,
 		Args: cobra.ExactArgs(1),
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			if noColor {
 				lipgloss.SetColorProfile(termenv.Ascii)
 			}
+			return loadRegistryOverrides(registryFile)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runCount(cmd.Context(), args[0], opts)
@@ -87,6 +144,8 @@ When counting a directory with --recursive, the command:
 
 	cmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable color output")
 	cmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "enable verbose output")
+	cmd.PersistentFlags().StringVar(&registryFile, "registry", "", "YAML or JSON file overriding model prices, context windows and encodings")
+	cmd.PersistentFlags().BoolVar(&strictModels, "strict", false, "fail on an unknown model instead of falling back to approximations")
 
 	cmd.Flags().StringVar(&opts.model, "model", "", `specific model to use
 
@@ -96,30 +155,59 @@ OpenAI Models:
   GPT-4o series:    gpt-4o, gpt-4o-mini
   o-series:         o3, o3-mini, o4-mini
   Legacy:           gpt-4, gpt-4-turbo, gpt-3.5-turbo
+  Embeddings:       text-embedding-3-small, text-embedding-3-large
 
 Anthropic Models:
   Opus:             claude-opus-4.6, claude-opus-4.5, claude-opus-4.1, claude-opus-4
   Sonnet:           claude-sonnet-4.6, claude-sonnet-4.5, claude-sonnet-4
-  Haiku:            claude-haiku-4.5, claude-haiku-3.5, claude-haiku-3
-  Legacy:           claude-opus-3
+  Haiku:            claude-haiku-4.5, claude-haiku-3
+  Legacy:           claude-opus-3, claude-sonnet-3.7, claude-sonnet-3.5, claude-haiku-3.5 (retired)
 
 Open Source Models (BPE approximation):
   Llama:            llama-3.1-8b, llama-3.1-70b, llama-3.1-405b, llama-4-scout, llama-4-maverick
   DeepSeek:         deepseek-v2, deepseek-v3, deepseek-coder-v2
   Qwen:             qwen-2.5-7b, qwen-2.5-14b, qwen-2.5-72b, qwen-3-72b
-  Phi:              phi-3-mini, phi-3-small, phi-3-medium`)
+  Phi:              phi-3-mini, phi-3-small, phi-3-medium
+
+Dated snapshots, provider prefixes and dashed versions are also accepted,
+e.g. claude-sonnet-4-5-20250929, openai/gpt-4.1, gpt-4o-2024-08-06`)
 	cmd.Flags().StringVar(&opts.vocabFile, "vocab-file", "", `path to SentencePiece .model file for exact tokenization
 Required for models that use SentencePiece (e.g., llama-3.1-8b)
 Download vocab files from HuggingFace (see error messages for URLs)`)
-	cmd.Flags().StringVar(&opts.provider, "provider", "all", `filter models by provider (openai, anthropic, meta, deepseek, alibaba, microsoft, all)`)
+	cmd.Flags().StringVar(&opts.provider, "provider", "all", `filter models by provider (openai, anthropic, meta, deepseek, alibaba, microsoft, a custom model's provider, all)`)
 	cmd.Flags().BoolVar(&opts.all, "all", false, "show all counting methods")
 	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
 	cmd.Flags().BoolVar(&opts.showCost, "cost", false, "include cost estimates")
-	cmd.Flags().BoolVarP(&opts.showModels, "models", "m", false, "show encoding-to-model lookup table")
+	cmd.Flags().BoolVarP(&opts.showModels, "models", "m", false, "show encoding-to-model lookup table (see tcount models for the full listing)")
 	cmd.Flags().BoolVarP(&opts.recursive, "recursive", "r", false, "recursively count tokens in directory")
 	cmd.Flags().BoolVarP(&opts.recursive, "directory", "d", false, "alias for --recursive")
+	cmd.Flags().BoolVar(&opts.includeImages, "include-images", false, "estimate image tokens (png, jpg, gif, webp) for vision models")
+	cmd.Flags().BoolVar(&opts.includeAudio, "include-audio", false, "estimate audio tokens (wav, mp3, flac, ogg) for audio-capable models")
+	cmd.Flags().BoolVar(&opts.pdfOverhead, "pdf-overhead", false, "add Claude's per-page PDF image overhead as a line item")
+	cmd.Flags().BoolVar(&opts.noExtract, "no-extract", false, "treat PDF, Office, ODF and EPUB documents as binary instead of extracting their text")
+	cmd.Flags().StringVar(&opts.imageDetail, "image-detail", tokenizer.ImageDetailAuto, "OpenAI image detail level for estimates (auto, low, high)")
 	cmd.Flags().Float64Var(&opts.charsPerToken, "chars-per-token", 4.0, "characters per token ratio")
 	cmd.Flags().Float64Var(&opts.wordsPerToken, "words-per-token", 0.75, "words per token ratio")
+	cmd.Flags().IntVar(&opts.outputTokens, "output-tokens", 0, "expected output tokens, priced at each model's output rate (implies --cost)")
+	cmd.Flags().Float64Var(&opts.outputRatio, "output-ratio", 0, "expected output tokens as a multiple of the input tokens (implies --cost)")
+	cmd.MarkFlagsMutuallyExclusive("output-tokens", "output-ratio")
+	cmd.Flags().IntVar(&opts.cachedTokens, "cached-tokens", 0, "input tokens in a cached prompt prefix, priced at cache rates (implies --cost)")
+	cmd.Flags().Float64Var(&opts.cacheHitRatio, "cache-hit-ratio", 0, "fraction of the input tokens read from the prompt cache (implies --cost)")
+	cmd.MarkFlagsMutuallyExclusive("cached-tokens", "cache-hit-ratio")
+	cmd.Flags().StringVar(&opts.serviceTier, "tier", string(tokenizer.ServiceTierStandard), "service tier priced alongside standard: standard, batch, flex, priority (non-standard implies --cost)")
+	cmd.Flags().StringSliceVar(&opts.costModels, "cost-models", nil, "comma-separated models to price instead of the default comparison set (implies --cost)")
+	cmd.Flags().BoolVar(&opts.costAll, "cost-all", false, "price every model with pricing, sorted cheapest first (implies --cost)")
+	cmd.MarkFlagsMutuallyExclusive("cost-models", "cost-all")
+	cmd.Flags().StringVar(&opts.asOf, "as-of", "", "price at the rates in force on this YYYY-MM-DD day (implies --cost)")
+	cmd.Flags().IntVar(&opts.maxTokens, "max-tokens", 0, "fail with exit status 3 when the input exceeds this many tokens")
+	cmd.Flags().Float64Var(&opts.maxCost, "max-cost", 0, "fail with exit status 3 when the total request cost exceeds this many USD (implies --cost)")
+	cmd.Flags().Float64Var(&opts.maxContextPct, "max-context-percent", 0, "fail with exit status 3 when input and output exceed this percentage of the context window")
+
+	cmd.AddCommand(newChatCmd())
+	cmd.AddCommand(newBatchCmd())
+	cmd.AddCommand(newFinetuneCmd())
+	cmd.AddCommand(newReplayCmd())
+	cmd.AddCommand(newModelsCmd())
 
 	return cmd
 }
@@ -129,12 +217,56 @@ func isValidModel(model string) bool {
 	if model == "" {
 		return true
 	}
-	for _, valid := range tokenizer.ListModels() {
-		if model == valid {
-			return true
+	return tokenizer.GetModelMetadata(model) != nil
+}
+
+// checkModel warns that an unknown model falls back to approximations,
+// suggesting close registry names, or fails with tokenizer.ErrModelNotFound
+// under --strict. Deprecated and retired models get a warning too.
+func checkModel(display *ui.UI, model string) error {
+	if meta := tokenizer.GetModelMetadata(model); meta != nil {
+		switch meta.Status(time.Now()) {
+		case tokenizer.ModelStatusRetired:
+			display.Warning("%s was retired on %s and is no longer served by its provider", meta.Name, meta.RetirementDate.Format(time.DateOnly))
+		case tokenizer.ModelStatusDeprecated:
+			if meta.RetirementDate.IsZero() {
+				display.Warning("%s is deprecated", meta.Name)
+			} else {
+				display.Warning("%s is deprecated and will be retired on %s", meta.Name, meta.RetirementDate.Format(time.DateOnly))
+			}
 		}
+		return nil
 	}
-	return false
+	if model == "" {
+		return nil
+	}
+	if strictModels {
+		return unknownModelError(model)
+	}
+	if hint := tokenizer.DidYouMean(tokenizer.SuggestModels(model)); hint != "" {
+		display.Warning("Unknown model '%s', using approximation methods (%s)", model, hint)
+	} else {
+		display.Warning("Unknown model '%s', using approximation methods", model)
+	}
+	return nil
+}
+
+// unknownModelError reports a model that is not in the registry, naming it
+// and suggesting close registry names.
+func unknownModelError(model string) error {
+	return errors.Wrap(tokenizer.ErrModelNotFound, "unknown model "+strconv.Quote(model)).
+		WithCode(errors.ErrCodeNotFound).
+		WithField("model", model).
+		WithHint(tokenizer.DidYouMean(tokenizer.SuggestModels(model)))
+}
+
+// canonicalModel returns the registered name of a model given by alias,
+// snapshot or provider-prefixed name, or model unchanged if it is unknown.
+func canonicalModel(model string) string {
+	if name, ok := tokenizer.ResolveModelName(model); ok {
+		return name
+	}
+	return model
 }
 
 // sentencePieceVocabURLs maps model prefixes to their HuggingFace vocab download URLs.
@@ -143,13 +275,19 @@ var sentencePieceVocabURLs = map[string]string{
 	"llama-4":   "https://huggingface.co/meta-llama/Llama-4-Scout-17B-16E/blob/main/tokenizer.model",
 }
 
-// isValidProvider checks if a provider name is valid.
+// isValidProvider checks if a provider name is valid. Providers of custom
+// models from registry overrides are valid too.
 func isValidProvider(provider string) bool {
 	for _, valid := range validProviders {
 		if provider == valid {
 			return true
 		}
 	}
+	for _, registered := range tokenizer.ListProviders() {
+		if provider == string(registered) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -164,6 +302,37 @@ func requiresSentencePiece(model string) (bool, string) {
 	return false, ""
 }
 
+// validImageDetails lists accepted values for the --image-detail flag.
+var validImageDetails = []string{tokenizer.ImageDetailAuto, tokenizer.ImageDetailLow, tokenizer.ImageDetailHigh}
+
+// isValidImageDetail checks if an image detail level is valid.
+func isValidImageDetail(detail string) bool {
+	for _, valid := range validImageDetails {
+		if detail == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// validServiceTiers lists accepted values for the --tier flag.
+var validServiceTiers = []string{
+	string(tokenizer.ServiceTierStandard),
+	string(tokenizer.ServiceTierBatch),
+	string(tokenizer.ServiceTierFlex),
+	string(tokenizer.ServiceTierPriority),
+}
+
+// isValidServiceTier checks if a service tier name is valid.
+func isValidServiceTier(tier string) bool {
+	for _, valid := range validServiceTiers {
+		if tier == valid {
+			return true
+		}
+	}
+	return false
+}
+
 // validProviders lists accepted values for the --provider flag.
 var validProviders = []string{"openai", "anthropic", "meta", "deepseek", "alibaba", "microsoft", "all"}
 
@@ -174,9 +343,50 @@ func runCount(ctx context.Context, path string, opts *countOptions) error {
 		return fmt.Errorf("invalid provider %q, valid options: %s", opts.provider, strings.Join(validProviders, ", "))
 	}
 
-	if !isValidModel(opts.model) {
-		display.Warning("Unknown model '%s', using approximation methods", opts.model)
+	if !isValidImageDetail(opts.imageDetail) {
+		return fmt.Errorf("invalid image detail %q, valid options: %s", opts.imageDetail, strings.Join(validImageDetails, ", "))
+	}
+
+	if !isValidServiceTier(opts.serviceTier) {
+		return fmt.Errorf("invalid tier %q, valid options: %s", opts.serviceTier, strings.Join(validServiceTiers, ", "))
+	}
+
+	if opts.outputTokens < 0 || opts.outputRatio < 0 {
+		return errors.Validation("output token estimates must not be negative")
+	}
+
+	if opts.cachedTokens < 0 || opts.cacheHitRatio < 0 || opts.cacheHitRatio > 1 {
+		return errors.Validation("cached tokens must not be negative and the cache hit ratio must be between 0 and 1")
+	}
+
+	if opts.maxTokens < 0 || opts.maxCost < 0 || opts.maxContextPct < 0 {
+		return errors.Validation("budget limits must not be negative")
+	}
+
+	var asOf time.Time
+	if opts.asOf != "" {
+		var err error
+		asOf, err = time.Parse(time.DateOnly, opts.asOf)
+		if err != nil {
+			return errors.Validation("invalid --as-of date").
+				WithField("as_of", opts.asOf).
+				WithHint("use YYYY-MM-DD, e.g. 2025-03-31")
+		}
+	}
+
+	for i, model := range opts.costModels {
+		if model == "" || !isValidModel(model) {
+			return errors.Validation("unknown model "+strconv.Quote(model)+" in --cost-models").
+				WithField("model", model).
+				WithHint(tokenizer.DidYouMean(tokenizer.SuggestModels(model)))
+		}
+		opts.costModels[i] = canonicalModel(model)
+	}
+
+	if err := checkModel(display, opts.model); err != nil {
+		return err
 	}
+	opts.model = canonicalModel(opts.model)
 
 	info, err := os.Stat(path)
 	if err != nil {
@@ -185,6 +395,9 @@ func runCount(ctx context.Context, path string, opts *countOptions) error {
 
 	var content []byte
 	var fileCount int
+	var images, audio []string
+	var pdfs []fileops.PDFPages
+	var extracted bool
 	isDirectory := info.IsDir()
 
 	if isDirectory {
@@ -197,21 +410,70 @@ func runCount(ctx context.Context, path string, opts *countOptions) error {
 			return errors.IO("walking directory", err).WithField("path", path)
 		}
 
-		if len(walkResult.Files) == 0 {
+		if opts.includeImages {
+			images = walkResult.Images
+		}
+		if opts.includeAudio {
+			audio = walkResult.Audio
+		}
+
+		files := walkResult.Files
+		if !opts.noExtract {
+			files = append(files, walkResult.Documents...)
+		}
+
+		if len(files) == 0 && len(images) == 0 && len(audio) == 0 {
 			return errors.NotFound("text files in directory").WithField("path", path)
 		}
 
 		if verbose {
 			display.Info("Found %d text files (skipped %d binary, %d ignored)",
 				len(walkResult.Files), walkResult.SkippedBinary, walkResult.SkippedIgnore)
+			if len(walkResult.Documents) > 0 && !opts.noExtract {
+				display.Info("Extracting text from %d documents", len(walkResult.Documents))
+			}
+			if len(images) > 0 {
+				display.Info("Estimating %d image files", len(images))
+			}
+			if len(audio) > 0 {
+				display.Info("Estimating %d audio files", len(audio))
+			}
 		}
 
-		content, err = fileops.AggregateFileContents(ctx, walkResult.Files)
+		agg, err := fileops.AggregateFiles(ctx, files)
 		if err != nil {
 			return errors.IO("reading files", err).WithField("path", path)
 		}
+		content, pdfs = agg.Content, agg.PDFs
 
-		fileCount = len(walkResult.Files)
+		fileCount = len(files) + len(images) + len(audio)
+	} else if fileops.IsImageFile(path) {
+		if !opts.includeImages {
+			return errors.Validation("file is an image").
+				WithField("path", path).
+				WithHint("use --include-images to estimate image tokens")
+		}
+		images = []string{path}
+		fileCount = 1
+	} else if fileops.IsAudioFile(path) {
+		if !opts.includeAudio {
+			return errors.Validation("file is audio").
+				WithField("path", path).
+				WithHint("use --include-audio to estimate audio tokens")
+		}
+		audio = []string{path}
+		fileCount = 1
+	} else if !opts.noExtract && fileops.IsDocumentFile(path) {
+		doc, err := fileops.ExtractDocument(path)
+		if err != nil {
+			return errors.IO("extracting document text", err).WithField("path", path)
+		}
+		content = []byte(doc.Text)
+		if doc.Format == "pdf" {
+			pdfs = []fileops.PDFPages{{Path: path, Pages: doc.Pages}}
+		}
+		extracted = true
+		fileCount = 1
 	} else {
 		content, err = os.ReadFile(path)
 		if err != nil {
@@ -220,6 +482,9 @@ func runCount(ctx context.Context, path string, opts *countOptions) error {
 		fileCount = 1
 	}
 
+	// Custom models may name their own vocab file; --vocab-file wins.
+	opts.vocabFile = vocabFileForModel(opts.model, opts.vocabFile)
+
 	// Check if model requires SentencePiece and validate vocab-file flag
 	if needsSP, downloadURL := requiresSentencePiece(opts.model); needsSP && opts.vocabFile == "" {
 		return fmt.Errorf(
@@ -233,39 +498,141 @@ func runCount(ctx context.Context, path string, opts *countOptions) error {
 	}
 
 	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{
-		CharsPerToken: opts.charsPerToken,
-		WordsPerToken: opts.wordsPerToken,
-		VocabFile:     opts.vocabFile,
-		Provider:      tokenizer.Provider(opts.provider),
+		CharsPerToken:   opts.charsPerToken,
+		WordsPerToken:   opts.wordsPerToken,
+		VocabFile:       opts.vocabFile,
+		Provider:        tokenizer.Provider(opts.provider),
+		IncludeImages:   opts.includeImages,
+		IncludeAudio:    opts.includeAudio,
+		ImageDetail:     opts.imageDetail,
+		PDFPageOverhead: opts.pdfOverhead,
+		NoExtract:       opts.noExtract,
 	})
 	if err != nil {
 		return errors.Wrap(err, "creating token counter")
 	}
 
-	result, err := counter.Count(ctx, string(content), opts.model, opts.all)
-	if err != nil {
-		return errors.Wrap(err, "counting tokens")
+	hasMedia := len(images) > 0 || len(audio) > 0
+	result := &tokenizer.CountResult{Methods: []tokenizer.MethodResult{}}
+	if len(content) > 0 || !hasMedia {
+		result, err = counter.Count(ctx, string(content), opts.model, opts.all)
+		if err != nil {
+			return errors.Wrap(err, "counting tokens")
+		}
+	}
+
+	if len(images) > 0 {
+		media, err := counter.EstimateImages(ctx, images)
+		if err != nil {
+			return errors.Wrap(err, "estimating images")
+		}
+		result.Media = append(result.Media, media...)
+	}
+	if len(audio) > 0 {
+		media, err := counter.EstimateAudioFiles(ctx, audio)
+		if err != nil {
+			return errors.Wrap(err, "estimating audio")
+		}
+		result.Media = append(result.Media, media...)
 	}
 
+	for _, p := range pdfs {
+		result.Pages += p.Pages
+	}
+	result.Media = append(result.Media, counter.EstimatePDFPages(pdfs)...)
+
 	result.FilePath = path
 	result.FileSize = len(content)
+	if !isDirectory && (hasMedia || extracted) {
+		result.FileSize = int(info.Size())
+	}
 	result.IsDirectory = isDirectory
 	if isDirectory {
 		result.FileCount = fileCount
 	}
 
-	if opts.showCost {
-		result.Costs = tokenizer.CalculateCosts(result.Methods)
+	serviceTier := tokenizer.ServiceTier(opts.serviceTier)
+	if opts.showCost || opts.outputTokens > 0 || opts.outputRatio > 0 || opts.cachedTokens > 0 || opts.cacheHitRatio > 0 ||
+		serviceTier != tokenizer.ServiceTierStandard || len(opts.costModels) > 0 || opts.costAll || !asOf.IsZero() || hasBudget(opts) {
+		result.Costs = tokenizer.CalculateCostsWithOptions(result.Methods, tokenizer.CostOptions{
+			Models:        opts.costModels,
+			AllModels:     opts.costAll,
+			Model:         opts.model,
+			Provider:      tokenizer.Provider(opts.provider),
+			OutputTokens:  opts.outputTokens,
+			OutputRatio:   opts.outputRatio,
+			CachedTokens:  opts.cachedTokens,
+			CacheHitRatio: opts.cacheHitRatio,
+			ServiceTier:   serviceTier,
+			AsOf:          asOf,
+		})
+	}
+
+	if hasBudget(opts) {
+		result.Budget = tokenizer.CheckBudget(result, tokenizer.Budget{
+			MaxTokens:         opts.maxTokens,
+			MaxCost:           opts.maxCost,
+			MaxContextPercent: opts.maxContextPct,
+			Model:             opts.model,
+		})
+		if len(result.Budget.Unchecked) > 0 {
+			return uncheckedBudgetError(opts, result.Budget.Unchecked[0])
+		}
 	}
 
 	if opts.jsonOutput {
-		return outputJSON(result)
+		err = outputJSON(result)
+	} else {
+		err = outputTable(display, result, opts.showModels, serviceTier)
+	}
+	if err != nil {
+		return err
 	}
 
-	return outputTable(display, result, opts.showModels)
+	if result.Budget != nil && result.Budget.Exceeded {
+		return errors.Budget("input exceeds the budget").
+			WithField("violations", len(result.Budget.Violations))
+	}
+	return nil
 }
 
-func outputJSON(result *tokenizer.CountResult) error {
+// uncheckedBudgetError explains why a budget limit could not be checked:
+// the selected model has no pricing or context window, or without --model
+// the cost selection holds no priced models.
+func uncheckedBudgetError(opts *countOptions, limit string) error {
+	flag, missing := "--max-cost", "pricing"
+	if limit == tokenizer.BudgetLimitContext {
+		flag, missing = "--max-context-percent", "context window"
+	}
+
+	if opts.model != "" {
+		hint := "use --model with a priced model, or a registry override with its prices"
+		if limit == tokenizer.BudgetLimitContext {
+			hint = "use --model with a model that has a context window, or a registry override with one"
+		}
+		return errors.Validation(flag+" cannot be checked: model "+strconv.Quote(opts.model)+" has no "+missing).
+			WithField("model", opts.model).
+			WithHint(hint)
+	}
+
+	selection := "the cost selection"
+	switch {
+	case len(opts.costModels) > 0:
+		selection = "--cost-models " + strconv.Quote(strings.Join(opts.costModels, ","))
+	case opts.provider != "all":
+		selection = "--provider " + strconv.Quote(opts.provider)
+	}
+	return errors.Validation(flag+" cannot be checked: "+selection+" contains no priced models").
+		WithField("provider", opts.provider).
+		WithHint("use --model with a priced model, or a --provider or --cost-models selection with pricing")
+}
+
+// hasBudget reports whether any budget limit was set.
+func hasBudget(opts *countOptions) bool {
+	return opts.maxTokens > 0 || opts.maxCost > 0 || opts.maxContextPct > 0
+}
+
+func outputJSON(result any) error {
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(result)
@@ -283,7 +650,7 @@ func styles() (title, section, label, valStyle lipgloss.Style) {
 	return
 }
 
-func outputTable(_ *ui.UI, result *tokenizer.CountResult, showModels bool) error {
+func outputTable(_ *ui.UI, result *tokenizer.CountResult, showModels bool, serviceTier tokenizer.ServiceTier) error {
 	titleStyle, sectionStyle, labelStyle, valStyle := styles()
 
 	// Title
@@ -302,6 +669,9 @@ func outputTable(_ *ui.UI, result *tokenizer.CountResult, showModels bool) error
 	fmt.Printf("  %s %s\n", labelStyle.Render("Characters:"), valStyle.Render(formatInt(result.Characters)))
 	fmt.Printf("  %s %s\n", labelStyle.Render("Words:"), valStyle.Render(formatInt(result.Words)))
 	fmt.Printf("  %s %s\n", labelStyle.Render("Lines:"), valStyle.Render(formatInt(result.Lines)))
+	if result.Pages > 0 {
+		fmt.Printf("  %s %s\n", labelStyle.Render("Pages:"), valStyle.Render(formatInt(result.Pages)))
+	}
 	fmt.Println()
 
 	// Build token table rows
@@ -349,18 +719,36 @@ func outputTable(_ *ui.UI, result *tokenizer.CountResult, showModels bool) error
 			return cellStyle
 		})
 
-	fmt.Println(sectionStyle.Render("Token Counts by Method"))
-	fmt.Println(t)
+	if len(rows) > 0 {
+		fmt.Println(sectionStyle.Render("Token Counts by Method"))
+		fmt.Println(t)
+	}
+
+	for _, method := range result.Methods {
+		if method.ContextWindow > 0 {
+			fmt.Println()
+			fmt.Println(sectionStyle.Render("Context"))
+			pct := float64(method.Tokens) / float64(method.ContextWindow) * 100
+			fmt.Printf("  %s %s (%.1f%% used)\n", labelStyle.Render("Context window:"), valStyle.Render(formatInt(method.ContextWindow)), pct)
+			fmt.Printf("  %s %s tokens\n", labelStyle.Render("Output headroom:"), valStyle.Render(formatInt(method.OutputHeadroom)))
+			break
+		}
+	}
+
+	if len(result.Media) > 0 {
+		fmt.Println()
+		outputMedia(sectionStyle, labelStyle, result.Media)
+	}
 
 	// Cost section
 	if len(result.Costs) > 0 {
 		fmt.Println()
-		fmt.Println(sectionStyle.Render("Cost Estimates (Input)"))
-		for _, cost := range result.Costs {
-			fmt.Printf("  %s $%.4f ($%.2f/1M tokens)\n",
-				labelStyle.Render(cost.Model+":"),
-				cost.Cost, cost.RatePer1M)
-		}
+		outputCosts(sectionStyle, labelStyle, result.Costs, serviceTier)
+	}
+
+	if result.Budget != nil {
+		fmt.Println()
+		outputBudget(sectionStyle, labelStyle, result.Budget)
 	}
 
 	// Model lookup
@@ -372,7 +760,229 @@ func outputTable(_ *ui.UI, result *tokenizer.CountResult, showModels bool) error
 	return nil
 }
 
+// outputCosts prints the cost table for every priced model, with a column
+// for a non-standard service tier, notes on historical prices, warm-cache
+// totals and long-context tiers, and the prompt-caching line items when a
+// cached prefix was given.
+func outputCosts(sectionStyle, labelStyle lipgloss.Style, costs []tokenizer.CostEstimate, serviceTier tokenizer.ServiceTier) {
+	showTier := serviceTier != tokenizer.ServiceTierStandard
+	headers := []string{"Model", "Source", "Input Tokens", "Input Cost", "Output Tokens", "Output Cost", "Total Cost"}
+	if showTier {
+		headers = append(headers, "Cost ("+string(serviceTier)+")")
+	}
+
+	rows := make([][]string, 0, len(costs))
+	for _, cost := range costs {
+		row := []string{
+			cost.Model,
+			cost.TokenSource,
+			formatInt(cost.Tokens),
+			fmt.Sprintf("$%.4f", cost.Cost),
+			formatInt(cost.OutputTokens),
+			fmt.Sprintf("$%.4f", cost.OutputCost),
+			fmt.Sprintf("$%.4f", cost.TotalCost),
+		}
+		if showTier {
+			tierCost := "n/a"
+			if cost.ServiceTier != "" {
+				tierCost = fmt.Sprintf("$%.4f", cost.ServiceTierCost)
+			}
+			row = append(row, tierCost)
+		}
+		rows = append(rows, row)
+	}
+
+	purple := lipgloss.Color("99")
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(purple).Align(lipgloss.Center)
+	cellStyle := lipgloss.NewStyle().PaddingLeft(1).PaddingRight(1)
+	numberCellStyle := cellStyle.Align(lipgloss.Right)
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(purple)).
+		Headers(headers...).
+		Rows(rows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			if col <= 1 {
+				return cellStyle
+			}
+			return numberCellStyle
+		})
+
+	fmt.Println(sectionStyle.Render("Cost Estimates"))
+	fmt.Println(t)
+	if len(costs) > 0 && costs[0].PricesAsOf != "" {
+		fmt.Printf("  %s %s\n", labelStyle.Render("Prices as of:"), costs[0].PricesAsOf)
+	}
+	for _, cost := range costs {
+		if cost.WarmCache {
+			fmt.Printf("  %s assumes a warm cache; the first request pays Cache Write instead of Cache Read\n",
+				labelStyle.Render("Total Cost:"))
+			break
+		}
+	}
+	for _, cost := range costs {
+		if cost.TierAbove > 0 {
+			fmt.Printf("  %s long-context rates for prompts over %s tokens ($%.2f/1M input, $%.2f/1M output)\n",
+				labelStyle.Render(cost.Model+":"), formatInt(cost.TierAbove), cost.RatePer1M, cost.OutputRatePer1M)
+		}
+	}
+
+	cacheRows := make([][]string, 0, len(costs))
+	for _, cost := range costs {
+		if cost.CachedTokens == 0 {
+			continue
+		}
+		cacheRows = append(cacheRows, []string{
+			cost.Model,
+			formatInt(cost.CachedTokens),
+			fmt.Sprintf("$%.4f", cost.UncachedCost),
+			fmt.Sprintf("$%.4f", cost.CacheWriteCost),
+			fmt.Sprintf("$%.4f", cost.CacheReadCost),
+			fmt.Sprintf("$%.4f", cost.CacheSavings),
+		})
+	}
+	if len(cacheRows) == 0 {
+		return
+	}
+
+	cacheTable := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(purple)).
+		Headers("Model", "Cached Tokens", "Uncached", "Cache Write", "Cache Read", "Savings").
+		Rows(cacheRows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			if col == 0 {
+				return cellStyle
+			}
+			return numberCellStyle
+		})
+
+	fmt.Println()
+	fmt.Println(sectionStyle.Render("Prompt Caching (per request)"))
+	fmt.Println(cacheTable)
+}
+
+// outputBudget prints the budget limits and, when they are exceeded, an
+// over-budget table with one row per model and limit.
+func outputBudget(sectionStyle, labelStyle lipgloss.Style, budget *tokenizer.BudgetResult) {
+	if !budget.Exceeded {
+		fmt.Println(sectionStyle.Render("Budget"))
+		fmt.Printf("  %s %s\n", labelStyle.Render("Status:"), "within budget")
+		return
+	}
+
+	rows := make([][]string, 0, len(budget.Violations))
+	for _, v := range budget.Violations {
+		model := v.Model
+		if model == "" {
+			model = "-"
+		}
+		var value, limit string
+		switch v.Limit {
+		case tokenizer.BudgetLimitCost:
+			// The cost table's four places, or more when the limit was
+			// given with more.
+			places := decimalPlaces(v.Max, 4)
+			value, limit = fmt.Sprintf("$%.*f", places, v.Value), fmt.Sprintf("$%.*f", places, v.Max)
+		case tokenizer.BudgetLimitContext:
+			places := decimalPlaces(v.Max, 1)
+			value, limit = fmt.Sprintf("%.*f%%", places, v.Value), fmt.Sprintf("%.*f%%", places, v.Max)
+		default:
+			value, limit = formatInt(int(v.Value)), formatInt(int(v.Max))
+		}
+		rows = append(rows, []string{model, v.Limit, value, limit})
+	}
+
+	red := lipgloss.Color("9")
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(red).Align(lipgloss.Center)
+	cellStyle := lipgloss.NewStyle().PaddingLeft(1).PaddingRight(1)
+	numberCellStyle := cellStyle.Align(lipgloss.Right)
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(red)).
+		Headers("Model", "Limit", "Value", "Max").
+		Rows(rows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			if col <= 1 {
+				return cellStyle
+			}
+			return numberCellStyle
+		})
+
+	fmt.Println(sectionStyle.Foreground(red).Render("Over Budget"))
+	fmt.Println(t)
+}
+
+// outputMedia prints per-file media estimates and totals. Image totals are
+// grouped by provider and audio totals by model.
+func outputMedia(sectionStyle, labelStyle lipgloss.Style, media []tokenizer.MediaEstimate) {
+	fmt.Println(sectionStyle.Render("Media Estimates"))
+
+	totals := make(map[string]int)
+	costs := make(map[string]float64)
+	var keys []string
+	for _, m := range media {
+		key := string(m.Provider)
+		detail := string(m.Provider)
+		if m.Model != "" {
+			key = m.Model
+			detail = m.Model
+		}
+		if m.Width > 0 && m.Height > 0 {
+			detail += fmt.Sprintf(" %dx%d", m.Width, m.Height)
+		}
+		if m.Detail != "" {
+			detail += ", detail " + m.Detail
+		}
+		if m.Pages > 0 {
+			detail += fmt.Sprintf(", %d pages", m.Pages)
+		}
+		if m.DurationSeconds > 0 {
+			detail += fmt.Sprintf(", %.1fs", m.DurationSeconds)
+		}
+		if m.Cost > 0 {
+			detail += fmt.Sprintf(", $%.4f", m.Cost)
+		}
+		fmt.Printf("  %s %s tokens (%s)\n", labelStyle.Render(m.Path+":"), formatInt(m.Tokens), detail)
+
+		if _, ok := totals[key]; !ok {
+			keys = append(keys, key)
+		}
+		totals[key] += m.Tokens
+		costs[key] += m.Cost
+	}
+
+	for _, k := range keys {
+		if costs[k] > 0 {
+			fmt.Printf("  %s %s tokens ($%.4f)\n", labelStyle.Render("Total ("+k+"):"), formatInt(totals[k]), costs[k])
+		} else {
+			fmt.Printf("  %s %s tokens\n", labelStyle.Render("Total ("+k+"):"), formatInt(totals[k]))
+		}
+	}
+}
+
 // formatInt formats an integer with comma thousand separators.
+// decimalPlaces returns the number of decimal places needed to print v
+// exactly, but at least minPlaces.
+func decimalPlaces(v float64, minPlaces int) int {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return max(len(s)-i-1, minPlaces)
+	}
+	return minPlaces
+}
+
 func formatInt(n int) string {
 	if n < 0 {
 		return "-" + formatInt(-n)
@@ -402,7 +1012,15 @@ func outputModelLookup(sectionStyle, labelStyle lipgloss.Style) {
 	byEncoding := tokenizer.ModelsByEncoding()
 
 	order := []string{"o200k_base", "cl100k_base", "claude_approx"}
-	for _, enc := range order {
+	var custom []string
+	for enc := range byEncoding {
+		if !containsEncoding(order, enc) {
+			custom = append(custom, enc)
+		}
+	}
+	sort.Strings(custom)
+
+	for _, enc := range append(order, custom...) {
 		models, ok := byEncoding[enc]
 		if !ok {
 			continue
@@ -410,3 +1028,13 @@ func outputModelLookup(sectionStyle, labelStyle lipgloss.Style) {
 		fmt.Printf("  %s %s\n", labelStyle.Render(enc+":"), strings.Join(models, ", "))
 	}
 }
+
+// containsEncoding reports whether encodings contains enc.
+func containsEncoding(encodings []string, enc string) bool {
+	for _, e := range encodings {
+		if e == enc {
+			return true
+		}
+	}
+	return false
+}
@@ -151,10 +151,33 @@ func TestNewRootCmd(t *testing.T) {
 	}
 
 	// Verify flags exist
-	flags := []string{"model", "vocab-file", "provider", "all", "json", "cost", "models", "recursive", "no-color", "verbose"}
+	flags := []string{"model", "vocab-file", "provider", "all", "json", "cost", "models", "recursive", "include-images", "include-audio", "image-detail", "pdf-overhead", "no-extract", "output-tokens", "output-ratio", "cached-tokens", "cache-hit-ratio", "tier", "cost-models", "cost-all", "as-of", "max-tokens", "max-cost", "max-context-percent", "no-color", "verbose", "registry", "strict"}
 	for _, flag := range flags {
 		if cmd.Flags().Lookup(flag) == nil && cmd.PersistentFlags().Lookup(flag) == nil {
 			t.Errorf("Flag --%s not found", flag)
 		}
 	}
 }
+
+func TestResolveChatFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		model  string
+		want   string
+	}{
+		{"auto", "gpt-4o", "openai"},
+		{"auto", "claude-sonnet-4.6", "anthropic"},
+		{"auto", "claude-sonnet-4-5-20250929", "anthropic"},
+		{"auto", "llama-3.1-8b", "openai"},
+		{"openai", "claude-opus-4.6", "openai"},
+		{"anthropic", "gpt-4o", "anthropic"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+"/"+tt.model, func(t *testing.T) {
+			if got := resolveChatFormat(tt.format, tt.model); got != tt.want {
+				t.Errorf("resolveChatFormat(%q, %q) = %q, want %q", tt.format, tt.model, got, tt.want)
+			}
+		})
+	}
+}
@@ -11,9 +11,17 @@ const (
 	ErrCodeValidation = "VALIDATION"
 	ErrCodeIO         = "IO"
 	ErrCodeParse      = "PARSE"
+	ErrCodeBudget     = "BUDGET"
 	ErrCodeInternal   = "INTERNAL"
 )
 
+// Process exit codes. Budget failures get their own status so CI can tell
+// an over-budget input from a failed run.
+const (
+	ExitFailure = 1
+	ExitBudget  = 3
+)
+
 // Error is a structured error type with code, context, and chain support.
 type Error struct {
 	Code    string
@@ -127,6 +135,15 @@ func Parse(message string, err error) *Error {
 	}
 }
 
+// Budget creates a BUDGET error for input over a token or cost budget.
+func Budget(message string) *Error {
+	return &Error{
+		Code:    ErrCodeBudget,
+		Message: message,
+		Fields:  make(map[string]any),
+	}
+}
+
 // Code extracts the error code from any error.
 func Code(err error) string {
 	if err == nil {
@@ -137,3 +154,16 @@ func Code(err error) string {
 	}
 	return ErrCodeInternal
 }
+
+// ExitCode returns the process exit status for an error: 0 for nil,
+// ExitBudget for BUDGET errors and ExitFailure otherwise.
+func ExitCode(err error) int {
+	switch Code(err) {
+	case "":
+		return 0
+	case ErrCodeBudget:
+		return ExitBudget
+	default:
+		return ExitFailure
+	}
+}
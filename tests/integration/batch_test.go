@@ -0,0 +1,118 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+)
+
+func TestIntegrationBatch_Summary(t *testing.T) {
+	file := fixturesDir(t) + "/batch/requests.jsonl"
+	stdout, stderr, exitCode := runTcount(t, "batch", "--json", file)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+
+	var result tokenizer.BatchResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nraw: %s", err, stdout)
+	}
+
+	if result.Requests != 5 {
+		t.Errorf("requests = %d, want 5", result.Requests)
+	}
+	if len(result.Errors) != 2 {
+		t.Errorf("errors = %d, want 2 (missing model, invalid JSON): %+v", len(result.Errors), result.Errors)
+	}
+	if len(result.Models) != 4 {
+		t.Fatalf("models = %d, want 4", len(result.Models))
+	}
+	if !containsModel(result.Models, "gpt-4o-mini") {
+		t.Errorf("expected the snapshot under gpt-4o-mini: %+v", result.Models)
+	}
+	if math.Abs(result.BatchCost-result.StandardCost*tokenizer.BatchDiscount) > 1e-12 {
+		t.Errorf("batch cost %v is not the discounted standard cost %v", result.BatchCost, result.StandardCost)
+	}
+
+	for _, m := range result.Models {
+		switch m.Model {
+		case "gpt-4o":
+			if m.Requests != 2 || m.Encoding != "o200k_base" {
+				t.Errorf("gpt-4o summary = %+v", m)
+			}
+		case "gpt-4o-mini":
+			// Dated snapshots are summarized under their base model.
+			if m.ContextWindow != 128000 || m.StandardCost <= 0 {
+				t.Errorf("snapshot summary = %+v", m)
+			}
+		case "text-embedding-3-small":
+			if m.Encoding != "cl100k_base" || m.InputTokens != 4 {
+				t.Errorf("embeddings summary = %+v", m)
+			}
+		}
+	}
+}
+
+func TestIntegrationBatch_OverContext(t *testing.T) {
+	long := strings.Repeat("token ", 9000)
+	jsonl := fmt.Sprintf(`{"custom_id":"big","url":"/v1/chat/completions","body":{"model":"gpt-4","messages":[{"role":"user","content":%q}]}}`+"\n"+
+		`{"custom_id":"small","url":"/v1/chat/completions","body":{"model":"gpt-4","messages":[{"role":"user","content":"hi"}]}}`, long)
+
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{})
+	if err != nil {
+		t.Fatalf("NewCounter() error: %v", err)
+	}
+	result, err := counter.CountBatch(context.Background(), strings.NewReader(jsonl))
+	if err != nil {
+		t.Fatalf("CountBatch() error: %v", err)
+	}
+
+	if len(result.OverContext) != 1 || result.OverContext[0].CustomID != "big" {
+		t.Fatalf("expected only request %q over context, got %+v", "big", result.OverContext)
+	}
+	if result.Models[0].OverContext != 1 || result.Models[0].Requests != 2 {
+		t.Errorf("gpt-4 summary = %+v", result.Models[0])
+	}
+}
+
+func TestIntegrationBatch_CanonicalModels(t *testing.T) {
+	half := strings.Repeat("token ", 5000)
+	long := strings.Repeat("token ", 9000)
+	jsonl := `{"custom_id":"a","url":"/v1/chat/completions","body":{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}}` + "\n" +
+		`{"custom_id":"b","url":"/v1/chat/completions","body":{"model":"gpt-4o-2024-08-06","messages":[{"role":"user","content":"hi"}]}}` + "\n" +
+		`{"custom_id":"c","url":"/v1/chat/completions","body":{"model":"chatgpt-4o-latest","messages":[{"role":"user","content":"hi"}]}}` + "\n" +
+		fmt.Sprintf(`{"custom_id":"split","url":"/v1/embeddings","body":{"model":"text-embedding-3-small","input":[%q,%q]}}`, half, half) + "\n" +
+		fmt.Sprintf(`{"custom_id":"long","url":"/v1/embeddings","body":{"model":"text-embedding-3-small","input":[%q,"short"]}}`, long)
+
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{})
+	if err != nil {
+		t.Fatalf("NewCounter() error: %v", err)
+	}
+	result, err := counter.CountBatch(context.Background(), strings.NewReader(jsonl))
+	if err != nil {
+		t.Fatalf("CountBatch() error: %v", err)
+	}
+
+	if len(result.Models) != 2 || result.Models[0].Model != "gpt-4o" || result.Models[0].Requests != 3 {
+		t.Errorf("expected the snapshot and alias summarized under gpt-4o: %+v", result.Models)
+	}
+	// Each embeddings input is limited to 8,191 tokens, not their sum.
+	if len(result.OverContext) != 1 || result.OverContext[0].CustomID != "long" || result.OverContext[0].Tokens > 9001 {
+		t.Errorf("expected only the request with an over-long input over context, got %+v", result.OverContext)
+	}
+}
+
+// containsModel reports whether summaries include model.
+func containsModel(summaries []tokenizer.BatchModelSummary, model string) bool {
+	for _, s := range summaries {
+		if s.Model == model {
+			return true
+		}
+	}
+	return false
+}
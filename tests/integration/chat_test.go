@@ -0,0 +1,197 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+)
+
+func TestIntegrationChat_CompletionRequest(t *testing.T) {
+	file := fixturesDir(t) + "/chat/completion.json"
+	stdout, stderr, exitCode := runTcount(t, "chat", "--json", file)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+
+	var result tokenizer.ChatCountResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nraw: %s", err, stdout)
+	}
+
+	if result.Model != "gpt-4o" {
+		t.Errorf("expected model from request body, got %q", result.Model)
+	}
+	if len(result.Messages) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(result.Messages))
+	}
+
+	sum := result.ReplyPriming
+	for _, m := range result.Messages {
+		if m.OverheadTokens == 0 {
+			t.Errorf("message %d: expected per-message overhead", m.Index)
+		}
+		sum += m.Tokens
+	}
+	if sum != result.TotalTokens {
+		t.Errorf("message tokens plus priming = %d, total = %d", sum, result.TotalTokens)
+	}
+	if result.ContextWindow != 128000 {
+		t.Errorf("expected gpt-4o context window, got %d", result.ContextWindow)
+	}
+}
+
+func TestIntegrationChat_ResponsesInput(t *testing.T) {
+	file := fixturesDir(t) + "/chat/responses.json"
+	stdout, stderr, exitCode := runTcount(t, "chat", file)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "gpt-4.1") {
+		t.Errorf("expected model gpt-4.1 in output:\n%s", stdout)
+	}
+	for _, role := range []string{"system", "user", "assistant", "tool"} {
+		if !strings.Contains(stdout, role) {
+			t.Errorf("expected role %q in output:\n%s", role, stdout)
+		}
+	}
+}
+
+func TestIntegrationChat_ToolBreakdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		minTools int
+	}{
+		{"openai tools", "tools.json", 20},
+		{"anthropic tools", "anthropic_tools.json", 313},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr, exitCode := runTcount(t, "chat", "--json", fixturesDir(t)+"/chat/"+tc.file)
+			if exitCode != 0 {
+				t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+			}
+
+			var result tokenizer.ChatCountResult
+			if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+				t.Fatalf("failed to parse JSON output: %v\nraw: %s", err, stdout)
+			}
+
+			b := result.Breakdown
+			if b.Tools < tc.minTools {
+				t.Errorf("expected at least %d tool tokens, got %d", tc.minTools, b.Tools)
+			}
+			if got := b.System + b.Messages + b.Tools + result.ReplyPriming; got != result.TotalTokens {
+				t.Errorf("breakdown sums to %d, total = %d", got, result.TotalTokens)
+			}
+		})
+	}
+}
+
+func TestIntegrationChat_AnthropicMessages(t *testing.T) {
+	file := fixturesDir(t) + "/chat/anthropic_messages.json"
+	stdout, stderr, exitCode := runTcount(t, "chat", "--json", file)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+
+	var result tokenizer.ChatCountResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nraw: %s", err, stdout)
+	}
+
+	if result.Encoding != "claude_approx" {
+		t.Errorf("expected claude_approx encoding, got %q", result.Encoding)
+	}
+	if result.Breakdown.System == 0 {
+		t.Error("expected system prompt tokens")
+	}
+	if result.Breakdown.Documents == 0 {
+		t.Error("expected document tokens")
+	}
+	if result.Breakdown.Images == 0 {
+		t.Error("expected image tokens")
+	}
+	if result.ContextWindow != 200000 {
+		t.Errorf("expected claude context window, got %d", result.ContextWindow)
+	}
+	if result.InputCost <= 0 {
+		t.Error("expected a positive input cost")
+	}
+}
+
+func TestIntegrationChat_AnthropicPDFDocument(t *testing.T) {
+	pdf, err := os.ReadFile(fixturesDir(t) + "/pdf/spec.pdf")
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	body := fmt.Sprintf(`{"model":"claude-sonnet-4.5","messages":[{"role":"user","content":[
+		{"type":"document","source":{"type":"base64","media_type":"application/pdf","data":%q}},
+		{"type":"text","text":"Summarize this."}]}]}`, base64.StdEncoding.EncodeToString(pdf))
+
+	req, err := tokenizer.ParseAnthropicRequest([]byte(body))
+	if err != nil {
+		t.Fatalf("ParseAnthropicRequest() error: %v", err)
+	}
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{})
+	if err != nil {
+		t.Fatalf("NewCounter() error: %v", err)
+	}
+	result, err := counter.CountAnthropicRequest(context.Background(), req, "claude-sonnet-4.5")
+	if err != nil {
+		t.Fatalf("CountAnthropicRequest() error: %v", err)
+	}
+
+	// Two pages of image overhead plus the extracted text.
+	if min := tokenizer.AnthropicPDFPageTokens(2); result.Breakdown.Documents <= min {
+		t.Errorf("documents = %d, want more than %d", result.Breakdown.Documents, min)
+	}
+	if len(result.Notes) != 0 {
+		t.Errorf("expected no notes, got %v", result.Notes)
+	}
+}
+
+func TestIntegrationChat_AnthropicSystemBlocks(t *testing.T) {
+	body := `{"model":"claude-sonnet-4.5","system":[
+		{"type":"text","text":"Answer from the attached sources."},
+		{"type":"image","source":{"type":"url","url":"https://example.com/chart.png"}},
+		{"type":"document","source":{"type":"text","media_type":"text/plain","data":"The launch is on Tuesday."}},
+		{"type":"container_upload","file_id":"file_1"}],
+		"messages":[{"role":"user","content":"When is the launch?"}]}`
+
+	req, err := tokenizer.ParseAnthropicRequest([]byte(body))
+	if err != nil {
+		t.Fatalf("ParseAnthropicRequest() error: %v", err)
+	}
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{})
+	if err != nil {
+		t.Fatalf("NewCounter() error: %v", err)
+	}
+	result, err := counter.CountAnthropicRequest(context.Background(), req, "claude-sonnet-4.5")
+	if err != nil {
+		t.Fatalf("CountAnthropicRequest() error: %v", err)
+	}
+
+	b := result.Breakdown
+	if b.Images == 0 {
+		t.Error("expected image tokens from the system prompt")
+	}
+	if b.Documents == 0 {
+		t.Error("expected document tokens from the system prompt")
+	}
+	if got := b.System + b.Messages + b.Tools + b.Images + b.Documents + result.ReplyPriming; got != result.TotalTokens {
+		t.Errorf("breakdown sums to %d, total = %d", got, result.TotalTokens)
+	}
+
+	notes := strings.Join(result.Notes, "\n")
+	if !strings.Contains(notes, `unsupported "container_upload" block not estimated`) {
+		t.Errorf("expected a note for the unsupported system block, got %v", result.Notes)
+	}
+}
@@ -2,6 +2,7 @@ package integration_test
 
 import (
 	"encoding/json"
+	"math"
 	"strings"
 	"testing"
 
@@ -163,3 +164,153 @@ func TestIntegrationCLI_ErrorCases(t *testing.T) {
 		})
 	}
 }
+
+func TestIntegrationCLI_OutputCostEstimates(t *testing.T) {
+	file := fixturesDir(t) + "/sample.txt"
+	stdout, stderr, exitCode := runTcount(t, "--json", "--output-ratio", "0.5", file)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+
+	var result tokenizer.CountResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if len(result.Costs) == 0 {
+		t.Fatal("expected --output-ratio to imply cost estimates")
+	}
+	for _, c := range result.Costs {
+		if want := int(math.Round(float64(c.Tokens) * 0.5)); c.OutputTokens != want {
+			t.Errorf("%s: output tokens = %d, want %d", c.Model, c.OutputTokens, want)
+		}
+		if c.OutputCost <= 0 || math.Abs(c.TotalCost-c.Cost-c.OutputCost) > 1e-12 {
+			t.Errorf("%s: input %v + output %v != total %v", c.Model, c.Cost, c.OutputCost, c.TotalCost)
+		}
+	}
+
+	_, _, exitCode = runTcount(t, "--output-tokens", "10", "--output-ratio", "0.5", file)
+	if exitCode == 0 {
+		t.Error("expected --output-tokens and --output-ratio to be mutually exclusive")
+	}
+}
+
+func TestIntegrationCLI_PromptCachingCosts(t *testing.T) {
+	file := fixturesDir(t) + "/sample.txt"
+	stdout, stderr, exitCode := runTcount(t, "--json", "--cache-hit-ratio", "0.5", file)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+
+	var result tokenizer.CountResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if len(result.Costs) == 0 {
+		t.Fatal("expected --cache-hit-ratio to imply cost estimates")
+	}
+	for _, c := range result.Costs {
+		meta := tokenizer.GetModelMetadata(c.Model)
+		if c.CachedTokens != int(math.Round(float64(c.Tokens)*0.5)) {
+			t.Errorf("%s: cached tokens = %d of %d", c.Model, c.CachedTokens, c.Tokens)
+		}
+		if c.CacheReadCost >= c.CacheWriteCost || c.CacheSavings <= 0 {
+			t.Errorf("%s: read %v, write %v, savings %v", c.Model, c.CacheReadCost, c.CacheWriteCost, c.CacheSavings)
+		}
+		wantRead := float64(c.CachedTokens) * meta.CacheReadPricePer1M / 1_000_000
+		if math.Abs(c.CacheReadCost-wantRead) > 1e-12 {
+			t.Errorf("%s: cache read cost = %v, want %v", c.Model, c.CacheReadCost, wantRead)
+		}
+		if math.Abs(c.TotalCost-c.UncachedCost-c.CacheReadCost) > 1e-12 {
+			t.Errorf("%s: total %v is not the cache-hit input cost", c.Model, c.TotalCost)
+		}
+		if !c.WarmCache {
+			t.Errorf("%s: expected the total to be marked as a warm-cache total", c.Model)
+		}
+	}
+
+	stdout, _, _ = runTcount(t, "--cache-hit-ratio", "0.5", file)
+	if !strings.Contains(stdout, "assumes a warm cache") {
+		t.Errorf("expected the cost table to note the warm-cache total:\n%s", stdout)
+	}
+
+	_, _, exitCode = runTcount(t, "--cache-hit-ratio", "1.5", file)
+	if exitCode == 0 {
+		t.Error("expected a cache hit ratio above 1 to be rejected")
+	}
+}
+
+func TestIntegrationCLI_Budget(t *testing.T) {
+	file := fixturesDir(t) + "/sample.txt"
+
+	stdout, stderr, exitCode := runTcount(t, "--model", "gpt-4o", "--max-tokens", "100000", file)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0 within budget, got %d\nstderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "within budget") {
+		t.Errorf("expected a within-budget status:\n%s", stdout)
+	}
+
+	stdout, _, exitCode = runTcount(t, "--model", "gpt-4o", "--max-tokens", "5", file)
+	if exitCode != 3 {
+		t.Fatalf("expected exit code 3 over budget, got %d", exitCode)
+	}
+	if !strings.Contains(stdout, "Over Budget") {
+		t.Errorf("expected an over-budget section:\n%s", stdout)
+	}
+
+	stdout, _, exitCode = runTcount(t, "--json", "--max-cost", "0.0000001", file)
+	if exitCode != 3 {
+		t.Fatalf("expected exit code 3 over budget, got %d", exitCode)
+	}
+	var result tokenizer.CountResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if result.Budget == nil || !result.Budget.Exceeded {
+		t.Fatalf("expected an exceeded budget in JSON: %+v", result.Budget)
+	}
+	if len(result.Budget.Violations) != len(result.Costs) {
+		t.Errorf("expected one cost violation per model shown, got %d for %d models",
+			len(result.Budget.Violations), len(result.Costs))
+	}
+	for _, v := range result.Budget.Violations {
+		if v.Limit != tokenizer.BudgetLimitCost || v.Value <= v.Max {
+			t.Errorf("unexpected violation: %+v", v)
+		}
+	}
+
+	_, _, exitCode = runTcount(t, "--max-tokens", "-1", file)
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1 for a negative budget, got %d", exitCode)
+	}
+
+	_, stderr, exitCode = runTcount(t, "--model", "unpriced-model", "--max-cost", "1", file)
+	if exitCode != 1 || !strings.Contains(stderr, "--max-cost cannot be checked") {
+		t.Errorf("expected --max-cost to fail for an unpriced model, got exit %d, stderr:\n%s", exitCode, stderr)
+	}
+	unchecked := tokenizer.CheckBudget(&tokenizer.CountResult{}, tokenizer.Budget{MaxCost: 1, Model: "unpriced-model"})
+	if unchecked.Exceeded || len(unchecked.Unchecked) != 1 || unchecked.Unchecked[0] != tokenizer.BudgetLimitCost {
+		t.Errorf("expected the cost limit reported as unchecked: %+v", unchecked)
+	}
+
+	// Without --model, a provider with no priced models leaves nothing to
+	// check; the error names the filter rather than an empty model.
+	for _, limit := range []string{"--max-cost", "--max-context-percent"} {
+		_, stderr, exitCode = runTcount(t, "--provider", "meta", limit, "1", file)
+		if exitCode != 1 || !strings.Contains(stderr, limit+` cannot be checked: --provider "meta" contains no priced models`) {
+			t.Errorf("expected %s to fail naming the provider filter, got exit %d, stderr:\n%s", limit, exitCode, stderr)
+		}
+	}
+	unchecked = tokenizer.CheckBudget(&tokenizer.CountResult{}, tokenizer.Budget{MaxContextPercent: 50})
+	if unchecked.Exceeded || len(unchecked.Unchecked) != 1 || unchecked.Unchecked[0] != tokenizer.BudgetLimitContext {
+		t.Errorf("expected the context limit reported as unchecked: %+v", unchecked)
+	}
+
+	// Limits print with the precision they were given.
+	stdout, _, _ = runTcount(t, "--model", "gpt-4o", "--max-cost", "0.00001", "--max-context-percent", "0.001", file)
+	for _, want := range []string{"$0.00001", "0.001%"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected the budget table to show %s:\n%s", want, stdout)
+		}
+	}
+}
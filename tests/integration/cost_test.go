@@ -0,0 +1,165 @@
+package integration_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+)
+
+func TestIntegrationCost_LongContextTier(t *testing.T) {
+	costsFor := func(tokens int) map[string]tokenizer.CostEstimate {
+		methods := []tokenizer.MethodResult{{Name: "o200k_base", Tokens: tokens, IsExact: true}}
+		byModel := make(map[string]tokenizer.CostEstimate)
+		for _, c := range tokenizer.CalculateCostsWithOptions(methods, tokenizer.CostOptions{OutputTokens: 1000}) {
+			byModel[c.Model] = c
+		}
+		return byModel
+	}
+
+	below := costsFor(200000)["claude-sonnet-4.6"]
+	if below.TierAbove != 0 || below.RatePer1M != 3.00 || below.OutputRatePer1M != 15.00 {
+		t.Errorf("200K prompt should use base prices: %+v", below)
+	}
+
+	above := costsFor(250000)
+	sonnet := above["claude-sonnet-4.6"]
+	if sonnet.TierAbove != 200000 || sonnet.RatePer1M != 6.00 || sonnet.OutputRatePer1M != 22.50 {
+		t.Errorf("250K prompt should use long-context prices: %+v", sonnet)
+	}
+	if want := 250000*6.00/1_000_000 + 1000*22.50/1_000_000; math.Abs(sonnet.TotalCost-want) > 1e-9 {
+		t.Errorf("total cost = %v, want %v", sonnet.TotalCost, want)
+	}
+
+	// Models without tiers keep their flat prices.
+	if gpt := above["gpt-4o"]; gpt.TierAbove != 0 || gpt.RatePer1M != 2.50 {
+		t.Errorf("gpt-4o should have flat pricing: %+v", gpt)
+	}
+
+	// The tier is reachable: a 250K prompt fits the 1M long-context window.
+	meta := tokenizer.GetModelMetadata("claude-sonnet-4.6")
+	if got := meta.ContextWindowFor(200000); got != 200000 {
+		t.Errorf("200K prompt window = %d, want the standard 200,000", got)
+	}
+	if headroom, _ := meta.OutputHeadroom(250000); meta.ContextWindowFor(250000) != 1000000 || headroom != meta.MaxOutputTokens {
+		t.Errorf("250K prompt window = %d, headroom = %d, want 1,000,000 and %d",
+			meta.ContextWindowFor(250000), headroom, meta.MaxOutputTokens)
+	}
+	budget := tokenizer.CheckBudget(&tokenizer.CountResult{Costs: []tokenizer.CostEstimate{sonnet}},
+		tokenizer.Budget{MaxContextPercent: 90, Model: "claude-sonnet-4.6"})
+	if budget.Exceeded {
+		t.Errorf("250K prompt should fit the long-context window: %+v", budget.Violations)
+	}
+	if window := tokenizer.GetModelMetadata("claude-opus-4.5").ContextWindowFor(250000); window != 200000 {
+		t.Errorf("claude-opus-4.5 has no long-context window, got %d", window)
+	}
+}
+
+func TestIntegrationCost_ServiceTier(t *testing.T) {
+	methods := []tokenizer.MethodResult{{Name: "o200k_base", Tokens: 10000, IsExact: true}}
+	costs := tokenizer.CalculateCostsWithOptions(methods, tokenizer.CostOptions{
+		OutputTokens: 2000,
+		ServiceTier:  tokenizer.ServiceTierFlex,
+	})
+
+	for _, c := range costs {
+		meta := tokenizer.GetModelMetadata(c.Model)
+		multiplier, ok := meta.ServiceTierMultiplier(tokenizer.ServiceTierFlex)
+		if !ok {
+			if c.ServiceTier != "" || c.ServiceTierCost != 0 {
+				t.Errorf("%s does not offer flex but has tier cost %+v", c.Model, c)
+			}
+			continue
+		}
+		if c.ServiceTier != "flex" || math.Abs(c.ServiceTierCost-c.TotalCost*multiplier) > 1e-12 {
+			t.Errorf("%s: flex cost = %v, want %v", c.Model, c.ServiceTierCost, c.TotalCost*multiplier)
+		}
+	}
+
+	if m, ok := tokenizer.GetModelMetadata("claude-sonnet-4.6").ServiceTierMultiplier(tokenizer.ServiceTierBatch); !ok || m != 0.5 {
+		t.Errorf("claude-sonnet-4.6 batch multiplier = %v, %v; want 0.5", m, ok)
+	}
+}
+
+func TestIntegrationCost_TierFlag(t *testing.T) {
+	file := fixturesDir(t) + "/sample.txt"
+	stdout, _, exitCode := runTcount(t, "--tier", "batch", file)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", exitCode)
+	}
+	if !strings.Contains(stdout, "Cost (batch)") {
+		t.Errorf("expected a batch cost column with --tier batch:\n%s", stdout)
+	}
+
+	if _, _, exitCode := runTcount(t, "--tier", "overnight", file); exitCode == 0 {
+		t.Error("expected an unknown tier to be rejected")
+	}
+}
+
+func TestIntegrationCost_ModelSet(t *testing.T) {
+	methods := []tokenizer.MethodResult{
+		{Name: "bpe_o200k_base", Encoding: "o200k_base", Tokens: 1000, IsExact: true},
+		{Name: "claude_3_approx", Encoding: "claude_approx", Tokens: 1100},
+		{Name: "character_based_div4", Tokens: 900},
+	}
+	names := func(costs []tokenizer.CostEstimate) []string {
+		out := make([]string, 0, len(costs))
+		for _, c := range costs {
+			out = append(out, c.Model)
+		}
+		return out
+	}
+
+	t.Run("selected model first", func(t *testing.T) {
+		got := names(tokenizer.CalculateCostsWithOptions(methods, tokenizer.CostOptions{Model: "gpt-4.1-mini"}))
+		want := "gpt-4.1-mini,gpt-5,gpt-4o,claude-sonnet-4.6,claude-sonnet-4.5"
+		if strings.Join(got, ",") != want {
+			t.Errorf("models = %v, want %s", got, want)
+		}
+	})
+
+	t.Run("provider filter", func(t *testing.T) {
+		for _, c := range tokenizer.CalculateCostsWithOptions(methods, tokenizer.CostOptions{Provider: tokenizer.ProviderAnthropic}) {
+			if !strings.HasPrefix(c.Model, "claude-") {
+				t.Errorf("anthropic filter priced %s", c.Model)
+			}
+		}
+	})
+
+	t.Run("explicit models and token sources", func(t *testing.T) {
+		costs := tokenizer.CalculateCostsWithOptions(methods, tokenizer.CostOptions{
+			Models: []string{"claude-haiku-4.5", "gpt-4o", "gpt-4"},
+		})
+		want := []struct {
+			model  string
+			tokens int
+			source string
+		}{
+			{"claude-haiku-4.5", 1100, tokenizer.TokenSourceEstimated},
+			{"gpt-4o", 1000, tokenizer.TokenSourceExact},
+			{"gpt-4", 1000, tokenizer.TokenSourceProxy},
+		}
+		if len(costs) != len(want) {
+			t.Fatalf("got %d costs, want %d", len(costs), len(want))
+		}
+		for i, w := range want {
+			c := costs[i]
+			if c.Model != w.model || c.Tokens != w.tokens || c.TokenSource != w.source {
+				t.Errorf("cost %d = %s %d %s, want %s %d %s", i, c.Model, c.Tokens, c.TokenSource, w.model, w.tokens, w.source)
+			}
+		}
+	})
+
+	t.Run("all models cheapest first", func(t *testing.T) {
+		costs := tokenizer.CalculateCostsWithOptions(methods, tokenizer.CostOptions{AllModels: true, OutputTokens: 500})
+		if len(costs) < 20 {
+			t.Fatalf("expected every priced model, got %d", len(costs))
+		}
+		for i := 1; i < len(costs); i++ {
+			if costs[i].TotalCost < costs[i-1].TotalCost {
+				t.Errorf("%s ($%v) sorted after %s ($%v)", costs[i].Model, costs[i].TotalCost, costs[i-1].Model, costs[i-1].TotalCost)
+			}
+		}
+	})
+}
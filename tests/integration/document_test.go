@@ -0,0 +1,76 @@
+package integration_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+	"github.com/lancekrogers/go-token-counter/tokenizer/fileops"
+)
+
+func TestIntegrationDocument_ExtractText(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		// Tab stops in paragraph properties and deleted runs are not text.
+		{"report.docx", "Quarterly report\nRevenue\tgrew\nagain."},
+		// Shared strings, rich runs, inline strings and booleans; phonetic hints dropped.
+		{"budget.xlsx", "Item\tCost\nServers\t1200\tmonthly\tTRUE"},
+		// Slides in numeric order, so slide10 comes last.
+		{"deck.pptx", "Roadmap\n\nMilestones\n\nQuestions"},
+		// Repeated spaces, tabs and line breaks; annotations skipped.
+		{"notes.odt", "Meeting notes\nShip  it\tnow\nLine one\nLine two"},
+		// Spine order with an escaped href; head and style skipped.
+		{"book.epub", "Chapter One\nIt was a dark & stormy night.\n\nChapter Two\nThe end.\nReally."},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.file, func(t *testing.T) {
+			doc, err := fileops.ExtractDocument(fixturesDir(t) + "/documents/" + tc.file)
+			if err != nil {
+				t.Fatalf("ExtractDocument() error: %v", err)
+			}
+			if doc.Text != tc.want {
+				t.Errorf("text = %q, want %q", doc.Text, tc.want)
+			}
+		})
+	}
+}
+
+func TestIntegrationDocument_CountDirectory(t *testing.T) {
+	dir := fixturesDir(t) + "/documents"
+
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{})
+	if err != nil {
+		t.Fatalf("NewCounter() error: %v", err)
+	}
+	result, err := counter.CountDirectory(context.Background(), dir, "", true)
+	if err != nil {
+		t.Fatalf("CountDirectory() error: %v", err)
+	}
+	if result.FileCount != 5 {
+		t.Errorf("file count = %d, want 5", result.FileCount)
+	}
+	if result.Words == 0 {
+		t.Error("expected words from extracted documents")
+	}
+
+	counter, err = tokenizer.NewCounter(tokenizer.CounterOptions{NoExtract: true})
+	if err != nil {
+		t.Fatalf("NewCounter() error: %v", err)
+	}
+	if _, err := counter.CountDirectory(context.Background(), dir, "", true); err == nil {
+		t.Error("expected an error for a directory of documents with NoExtract")
+	}
+}
+
+func TestIntegrationDocument_NoExtractFlag(t *testing.T) {
+	file := fixturesDir(t) + "/documents/report.docx"
+
+	extracted := runTcountJSON(t, file)
+	raw := runTcountJSON(t, "--no-extract", file)
+	if extracted.Characters == raw.Characters {
+		t.Errorf("expected --no-extract to count the raw archive, both have %d characters", raw.Characters)
+	}
+}
@@ -0,0 +1,86 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+)
+
+func TestIntegrationFinetune_Summary(t *testing.T) {
+	file := fixturesDir(t) + "/finetune/train.jsonl"
+	stdout, stderr, exitCode := runTcount(t, "finetune", "--json", "--model", "gpt-4.1-mini", "--epochs", "3", file)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+
+	var result tokenizer.FinetuneResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nraw: %s", err, stdout)
+	}
+
+	if result.Examples != 4 {
+		t.Errorf("examples = %d, want 4", result.Examples)
+	}
+	wantErrors := []int{5, 6, 7, 8}
+	if len(result.Errors) != len(wantErrors) {
+		t.Fatalf("errors = %+v, want lines %v", result.Errors, wantErrors)
+	}
+	for i, line := range wantErrors {
+		if result.Errors[i].Line != line {
+			t.Errorf("error %d on line %d, want %d", i, result.Errors[i].Line, line)
+		}
+	}
+	if result.MissingSystem != 1 {
+		t.Errorf("missing system = %d, want 1", result.MissingSystem)
+	}
+
+	if result.ExampleTokens.Total != result.PromptTokens.Total+result.AssistantTokens.Total {
+		t.Errorf("prompt (%d) + assistant (%d) != example tokens (%d)",
+			result.PromptTokens.Total, result.AssistantTokens.Total, result.ExampleTokens.Total)
+	}
+	if result.AssistantTokens.Min <= 0 || result.ExampleTokens.Max < result.ExampleTokens.P90 {
+		t.Errorf("unexpected distributions: %+v %+v", result.ExampleTokens, result.AssistantTokens)
+	}
+
+	if result.Epochs != 3 || result.AutoEpochs {
+		t.Errorf("epochs = %d (auto %v), want 3", result.Epochs, result.AutoEpochs)
+	}
+	if result.TrainingTokens != 3*result.ExampleTokens.Total {
+		t.Errorf("training tokens = %d, want %d", result.TrainingTokens, 3*result.ExampleTokens.Total)
+	}
+	wantCost := float64(result.TrainingTokens) * 5.00 / 1_000_000
+	if math.Abs(result.TrainingCost-wantCost) > 1e-12 {
+		t.Errorf("training cost = %v, want %v", result.TrainingCost, wantCost)
+	}
+}
+
+func TestIntegrationFinetune_OverLimitAndDefaultEpochs(t *testing.T) {
+	long := strings.Repeat("token ", 17000)
+	jsonl := fmt.Sprintf(`{"messages":[{"role":"user","content":%q},{"role":"assistant","content":"ok"}]}`+"\n"+
+		`{"messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"hello"}]}`, long)
+
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{})
+	if err != nil {
+		t.Fatalf("NewCounter() error: %v", err)
+	}
+	result, err := counter.CountFinetune(context.Background(), strings.NewReader(jsonl), tokenizer.FinetuneOptions{Model: "gpt-3.5-turbo"})
+	if err != nil {
+		t.Fatalf("CountFinetune() error: %v", err)
+	}
+
+	if len(result.OverLimit) != 1 || result.OverLimit[0].Line != 1 {
+		t.Fatalf("expected line 1 over the example limit, got %+v", result.OverLimit)
+	}
+	if want := 16385 + result.ExampleTokens.Min; result.BilledPerEpoch != want {
+		t.Errorf("billed tokens per epoch = %d, want %d (truncated)", result.BilledPerEpoch, want)
+	}
+	// Two examples: OpenAI trains for 100/2 epochs, capped at 25.
+	if !result.AutoEpochs || result.Epochs != 25 {
+		t.Errorf("epochs = %d (auto %v), want 25 (auto)", result.Epochs, result.AutoEpochs)
+	}
+}
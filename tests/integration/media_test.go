@@ -0,0 +1,138 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+	"github.com/lancekrogers/go-token-counter/tokenizer/fileops"
+)
+
+func TestIntegrationMedia_ImageInfo(t *testing.T) {
+	tests := []struct {
+		file          string
+		format        string
+		width, height int
+	}{
+		{"diagram.png", "png", 2048, 4096},
+		{"screenshot.webp", "webp", 800, 600},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.file, func(t *testing.T) {
+			info, err := fileops.ReadImageInfo(fixturesDir(t) + "/images/" + tc.file)
+			if err != nil {
+				t.Fatalf("ReadImageInfo() error: %v", err)
+			}
+			if info.Format != tc.format || info.Width != tc.width || info.Height != tc.height {
+				t.Errorf("got %s %dx%d, want %s %dx%d",
+					info.Format, info.Width, info.Height, tc.format, tc.width, tc.height)
+			}
+		})
+	}
+}
+
+func TestIntegrationMedia_IncludeImages(t *testing.T) {
+	dir := fixturesDir(t) + "/images"
+	result := runTcountJSON(t, "-r", "--include-images", dir)
+
+	if len(result.Media) != 4 {
+		t.Fatalf("expected 4 media estimates (2 images x 2 providers), got %d", len(result.Media))
+	}
+
+	for _, m := range result.Media {
+		if m.Kind != tokenizer.MediaKindImage {
+			t.Errorf("expected image kind, got %q", m.Kind)
+		}
+		// diagram.png is 2048x4096: OpenAI scales to 768x1536 (6 tiles),
+		// Anthropic caps at the ~1,600 token maximum.
+		if m.Width == 2048 {
+			switch m.Provider {
+			case tokenizer.ProviderOpenAI:
+				if m.Tokens != 85+6*170 {
+					t.Errorf("openai tokens = %d, want %d", m.Tokens, 85+6*170)
+				}
+			case tokenizer.ProviderAnthropic:
+				if m.Tokens > 1600 {
+					t.Errorf("anthropic tokens = %d, want <= 1600", m.Tokens)
+				}
+			}
+		}
+	}
+}
+
+func TestIntegrationMedia_UnknownImageSize(t *testing.T) {
+	largest := tokenizer.OpenAIImageTokens(768, 2048, tokenizer.ImageDetailHigh)
+	if largest != 85+8*170 {
+		t.Errorf("768x2048 tokens = %d, want %d", largest, 85+8*170)
+	}
+	if got := tokenizer.OpenAIImageTokens(0, 0, tokenizer.ImageDetailHigh); got != largest {
+		t.Errorf("unknown size tokens = %d, want the largest image's %d", got, largest)
+	}
+}
+
+func TestIntegrationMedia_ImageRequiresFlag(t *testing.T) {
+	_, stderr, exitCode := runTcount(t, fixturesDir(t)+"/images/diagram.png")
+	if exitCode == 0 {
+		t.Fatal("expected non-zero exit code for image without --include-images")
+	}
+	if stderr == "" {
+		t.Error("expected an error message on stderr")
+	}
+}
+
+func TestIntegrationMedia_AudioInfo(t *testing.T) {
+	tests := []struct {
+		file    string
+		format  string
+		seconds float64
+	}{
+		{"tone.wav", "wav", 2.5},
+		{"speech.flac", "flac", 12},
+		{"meeting.mp3", "mp3", 26.122},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.file, func(t *testing.T) {
+			info, err := fileops.ReadAudioInfo(fixturesDir(t) + "/audio/" + tc.file)
+			if err != nil {
+				t.Fatalf("ReadAudioInfo() error: %v", err)
+			}
+			if info.Format != tc.format {
+				t.Errorf("format = %q, want %q", info.Format, tc.format)
+			}
+			if got := info.Duration.Seconds(); got < tc.seconds-0.01 || got > tc.seconds+0.01 {
+				t.Errorf("duration = %.3fs, want %.3fs", got, tc.seconds)
+			}
+		})
+	}
+}
+
+func TestIntegrationMedia_IncludeAudio(t *testing.T) {
+	result := runTcountJSON(t, "--include-audio", fixturesDir(t)+"/audio/speech.flac")
+
+	if len(result.Media) != len(tokenizer.ListAudioModels()) {
+		t.Fatalf("expected one estimate per audio model, got %d", len(result.Media))
+	}
+	for _, m := range result.Media {
+		if m.Kind != tokenizer.MediaKindAudio {
+			t.Errorf("expected audio kind, got %q", m.Kind)
+		}
+		// 12 seconds at 10 tokens per second.
+		if m.Tokens != 120 {
+			t.Errorf("%s tokens = %d, want 120", m.Model, m.Tokens)
+		}
+		if m.Cost <= 0 {
+			t.Errorf("%s cost = %v, want > 0", m.Model, m.Cost)
+		}
+	}
+}
+
+func TestIntegrationMedia_AudioRequiresFlag(t *testing.T) {
+	_, stderr, exitCode := runTcount(t, fixturesDir(t)+"/audio/tone.wav")
+	if exitCode == 0 {
+		t.Fatal("expected non-zero exit code for audio without --include-audio")
+	}
+	if stderr == "" {
+		t.Error("expected an error message on stderr")
+	}
+}
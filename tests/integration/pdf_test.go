@@ -0,0 +1,131 @@
+package integration_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+	"github.com/lancekrogers/go-token-counter/tokenizer/fileops"
+)
+
+func TestIntegrationPDF_ExtractText(t *testing.T) {
+	info, err := fileops.ReadPDF(fixturesDir(t) + "/pdf/spec.pdf")
+	if err != nil {
+		t.Fatalf("ReadPDF() error: %v", err)
+	}
+
+	if info.Pages != 2 {
+		t.Errorf("pages = %d, want 2", info.Pages)
+	}
+	// Page 1 uses WinAnsi with TJ kerning, page 2 a ToUnicode Type0 font in a
+	// Flate stream and a Differences encoding with ligature and accent glyphs
+	// (accented glyph names decode to a base letter plus combining mark).
+	want := "Requirements for the token counter\nCaf\u00e9 menu\n\nhello world\nHi finale\u0301"
+	if info.Text != want {
+		t.Errorf("text = %q, want %q", info.Text, want)
+	}
+}
+
+func TestIntegrationPDF_CountDirectory(t *testing.T) {
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{PDFPageOverhead: true})
+	if err != nil {
+		t.Fatalf("NewCounter() error: %v", err)
+	}
+
+	result, err := counter.CountDirectory(context.Background(), fixturesDir(t)+"/pdf", "", true)
+	if err != nil {
+		t.Fatalf("CountDirectory() error: %v", err)
+	}
+	if result.Pages != 2 {
+		t.Errorf("pages = %d, want 2", result.Pages)
+	}
+	if result.Words == 0 {
+		t.Error("expected words from extracted PDF text")
+	}
+	if len(result.Media) != 1 || result.Media[0].Kind != tokenizer.MediaKindDocument {
+		t.Fatalf("expected one document overhead estimate, got %+v", result.Media)
+	}
+	if got := result.Media[0].Tokens; got != tokenizer.AnthropicPDFPageTokens(2) {
+		t.Errorf("overhead tokens = %d, want %d", got, tokenizer.AnthropicPDFPageTokens(2))
+	}
+}
+
+func TestIntegrationPDF_CLIReportsPages(t *testing.T) {
+	result := runTcountJSON(t, fixturesDir(t)+"/pdf/spec.pdf")
+	if result.Pages != 2 {
+		t.Errorf("pages = %d, want 2", result.Pages)
+	}
+	if len(result.Media) != 0 {
+		t.Errorf("expected no overhead without --pdf-overhead, got %d media", len(result.Media))
+	}
+}
+
+func TestIntegrationPDF_DeflateBomb(t *testing.T) {
+	// A content stream that inflates to 65 MB of text operators, past the
+	// 64 MB cap, from a few hundred KB on disk.
+	var stream bytes.Buffer
+	zw := zlib.NewWriter(&stream)
+	line := []byte("BT (a) Tj ET\n")
+	chunk := bytes.Repeat(line, (1<<20)/len(line))
+	for i := 0; i < 66; i++ {
+		if _, err := zw.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	pdf.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n")
+	pdf.WriteString("3 0 obj << /Type /Page /Parent 2 0 R /Contents 4 0 R >> endobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj << /Length %d /Filter /FlateDecode >> stream\n", stream.Len())
+	pdf.Write(stream.Bytes())
+	pdf.WriteString("\nendstream endobj\ntrailer << /Root 1 0 R >>\n%%EOF\n")
+
+	info, err := fileops.ParsePDF(pdf.Bytes())
+	if err != nil {
+		t.Fatalf("ParsePDF() error: %v", err)
+	}
+	if info.Pages != 1 {
+		t.Errorf("pages = %d, want 1", info.Pages)
+	}
+	if strings.Contains(info.Text, "a") {
+		t.Errorf("expected the oversized stream to be skipped, got %d bytes of text", len(info.Text))
+	}
+}
+
+func TestIntegrationPDF_BadStreamLength(t *testing.T) {
+	// A /Length that is negative or does not end at endstream is ignored
+	// and the stream is delimited by its endstream marker instead.
+	content := "BT (hello) Tj ET"
+	for _, length := range []string{"-100", "-1", "3", "100000", "1e300"} {
+		t.Run(length, func(t *testing.T) {
+			var pdf bytes.Buffer
+			pdf.WriteString("%PDF-1.4\n")
+			pdf.WriteString("1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+			pdf.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n")
+			pdf.WriteString("3 0 obj << /Type /Page /Parent 2 0 R /Contents 4 0 R >> endobj\n")
+			fmt.Fprintf(&pdf, "4 0 obj << /Length %s >>\nstream\n%s\nendstream\nendobj\n", length, content)
+			pdf.WriteString("trailer << /Root 1 0 R >>\n%%EOF\n")
+
+			info, err := fileops.ParsePDF(pdf.Bytes())
+			if err != nil {
+				t.Fatalf("ParsePDF() error: %v", err)
+			}
+			if !strings.Contains(info.Text, "hello") {
+				t.Errorf("text = %q, want it to contain %q", info.Text, "hello")
+			}
+		})
+	}
+
+	// A lone stream object with no page tree must not panic; whether it
+	// reports an error is irrelevant here.
+	_, _ = fileops.ParsePDF([]byte("%PDF-1.4\n1 0 obj << /Length -100 >>\nstream\nabcdef\nendstream\nendobj\n"))
+}
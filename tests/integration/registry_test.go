@@ -0,0 +1,686 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+)
+
+func TestIntegrationRegistry_ParseOverrides(t *testing.T) {
+	overrides, err := tokenizer.LoadRegistryOverrides(fixturesDir(t) + "/registry/overrides.json")
+	if err != nil {
+		t.Fatalf("LoadRegistryOverrides: %v", err)
+	}
+	haiku, ok := overrides.Models["claude-haiku-4.5"]
+	if !ok || haiku.ContextWindow == nil || *haiku.ContextWindow != 500000 {
+		t.Errorf("expected the JSON context window override: %+v", haiku)
+	}
+	if haiku.InputPricePer1M != nil {
+		t.Error("fields missing from the file should stay unset")
+	}
+
+	for _, bad := range []string{
+		"models:\n  gpt-4o:\n    encoding: p50k_base\n",
+		"models:\n  gpt-4o:\n    input_price_per_1m: -1\n",
+		"models:\n  gpt-4o:\n    input_price: 1\n",
+	} {
+		if _, err := tokenizer.ParseRegistryOverrides([]byte(bad)); err == nil {
+			t.Errorf("expected an error for:\n%s", bad)
+		}
+	}
+}
+
+func TestIntegrationRegistry_ModelsCommand(t *testing.T) {
+	yamlFile := fixturesDir(t) + "/registry/overrides.yaml"
+	stdout, stderr, exitCode := runTcount(t, "models", "--json", "--registry", yamlFile)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+
+	var report struct {
+		PricingUpdated string   `json:"pricing_updated"`
+		RegistryFiles  []string `json:"registry_files"`
+		Models         []struct {
+			Name            string   `json:"name"`
+			Encoding        string   `json:"encoding"`
+			InputPricePer1M float64  `json:"input_price_per_1m"`
+			Overridden      []string `json:"overridden"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nraw: %s", err, stdout)
+	}
+	if report.PricingUpdated == "" || len(report.RegistryFiles) == 0 {
+		t.Errorf("expected the pricing date and override file: %+v", report)
+	}
+
+	found := map[string]bool{}
+	for _, m := range report.Models {
+		switch m.Name {
+		case "gpt-4o":
+			found[m.Name] = true
+			if m.InputPricePer1M != 2.0 || strings.Join(m.Overridden, ",") != "input_price_per_1m" {
+				t.Errorf("gpt-4o override not reported: %+v", m)
+			}
+		case "my-finetune":
+			found[m.Name] = true
+			if m.Encoding != "o200k_base" || len(m.Overridden) == 0 || m.Overridden[0] != "model" {
+				t.Errorf("added model not reported: %+v", m)
+			}
+		case "gpt-4o-mini":
+			if len(m.Overridden) != 0 {
+				t.Errorf("built-in model reported as overridden: %+v", m)
+			}
+		}
+	}
+	if !found["gpt-4o"] || !found["my-finetune"] {
+		t.Errorf("expected gpt-4o and my-finetune in the listing: %v", found)
+	}
+
+	if _, stderr, _ := runTcount(t, "models", "--max-pricing-age", "1"); !strings.Contains(stderr, "last updated") {
+		t.Errorf("expected a stale pricing warning, got stderr:\n%s", stderr)
+	}
+	if _, stderr, _ := runTcount(t, "models", "--max-pricing-age", "0"); strings.Contains(stderr, "last updated") {
+		t.Errorf("expected no warning with --max-pricing-age 0, got stderr:\n%s", stderr)
+	}
+}
+
+func TestIntegrationRegistry_OverridesPricing(t *testing.T) {
+	file := fixturesDir(t) + "/sample.txt"
+	stdout, stderr, exitCode := runTcount(t, "--json", "--registry", fixturesDir(t)+"/registry/overrides.yaml",
+		"--cost-models", "my-finetune,gpt-4o", file)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+
+	var result tokenizer.CountResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if len(result.Costs) != 2 {
+		t.Fatalf("expected costs for both models, got %+v", result.Costs)
+	}
+	if c := result.Costs[0]; c.Model != "my-finetune" || c.RatePer1M != 3.75 || c.TokenSource != tokenizer.TokenSourceExact {
+		t.Errorf("added model not priced from the override: %+v", c)
+	}
+	if c := result.Costs[1]; c.RatePer1M != 2.0 {
+		t.Errorf("gpt-4o input price not overridden: %+v", c)
+	}
+
+	_, _, exitCode = runTcount(t, "--registry", fixturesDir(t)+"/registry/missing.yaml", file)
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1 for a missing registry file, got %d", exitCode)
+	}
+}
+
+func TestIntegrationRegistry_CustomModels(t *testing.T) {
+	file := fixturesDir(t) + "/sample.txt"
+	registry := fixturesDir(t) + "/registry/custom.yaml"
+
+	stdout, stderr, exitCode := runTcount(t, "--json", "--registry", registry, "--model", "acme-gpt-4o-ft-2026", "--cost", file)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	if strings.Contains(stderr, "Unknown model") {
+		t.Errorf("custom model should be known, got stderr:\n%s", stderr)
+	}
+	var result tokenizer.CountResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if len(result.Methods) != 1 || !result.Methods[0].IsExact || result.Methods[0].ContextWindow != 64000 {
+		t.Errorf("expected an exact o200k_base count with the custom context window: %+v", result.Methods)
+	}
+	if len(result.Costs) == 0 || result.Costs[0].Model != "acme-gpt-4o-ft-2026" || result.Costs[0].RatePer1M != 3.75 {
+		t.Errorf("expected the custom model priced first: %+v", result.Costs)
+	}
+
+	stdout, stderr, exitCode = runTcount(t, "--json", "--registry", registry, "--provider", "acme", "--cost", file)
+	if exitCode != 0 {
+		t.Fatalf("expected --provider acme to be accepted, got %d\nstderr: %s", exitCode, stderr)
+	}
+	result = tokenizer.CountResult{}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	for _, m := range result.Methods {
+		if m.Encoding != "" && m.Encoding != "o200k_base" {
+			t.Errorf("provider acme should only count its encodings, got %s", m.Encoding)
+		}
+	}
+	if len(result.Costs) != 1 || result.Costs[0].Model != "acme-gpt-4o-ft-2026" {
+		t.Errorf("expected only the priced acme model in costs: %+v", result.Costs)
+	}
+
+	stdout, _, _ = runTcount(t, "--registry", registry, "--models", file)
+	if !strings.Contains(stdout, "acme-gpt-4o-ft-2026") || !strings.Contains(stdout, "spm:") {
+		t.Errorf("expected custom models in the lookup table:\n%s", stdout)
+	}
+
+	// A custom SentencePiece model loads its own vocab file.
+	_, stderr, exitCode = runTcount(t, "--registry", registry, "--model", "acme-llama", file)
+	if exitCode == 0 || !strings.Contains(stderr, "missing-tokenizer.model") {
+		t.Errorf("expected the custom vocab file to be loaded, got %d\nstderr: %s", exitCode, stderr)
+	}
+}
+
+func TestIntegrationRegistry_ProjectConfig(t *testing.T) {
+	data, err := os.ReadFile(fixturesDir(t) + "/registry/custom.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".tcount.yaml"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binaryPath, "models", "--json", "--provider", "acme")
+	cmd.Dir = dir
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("tcount models failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "acme-gpt-4o-ft-2026") || !strings.Contains(out.String(), ".tcount.yaml") {
+		t.Errorf("expected the project config to be loaded:\n%s", out.String())
+	}
+}
+
+func TestIntegrationRegistry_API(t *testing.T) {
+	registry := tokenizer.NewRegistry()
+	custom := tokenizer.ModelMetadata{
+		Name: "acme-ft", Provider: "acme", Encoding: "cl100k_base",
+		ContextWindow: 32000, InputPricePer1M: 1.0, OutputPricePer1M: 2.0,
+	}
+
+	if err := registry.Register(custom); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := registry.Register(custom); !errors.Is(err, tokenizer.ErrModelExists) {
+		t.Errorf("duplicate Register error = %v, want ErrModelExists", err)
+	}
+	if err := registry.RegisterAlias("acme", "acme-ft"); err != nil {
+		t.Fatalf("RegisterAlias: %v", err)
+	}
+	if err := registry.RegisterAlias("gpt-4o", "acme-ft"); !errors.Is(err, tokenizer.ErrModelExists) {
+		t.Errorf("alias over a model error = %v, want ErrModelExists", err)
+	}
+	if err := registry.RegisterAlias("nope", "missing"); !errors.Is(err, tokenizer.ErrModelNotFound) {
+		t.Errorf("alias to unknown model error = %v, want ErrModelNotFound", err)
+	}
+	if err := registry.Update("missing", func(*tokenizer.ModelMetadata) {}); !errors.Is(err, tokenizer.ErrModelNotFound) {
+		t.Errorf("Update of unknown model error = %v, want ErrModelNotFound", err)
+	}
+
+	if err := registry.Update("acme", func(m *tokenizer.ModelMetadata) {
+		m.InputPricePer1M = 1.5
+		m.Name = "renamed"
+	}); err != nil {
+		t.Fatalf("Update through alias: %v", err)
+	}
+	meta, ok := registry.Lookup("acme")
+	if !ok || meta.Name != "acme-ft" || meta.InputPricePer1M != 1.5 {
+		t.Errorf("Lookup(acme) = %+v, %v", meta, ok)
+	}
+
+	// Lookup returns copies.
+	sonnet, _ := registry.Lookup("claude-sonnet-4.6")
+	sonnet.ServiceTiers[tokenizer.ServiceTierBatch] = 9
+	again, _ := registry.Lookup("claude-sonnet-4.6")
+	if again.ServiceTiers[tokenizer.ServiceTierBatch] == 9 {
+		t.Error("Lookup result shares its service tier map with the registry")
+	}
+
+	// The default registry is unaffected.
+	if tokenizer.GetModelMetadata("acme-ft") != nil || tokenizer.GetModelMetadata("acme") != nil {
+		t.Error("isolated registry leaked into the default registry")
+	}
+
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{Registry: registry, Provider: "acme"})
+	if err != nil {
+		t.Fatalf("NewCounter: %v", err)
+	}
+	result, err := counter.Count(context.Background(), "Hello from a custom registry.", "", false)
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	for _, m := range result.Methods {
+		if m.Encoding != "" && m.Encoding != "cl100k_base" {
+			t.Errorf("provider acme counted with %s", m.Encoding)
+		}
+	}
+	costs := tokenizer.CalculateCostsWithOptions(result.Methods, tokenizer.CostOptions{Registry: registry, Provider: "acme"})
+	if len(costs) != 1 || costs[0].Model != "acme-ft" || costs[0].RatePer1M != 1.5 {
+		t.Errorf("expected the custom model priced from the registry: %+v", costs)
+	}
+}
+
+func TestIntegrationRegistry_Concurrent(t *testing.T) {
+	registry := tokenizer.NewRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("model-%d", i)
+			if err := registry.Register(tokenizer.ModelMetadata{Name: name, Encoding: "o200k_base"}); err != nil {
+				t.Errorf("Register(%s): %v", name, err)
+			}
+			for j := 0; j < 50; j++ {
+				_ = registry.Update("gpt-4o", func(m *tokenizer.ModelMetadata) { m.ContextWindow++ })
+				registry.Lookup(name)
+				registry.Models()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	meta, _ := registry.Lookup("gpt-4o")
+	if meta.ContextWindow != 128000+8*50 {
+		t.Errorf("context window = %d, want %d", meta.ContextWindow, 128000+8*50)
+	}
+}
+
+func TestIntegrationRegistry_ModelNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+	}{
+		{"gpt-4o-2024-08-06", "gpt-4o"},
+		{"gpt-4-0613", "gpt-4"},
+		{"gpt-4-turbo-2024-04-09", "gpt-4-turbo"},
+		{"openai/gpt-4.1", "gpt-4.1"},
+		{"azure/gpt-4.1-mini", "gpt-4.1-mini"},
+		{"chatgpt-4o-latest", "gpt-4o"},
+		{"claude-sonnet-4-5-20250929", "claude-sonnet-4.5"},
+		{"claude-haiku-4-5@20251001", "claude-haiku-4.5"},
+		{"anthropic/claude-opus-4-1", "claude-opus-4.1"},
+		{"claude-opus-4-0", "claude-opus-4"},
+		{"claude-3-5-sonnet-latest", "claude-sonnet-3.5"},
+		{"claude-3-7-sonnet-20250219", "claude-sonnet-3.7"},
+		{"claude-3-5-haiku-20241022", "claude-haiku-3.5"},
+		{"claude-3-opus-20240229", "claude-opus-3"},
+		{"meta-llama/meta-llama-3.1-8b-instruct", "llama-3.1-8b"},
+		{"qwen/qwen2.5-72b-instruct", "qwen-2.5-72b"},
+		{"microsoft/phi-3-mini-4k-instruct", "phi-3-mini"},
+		{"deepseek-chat", "deepseek-v3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tokenizer.ResolveModelName(tt.name)
+			if !ok || got != tt.model {
+				t.Errorf("ResolveModelName(%q) = %q, %v, want %q", tt.name, got, ok, tt.model)
+			}
+			if meta := tokenizer.GetPricingForModel(tt.name); meta == nil || meta.Name != tt.model {
+				t.Errorf("GetPricingForModel(%q) = %+v, want %s", tt.name, meta, tt.model)
+			}
+		})
+	}
+
+	// Registered names are their own normal form, and prefixes no longer match.
+	for _, name := range tokenizer.ListModels() {
+		if got := tokenizer.NormalizeModelName(name); got != name {
+			t.Errorf("NormalizeModelName(%q) = %q", name, got)
+		}
+	}
+	for _, name := range []string{"gpt", "claude", "gpt-4o-mini-x", "sonnet"} {
+		if meta := tokenizer.GetPricingForModel(name); meta != nil {
+			t.Errorf("GetPricingForModel(%q) matched %s", name, meta.Name)
+		}
+	}
+	if meta := tokenizer.GetPricingForModel("gpt-4"); meta == nil || meta.Name != "gpt-4" {
+		t.Errorf("GetPricingForModel(gpt-4) = %+v", meta)
+	}
+	if _, ok := tokenizer.ResolveModelName("GPT-4o"); ok {
+		t.Error("model names should be case-sensitive")
+	}
+
+	file := fixturesDir(t) + "/sample.txt"
+	result := runTcountJSON(t, "--model", "claude-sonnet-4-5-20250929", "--cost-models", "openai/gpt-4o,gpt-4.1-2025-04-14", file)
+	if len(result.Methods) != 1 || result.Methods[0].Name != "bpe_claude_sonnet_4.5" {
+		t.Errorf("expected the snapshot counted as claude-sonnet-4.5: %+v", result.Methods)
+	}
+	var costModels []string
+	for _, c := range result.Costs {
+		costModels = append(costModels, c.Model)
+	}
+	if strings.Join(costModels, ",") != "gpt-4o,gpt-4.1" {
+		t.Errorf("expected cost models by registry name, got %v", costModels)
+	}
+}
+
+func TestIntegrationRegistry_UnknownModels(t *testing.T) {
+	if got := tokenizer.SuggestModels("claude-sonet-4.5"); len(got) == 0 || got[0] != "claude-sonnet-4.5" {
+		t.Errorf("SuggestModels(claude-sonet-4.5) = %v", got)
+	}
+	if got := tokenizer.SuggestModels("deepseek-chatt"); len(got) == 0 || got[0] != "deepseek-v3" {
+		t.Errorf("expected an alias suggested as its model, got %v", got)
+	}
+	if got := tokenizer.SuggestModels("zzzzzzzz"); got != nil {
+		t.Errorf("expected no suggestions, got %v", got)
+	}
+
+	lenient, err := tokenizer.NewCounter(tokenizer.CounterOptions{})
+	if err != nil {
+		t.Fatalf("NewCounter: %v", err)
+	}
+	if _, err := lenient.Count(context.Background(), "hello", "gpt-4p", false); err != nil {
+		t.Errorf("expected approximations for an unknown model, got %v", err)
+	}
+
+	strict, err := tokenizer.NewCounter(tokenizer.CounterOptions{StrictModels: true})
+	if err != nil {
+		t.Fatalf("NewCounter: %v", err)
+	}
+	_, err = strict.Count(context.Background(), "hello", "gpt-4p", false)
+	if !errors.Is(err, tokenizer.ErrModelNotFound) || !strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("expected ErrModelNotFound with suggestions, got %v", err)
+	}
+	for _, model := range []string{"gpt-4o-2024-08-06", "o200k_base"} {
+		if _, err := strict.Count(context.Background(), "hello", model, false); err != nil {
+			t.Errorf("strict Count(%s): %v", model, err)
+		}
+	}
+
+	file := fixturesDir(t) + "/sample.txt"
+	_, stderr, exitCode := runTcount(t, "--model", "gpt-4p", file)
+	if exitCode != 0 || !strings.Contains(stderr, "did you mean gpt-4") {
+		t.Errorf("expected a warning with suggestions, got exit %d, stderr:\n%s", exitCode, stderr)
+	}
+	_, stderr, exitCode = runTcount(t, "--strict", "--model", "gpt-4p", file)
+	if exitCode != 1 || !strings.Contains(stderr, "model not found") || !strings.Contains(stderr, "did you mean") {
+		t.Errorf("expected --strict to fail with suggestions, got exit %d, stderr:\n%s", exitCode, stderr)
+	}
+	_, stderr, exitCode = runTcount(t, "--cost-models", "gpt-4o,gpt-4p", file)
+	if exitCode != 1 || !strings.Contains(stderr, `unknown model "gpt-4p" in --cost-models`) || !strings.Contains(stderr, "did you mean") {
+		t.Errorf("expected --cost-models to name the unknown model, got exit %d, stderr:\n%s", exitCode, stderr)
+	}
+	if _, stderr, exitCode := runTcount(t, "--strict", "--model", "claude-sonnet-4-5-20250929", file); exitCode != 0 {
+		t.Errorf("expected a snapshot name to pass --strict, got exit %d, stderr:\n%s", exitCode, stderr)
+	}
+}
+
+func TestIntegrationRegistry_ModelsListing(t *testing.T) {
+	type model struct {
+		Name            string  `json:"name"`
+		Provider        string  `json:"provider"`
+		Encoding        string  `json:"encoding"`
+		Tokenizer       string  `json:"tokenizer"`
+		Accuracy        string  `json:"accuracy"`
+		ContextWindow   int     `json:"context_window"`
+		MaxOutputTokens int     `json:"max_output_tokens"`
+		InputPricePer1M float64 `json:"input_price_per_1m"`
+		CacheReadPer1M  float64 `json:"cache_read_price_per_1m"`
+	}
+	list := func(args ...string) []model {
+		t.Helper()
+		stdout, stderr, exitCode := runTcount(t, append([]string{"models", "--json", "--max-pricing-age", "0"}, args...)...)
+		if exitCode != 0 {
+			t.Fatalf("models %v: exit %d\nstderr: %s", args, exitCode, stderr)
+		}
+		var report struct {
+			Models []model `json:"models"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+			t.Fatalf("failed to parse JSON output: %v\nraw: %s", err, stdout)
+		}
+		return report.Models
+	}
+
+	for _, m := range list() {
+		want := map[string][2]string{
+			"gpt-4o":            {"tiktoken", "exact"},
+			"claude-sonnet-4.5": {"claude-approx", "estimated"},
+			"llama-3.1-8b":      {"tiktoken", "proxy"},
+		}[m.Name]
+		if want[0] != "" && (m.Tokenizer != want[0] || m.Accuracy != want[1]) {
+			t.Errorf("%s: tokenizer %s/%s, want %s/%s", m.Name, m.Tokenizer, m.Accuracy, want[0], want[1])
+		}
+		if m.Name == "gpt-4o" && (m.MaxOutputTokens != 16384 || m.CacheReadPer1M != 1.25) {
+			t.Errorf("gpt-4o limits or cache price missing: %+v", m)
+		}
+	}
+
+	for _, m := range list("--encoding", "claude_approx") {
+		if m.Encoding != "claude_approx" || m.Provider != "anthropic" {
+			t.Errorf("--encoding claude_approx listed %+v", m)
+		}
+	}
+
+	byPrice := list("--provider", "openai", "--sort", "input-price")
+	for i := 1; i < len(byPrice); i++ {
+		prev, cur := byPrice[i-1].InputPricePer1M, byPrice[i].InputPricePer1M
+		if cur != 0 && (prev == 0 || prev > cur) {
+			t.Fatalf("not sorted by input price at %s (%v) after %s (%v)", byPrice[i].Name, cur, byPrice[i-1].Name, prev)
+		}
+	}
+	byContext := list("--sort", "context")
+	if len(byContext) == 0 || !strings.HasPrefix(byContext[0].Name, "gpt-4.1") {
+		t.Errorf("expected the 1M-context models first, got %+v", byContext[0])
+	}
+
+	stdout, stderr, exitCode := runTcount(t, "models", "--csv", "--provider", "anthropic", "--max-pricing-age", "0")
+	if exitCode != 0 {
+		t.Fatalf("models --csv: exit %d\nstderr: %s", exitCode, stderr)
+	}
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV output: %v\nraw: %s", err, stdout)
+	}
+	if len(records) < 2 || records[0][0] != "name" || records[0][6] != "max_output_tokens" {
+		t.Fatalf("unexpected CSV header: %v", records)
+	}
+	for _, r := range records[1:] {
+		if r[1] != "anthropic" || len(r) != len(records[0]) {
+			t.Errorf("unexpected CSV row: %v", r)
+		}
+	}
+
+	if _, _, exitCode := runTcount(t, "models", "--sort", "bogus"); exitCode == 0 {
+		t.Error("expected an invalid --sort key to fail")
+	}
+	if _, stderr, exitCode := runTcount(t, "models", "--encoding", "o200k"); exitCode == 0 || !strings.Contains(stderr, "o200k_base, cl100k_base") {
+		t.Errorf("expected an unknown --encoding to fail listing the valid ones, got exit %d, stderr:\n%s", exitCode, stderr)
+	}
+	if _, _, exitCode := runTcount(t, "models", "--json", "--csv"); exitCode == 0 {
+		t.Error("expected --json and --csv to be mutually exclusive")
+	}
+}
+
+func TestIntegrationRegistry_Lifecycle(t *testing.T) {
+	gpt4o := tokenizer.GetModelMetadata("gpt-4o")
+	if gpt4o.MaxOutputTokens != 16384 || !gpt4o.SupportsModality(tokenizer.ModalityImage) || gpt4o.SupportsModality(tokenizer.ModalityAudio) {
+		t.Errorf("unexpected gpt-4o metadata: %+v", gpt4o)
+	}
+	if gpt4o.KnowledgeCutoff.IsZero() {
+		t.Error("expected a knowledge cutoff for gpt-4o")
+	}
+	for input, want := range map[int]int{1000: 16384, 120000: 8000, 200000: 0} {
+		if got, ok := gpt4o.OutputHeadroom(input); !ok || got != want {
+			t.Errorf("OutputHeadroom(%d) = %d, %v, want %d", input, got, ok, want)
+		}
+	}
+	if _, ok := (&tokenizer.ModelMetadata{Name: "x"}).OutputHeadroom(10); ok {
+		t.Error("expected no headroom without a context window")
+	}
+
+	opus3 := tokenizer.GetModelMetadata("claude-opus-3")
+	for _, tt := range []struct {
+		now  time.Time
+		want tokenizer.ModelStatus
+	}{
+		{time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), tokenizer.ModelStatusActive},
+		{time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC), tokenizer.ModelStatusDeprecated},
+		{time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC), tokenizer.ModelStatusRetired},
+	} {
+		if got := opus3.Status(tt.now); got != tt.want {
+			t.Errorf("claude-opus-3 status at %s = %s, want %s", tt.now.Format(time.DateOnly), got, tt.want)
+		}
+	}
+
+	overrides, err := tokenizer.ParseRegistryOverrides([]byte("models:\n  gpt-4o:\n    deprecation_date: 2020-01-01\n    retirement_date: 2999-01-01\n"))
+	if err != nil {
+		t.Fatalf("ParseRegistryOverrides: %v", err)
+	}
+	registry := tokenizer.NewRegistry()
+	if err := registry.ApplyOverrides(overrides); err != nil {
+		t.Fatalf("ApplyOverrides: %v", err)
+	}
+	if meta, _ := registry.Lookup("gpt-4o"); meta.Status(time.Now()) != tokenizer.ModelStatusDeprecated {
+		t.Errorf("expected gpt-4o deprecated by the override, got %s", meta.Status(time.Now()))
+	}
+	if _, err := tokenizer.ParseRegistryOverrides([]byte("models:\n  gpt-4o:\n    retirement_date: soon\n")); err == nil {
+		t.Error("expected an invalid date to fail")
+	}
+
+	file := fixturesDir(t) + "/sample.txt"
+	_, stderr, exitCode := runTcount(t, "--model", "claude-opus-3", file)
+	if exitCode != 0 || !strings.Contains(stderr, "claude-opus-3 was retired on 2026-01-05") {
+		t.Errorf("expected a retirement warning, got exit %d, stderr:\n%s", exitCode, stderr)
+	}
+	if _, stderr, _ := runTcount(t, "--model", "gpt-4o", file); strings.Contains(stderr, "retired") || strings.Contains(stderr, "deprecated") {
+		t.Errorf("unexpected lifecycle warning for gpt-4o:\n%s", stderr)
+	}
+
+	result := runTcountJSON(t, "--model", "gpt-4o", file)
+	if len(result.Methods) != 1 || result.Methods[0].OutputHeadroom != 16384 {
+		t.Errorf("expected the output headroom capped at max output: %+v", result.Methods)
+	}
+}
+
+func TestIntegrationRegistry_PriceHistory(t *testing.T) {
+	gpt4o := tokenizer.GetModelMetadata("gpt-4o")
+	if then := gpt4o.AsOf(time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)); then.InputPricePer1M != 5.00 || then.OutputPricePer1M != 15.00 {
+		t.Errorf("gpt-4o prices on 2024-06-01 = $%.2f/$%.2f, want $5.00/$15.00", then.InputPricePer1M, then.OutputPricePer1M)
+	}
+	if now := gpt4o.AsOf(time.Date(2024, time.October, 2, 0, 0, 0, 0, time.UTC)); now.InputPricePer1M != gpt4o.InputPricePer1M {
+		t.Errorf("expected current prices from the day of the change, got $%.2f", now.InputPricePer1M)
+	}
+	if before := gpt4o.AsOf(time.Date(2024, time.May, 12, 0, 0, 0, 0, time.UTC)); before.InputPricePer1M != 0 || before.OutputPricePer1M != 0 {
+		t.Errorf("expected no gpt-4o prices before its 2024-05-13 release, got $%.2f/$%.2f", before.InputPricePer1M, before.OutputPricePer1M)
+	}
+
+	file := fixturesDir(t) + "/sample.txt"
+	result := runTcountJSON(t, "--as-of", "2024-06-01", "--cost-models", "gpt-4o", file)
+	if len(result.Costs) != 1 || result.Costs[0].RatePer1M != 5.00 || result.Costs[0].PricesAsOf != "2024-06-01" {
+		t.Errorf("expected gpt-4o priced at $5.00/1M as of 2024-06-01: %+v", result.Costs)
+	}
+	if result := runTcountJSON(t, "--as-of", "2024-01-01", "--cost-models", "gpt-4o", file); len(result.Costs) != 0 {
+		t.Errorf("expected gpt-4o to be unpriced before its release: %+v", result.Costs)
+	}
+	if _, stderr, exitCode := runTcount(t, "--as-of", "06/01/2024", file); exitCode != 1 || !strings.Contains(stderr, "invalid --as-of date") {
+		t.Errorf("expected an invalid date to fail, got exit %d, stderr:\n%s", exitCode, stderr)
+	}
+
+	stdout, stderr, exitCode := runTcount(t, "models", "--price-history", "gpt-4o-2024-08-06", "--json")
+	if exitCode != 0 {
+		t.Fatalf("models --price-history failed with exit %d:\n%s", exitCode, stderr)
+	}
+	var report struct {
+		Model   string `json:"model"`
+		Periods []struct {
+			From            string  `json:"from"`
+			Until           string  `json:"until"`
+			InputPricePer1M float64 `json:"input_price_per_1m"`
+		} `json:"periods"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("parsing price history JSON: %v\n%s", err, stdout)
+	}
+	if report.Model != "gpt-4o" || len(report.Periods) != 2 || report.Periods[0].From != "2024-05-13" || report.Periods[0].Until != "2024-10-02" ||
+		report.Periods[1].From != "2024-10-02" || report.Periods[1].Until != "" {
+		t.Errorf("unexpected gpt-4o price history: %+v", report)
+	}
+	if _, stderr, exitCode := runTcount(t, "models", "--price-history", "gpt-4x"); exitCode == 0 ||
+		!strings.Contains(stderr, `unknown model "gpt-4x"`) || !strings.Contains(stderr, "did you mean") {
+		t.Errorf("expected an unknown model to fail with suggestions, got exit %d, stderr:\n%s", exitCode, stderr)
+	}
+
+	registryFile := filepath.Join(t.TempDir(), "prices.yaml")
+	overrides := "models:\n  gpt-4.1:\n    release_date: 2024-06-01\n    price_history:\n      - until: 2025-01-01\n        input_price_per_1m: 4.0\n        output_price_per_1m: 12.0\n"
+	if err := os.WriteFile(registryFile, []byte(overrides), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result = runTcountJSON(t, "--registry", registryFile, "--as-of", "2024-12-31", "--cost-models", "gpt-4.1", file)
+	if len(result.Costs) != 1 || result.Costs[0].RatePer1M != 4.0 || result.Costs[0].OutputRatePer1M != 12.0 {
+		t.Errorf("expected the override price history to apply: %+v", result.Costs)
+	}
+	stdout, _, _ = runTcount(t, "--registry", registryFile, "models", "--price-history", "gpt-4.1", "--csv")
+	if !strings.Contains(stdout, "gpt-4.1,2024-06-01,2025-01-01,4,12,0,0") {
+		t.Errorf("expected the override period in CSV output:\n%s", stdout)
+	}
+
+	for _, bad := range []string{
+		"models:\n  gpt-4o:\n    price_history:\n      - until: 2024-10-02\n      - until: 2024-01-01\n",
+		"models:\n  gpt-4o:\n    price_history:\n      - input_price_per_1m: 1.0\n",
+		"models:\n  gpt-4o:\n    price_history:\n      - until: 2024-01-01\n        input_price_per_1m: -1\n",
+	} {
+		if _, err := tokenizer.ParseRegistryOverrides([]byte(bad)); err == nil {
+			t.Errorf("expected an invalid price history to fail:\n%s", bad)
+		}
+	}
+}
+
+func TestIntegrationRegistry_CounterOverridesArePrivate(t *testing.T) {
+	before := tokenizer.GetModelMetadata("gpt-4o").InputPricePer1M
+	overrides, err := tokenizer.ParseRegistryOverrides([]byte("models:\n  gpt-4o:\n    input_price_per_1m: 99\n"))
+	if err != nil {
+		t.Fatalf("ParseRegistryOverrides: %v", err)
+	}
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{RegistryOverrides: overrides})
+	if err != nil {
+		t.Fatalf("NewCounter: %v", err)
+	}
+
+	if meta, _ := counter.Registry().Lookup("gpt-4o"); meta.InputPricePer1M != 99 {
+		t.Errorf("expected the counter's registry to carry the override, got $%.2f", meta.InputPricePer1M)
+	}
+	if got := tokenizer.GetModelMetadata("gpt-4o").InputPricePer1M; got != before {
+		t.Errorf("counter overrides leaked into the default registry: $%.2f, want $%.2f", got, before)
+	}
+	if fields := tokenizer.OverriddenFields("gpt-4o"); fields != nil {
+		t.Errorf("expected no overridden fields in the default registry, got %v", fields)
+	}
+}
+
+func TestIntegrationRegistry_ApplyOverridesValidation(t *testing.T) {
+	registry := tokenizer.NewRegistry()
+	if err := registry.Register(tokenizer.ModelMetadata{Name: "acme-only", Encoding: "o200k_base"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	partial, err := tokenizer.ParseRegistryOverrides([]byte("models:\n  acme-only:\n    input_price_per_1m: 1\n"))
+	if err != nil {
+		t.Fatalf("a partial override of a custom registry's model should parse: %v", err)
+	}
+	if err := registry.ApplyOverrides(partial); err != nil {
+		t.Errorf("expected the partial override to apply to the custom registry: %v", err)
+	}
+	if err := tokenizer.NewRegistry().ApplyOverrides(partial); err == nil {
+		t.Error("expected a partial override of an unknown model to fail")
+	}
+
+	// zz-new sorts after gpt-4o, which must stay unchanged.
+	mixed, err := tokenizer.ParseRegistryOverrides([]byte("models:\n  gpt-4o:\n    input_price_per_1m: 42\n  zz-new:\n    input_price_per_1m: 1\n"))
+	if err != nil {
+		t.Fatalf("ParseRegistryOverrides: %v", err)
+	}
+	if err := registry.ApplyOverrides(mixed); err == nil {
+		t.Error("expected a new model without an encoding to fail")
+	}
+	if meta, _ := registry.Lookup("gpt-4o"); meta.InputPricePer1M == 42 {
+		t.Error("a failed ApplyOverrides changed the registry")
+	}
+}
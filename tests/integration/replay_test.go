@@ -0,0 +1,87 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+)
+
+func TestIntegrationReplay_CumulativeInput(t *testing.T) {
+	file := fixturesDir(t) + "/replay/transcript.jsonl"
+	stdout, stderr, exitCode := runTcount(t, "replay", "--json", file)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+
+	var result tokenizer.ReplayResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nraw: %s", err, stdout)
+	}
+
+	// The session metadata line is skipped and wrapped messages unwrapped.
+	if result.Messages != 7 {
+		t.Errorf("messages = %d, want 7", result.Messages)
+	}
+	if len(result.Steps) != 3 {
+		t.Fatalf("steps = %d, want 3", len(result.Steps))
+	}
+
+	sum := 0
+	for i, step := range result.Steps {
+		if i > 0 && step.InputTokens <= result.Steps[i-1].InputTokens+result.Steps[i-1].OutputTokens {
+			t.Errorf("step %d input %d does not include the previous step's history", step.Step, step.InputTokens)
+		}
+		sum += step.InputTokens
+		if step.CumulativeInput != sum {
+			t.Errorf("step %d cumulative input = %d, want %d", step.Step, step.CumulativeInput, sum)
+		}
+	}
+	if result.TotalInputTokens <= result.TranscriptTokens {
+		t.Errorf("total input %d should exceed the final transcript's %d tokens", result.TotalInputTokens, result.TranscriptTokens)
+	}
+	if math.Abs(result.TotalCost-result.InputCost-result.OutputCost) > 1e-12 || result.TotalCost <= 0 {
+		t.Errorf("costs = %v input + %v output, total %v", result.InputCost, result.OutputCost, result.TotalCost)
+	}
+	if result.OverflowStep != 0 {
+		t.Errorf("overflow step = %d, want none", result.OverflowStep)
+	}
+}
+
+func TestIntegrationReplay_CacheAndOverflow(t *testing.T) {
+	chunk := strings.Repeat("history ", 1500)
+	var messages []string
+	for i := 0; i < 6; i++ {
+		messages = append(messages,
+			fmt.Sprintf(`{"role":"user","content":%q}`, chunk),
+			`{"role":"assistant","content":"noted"}`)
+	}
+	req, err := tokenizer.ParseTranscript([]byte(strings.Join(messages, "\n")))
+	if err != nil {
+		t.Fatalf("ParseTranscript() error: %v", err)
+	}
+
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{})
+	if err != nil {
+		t.Fatalf("NewCounter() error: %v", err)
+	}
+	result, err := counter.CountReplay(context.Background(), req, tokenizer.ReplayOptions{Model: "gpt-4", Cache: true})
+	if err != nil {
+		t.Fatalf("CountReplay() error: %v", err)
+	}
+
+	// gpt-4 has an 8,192 token window; each user turn adds ~1,500 tokens.
+	if result.OverflowStep != 6 {
+		t.Errorf("overflow step = %d, want 6", result.OverflowStep)
+	}
+	if result.Steps[0].CachedTokens != 0 || result.Steps[1].CachedTokens != result.Steps[0].InputTokens {
+		t.Errorf("step 2 should read step 1's prompt from the cache: %+v", result.Steps[:2])
+	}
+	if result.CacheRead != 0.5 || result.TotalCost >= result.UncachedCost {
+		t.Errorf("cached cost %v should be below uncached %v (read %v)", result.TotalCost, result.UncachedCost, result.CacheRead)
+	}
+}
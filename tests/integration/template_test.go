@@ -0,0 +1,131 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lancekrogers/go-token-counter/tokenizer"
+)
+
+// chatJSON runs "tcount chat --json" with args and decodes the result.
+func chatJSON(t *testing.T, args ...string) tokenizer.ChatCountResult {
+	t.Helper()
+	stdout, stderr, exitCode := runTcount(t, append([]string{"chat", "--json"}, args...)...)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	var result tokenizer.ChatCountResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nraw: %s", err, stdout)
+	}
+	return result
+}
+
+func TestIntegrationChat_OpenWeightTemplates(t *testing.T) {
+	file := fixturesDir(t) + "/chat/completion.json"
+	tests := []struct {
+		model    string
+		template string
+	}{
+		{"llama-3.1-8b", "llama3"},
+		{"llama-4-scout", "llama4"},
+		{"qwen-2.5-7b", "qwen2.5"},
+		{"qwen-3-72b", "chatml"},
+		{"phi-3-mini", "phi3"},
+		{"deepseek-v3", "deepseek-v3"},
+		{"mistral-7b-instruct", "mistral"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.model, func(t *testing.T) {
+			result := chatJSON(t, "--model", tc.model, file)
+			if result.Template != tc.template {
+				t.Errorf("template = %q, want %q", result.Template, tc.template)
+			}
+
+			sum := result.ReplyPriming
+			for _, m := range result.Messages {
+				sum += m.Tokens
+			}
+			if sum != result.TotalTokens {
+				t.Errorf("message tokens plus generation prompt = %d, total = %d", sum, result.TotalTokens)
+			}
+		})
+	}
+}
+
+func TestIntegrationChat_Llama3TemplateOverhead(t *testing.T) {
+	file := fixturesDir(t) + "/chat/completion.json"
+	templated := chatJSON(t, "--model", "llama-3.1-8b", file)
+
+	// <|start_header_id|>user<|end_header_id|>\n\n ... <|eot_id|>
+	if got := templated.Messages[1].OverheadTokens; got != 5 {
+		t.Errorf("user turn overhead = %d, want 5", got)
+	}
+	// <|start_header_id|>assistant<|end_header_id|>\n\n
+	if templated.ReplyPriming != 4 {
+		t.Errorf("generation prompt = %d, want 4", templated.ReplyPriming)
+	}
+
+	plain := chatJSON(t, "--model", "llama-3.1-8b", "--template", "none", file)
+	if plain.Template != "" {
+		t.Errorf("expected no template with --template none, got %q", plain.Template)
+	}
+	// The system header with the knowledge cutoff and date is only part of
+	// the templated prompt.
+	if templated.TotalTokens <= plain.TotalTokens {
+		t.Errorf("templated total %d should exceed plain total %d", templated.TotalTokens, plain.TotalTokens)
+	}
+}
+
+func TestIntegrationChat_HuggingFaceTemplate(t *testing.T) {
+	file := fixturesDir(t) + "/chat/completion.json"
+	config := fixturesDir(t) + "/chat/qwen3/tokenizer_config.json"
+
+	// Without tools or trailing reasoning the Qwen3 template renders plain ChatML.
+	hf := chatJSON(t, "--model", "qwen-3-72b", "--template", config, file)
+	chatml := chatJSON(t, "--model", "qwen-3-72b", "--template", "chatml", file)
+	if hf.TotalTokens != chatml.TotalTokens {
+		t.Errorf("tokenizer_config.json template total = %d, chatml = %d", hf.TotalTokens, chatml.TotalTokens)
+	}
+
+	// A model directory works as well as the config file itself.
+	dir := chatJSON(t, "--model", "qwen-3-72b", "--template", fixturesDir(t)+"/chat/qwen3", file)
+	if dir.TotalTokens != hf.TotalTokens {
+		t.Errorf("directory template total = %d, want %d", dir.TotalTokens, hf.TotalTokens)
+	}
+}
+
+func TestIntegrationChat_HuggingFaceTemplateToolCalls(t *testing.T) {
+	tmpl, err := tokenizer.LoadChatTemplate(fixturesDir(t) + "/chat/qwen3/tokenizer_config.json")
+	if err != nil {
+		t.Fatalf("LoadChatTemplate() error: %v", err)
+	}
+	if tmpl.EOSToken != "<|im_end|>" {
+		t.Errorf("eos token = %q, want <|im_end|>", tmpl.EOSToken)
+	}
+
+	messages := []tokenizer.ChatMessage{
+		{Role: "user", Content: "What's the weather in Paris?"},
+		{Role: "assistant", ToolCalls: []tokenizer.ToolCall{{ID: "call_1", Name: "get_weather", Arguments: `{"location": "Paris"}`}}},
+		{Role: "tool", Content: "18C and sunny", ToolCallID: "call_1"},
+	}
+	prompt, err := tmpl.Render(messages, true)
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+
+	for _, want := range []string{
+		"<|im_start|>user\nWhat's the weather in Paris?<|im_end|>\n",
+		"<tool_call>\n{\"name\": \"get_weather\", \"arguments\": {\"location\": \"Paris\"}}\n</tool_call><|im_end|>\n",
+		"<|im_start|>user\n<tool_response>\n18C and sunny\n</tool_response><|im_end|>\n",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("rendered prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	if !strings.HasSuffix(prompt, "<|im_start|>assistant\n") {
+		t.Errorf("expected generation prompt at end:\n%s", prompt)
+	}
+}
@@ -0,0 +1,73 @@
+package tokenizer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// modelAliases maps names used by provider APIs that normalization alone
+// does not reach to built-in models. Keys are in normalized form.
+var modelAliases = map[string]string{
+	"chatgpt-4o":          "gpt-4o",
+	"gpt-4o-chat":         "gpt-4o",
+	"gpt-5-chat":          "gpt-5",
+	"gpt-4-turbo-preview": "gpt-4-turbo",
+	"claude-opus-4.0":     "claude-opus-4",
+	"claude-sonnet-4.0":   "claude-sonnet-4",
+	"deepseek-chat":       "deepseek-v3",
+	"deepseek-coder":      "deepseek-coder-v2",
+}
+
+var (
+	// snapshotSuffix matches the suffixes of pinned and floating model
+	// versions and variants: -latest, -2024-08-06, -20250929, -0613, a context
+	// size such as -4k, and -instruct.
+	snapshotSuffix = regexp.MustCompile(`-(latest|\d{4}-\d{2}-\d{2}|\d{8}|\d{4}|\d+k|instruct)$`)
+
+	// joinedVersion matches a family name written without a dash before its
+	// version, as in qwen2.5 or llama3.1.
+	joinedVersion = regexp.MustCompile(`^([a-z]{2,})(\d)`)
+
+	// dashedVersion matches a minor version written with a dash, as in
+	// claude-sonnet-4-5 or llama-3-1-8b.
+	dashedVersion = regexp.MustCompile(`(\d)-(\d)(-|$)`)
+
+	// versionFirstClaude matches the older Claude naming with the version
+	// before the family, as in claude-3.5-sonnet.
+	versionFirstClaude = regexp.MustCompile(`^claude-(\d+(?:\.\d+)?)-(opus|sonnet|haiku)`)
+)
+
+// NormalizeModelName rewrites a model name from a provider API, gateway or
+// config to the registry's naming:
+//
+//   - without a provider prefix (openai/gpt-4.1) or a Vertex
+//     version (claude-sonnet-4-5@20250929)
+//   - without -latest, dated snapshot (gpt-4o-2024-08-06,
+//     claude-sonnet-4-5-20250929, gpt-4-0613) or -instruct suffixes
+//   - with dotted versions (claude-sonnet-4-5 → claude-sonnet-4.5,
+//     qwen2.5 → qwen-2.5) and the family before the version
+//     (claude-3-5-haiku → claude-haiku-3.5)
+//
+// Names are case-sensitive. The result is not necessarily a registered
+// model.
+func NormalizeModelName(name string) string {
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	for {
+		trimmed := snapshotSuffix.ReplaceAllString(name, "")
+		if trimmed == name {
+			break
+		}
+		name = trimmed
+	}
+	name = strings.TrimPrefix(name, "meta-")
+	name = joinedVersion.ReplaceAllString(name, "$1-$2")
+	name = dashedVersion.ReplaceAllString(name, "$1.$2$3")
+	name = versionFirstClaude.ReplaceAllString(name, "claude-$2-$1")
+	return name
+}
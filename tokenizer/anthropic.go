@@ -0,0 +1,410 @@
+package tokenizer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lancekrogers/go-token-counter/tokenizer/fileops"
+)
+
+// Anthropic does not publish its prompt framing, so these overheads are
+// estimates calibrated against the count_tokens endpoint for short prompts.
+const (
+	// anthropicMessageOverhead covers the role turn markers around each message.
+	anthropicMessageOverhead = 4
+
+	// anthropicBlockOverhead covers the wrapper around each non-text content block.
+	anthropicBlockOverhead = 3
+
+	// anthropicReplyPriming covers the assistant turn opened for the response.
+	anthropicReplyPriming = 3
+
+	// anthropicMaxImageTokens is the cost of an image at Anthropic's maximum
+	// resolution, used when the image dimensions cannot be determined.
+	anthropicMaxImageTokens = 1600
+)
+
+// AnthropicRequest is a parsed Anthropic /v1/messages request body.
+type AnthropicRequest struct {
+	Model      string             `json:"model,omitempty"`
+	System     []AnthropicBlock   `json:"system,omitempty"`
+	Messages   []AnthropicMessage `json:"messages"`
+	Tools      []ToolDefinition   `json:"tools,omitempty"`
+	ToolChoice string             `json:"tool_choice,omitempty"`
+}
+
+// AnthropicMessage is a single user or assistant turn.
+// String content is normalized to a single text block.
+type AnthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []AnthropicBlock `json:"content"`
+}
+
+// AnthropicBlock is a content block of a message, system prompt or tool result.
+type AnthropicBlock struct {
+	Type      string           `json:"type"`
+	Text      string           `json:"text,omitempty"`
+	Source    *AnthropicSource `json:"source,omitempty"`
+	Title     string           `json:"title,omitempty"`
+	Context   string           `json:"context,omitempty"`
+	ID        string           `json:"id,omitempty"`
+	Name      string           `json:"name,omitempty"`
+	Input     json.RawMessage  `json:"input,omitempty"`
+	ToolUseID string           `json:"tool_use_id,omitempty"`
+	Content   []AnthropicBlock `json:"content,omitempty"`
+}
+
+// AnthropicSource is the source of an image or document block.
+type AnthropicSource struct {
+	Type      string           `json:"type"`
+	MediaType string           `json:"media_type,omitempty"`
+	Data      string           `json:"data,omitempty"`
+	URL       string           `json:"url,omitempty"`
+	Content   []AnthropicBlock `json:"content,omitempty"`
+}
+
+// ParseAnthropicRequest parses an Anthropic /v1/messages request body.
+// The system prompt and message content may be strings or block arrays.
+func ParseAnthropicRequest(data []byte) (*AnthropicRequest, error) {
+	var raw struct {
+		Model      string             `json:"model"`
+		System     json.RawMessage    `json:"system"`
+		Messages   []AnthropicMessage `json:"messages"`
+		Tools      []json.RawMessage  `json:"tools"`
+		ToolChoice json.RawMessage    `json:"tool_choice"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing messages request: %w", err)
+	}
+	if len(raw.Messages) == 0 {
+		return nil, fmt.Errorf("messages request has no messages")
+	}
+
+	system, err := parseAnthropicContent(raw.System)
+	if err != nil {
+		return nil, fmt.Errorf("parsing system prompt: %w", err)
+	}
+
+	tools, err := parseToolDefinitions(raw.Tools)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AnthropicRequest{
+		Model:      raw.Model,
+		System:     system,
+		Messages:   raw.Messages,
+		Tools:      tools,
+		ToolChoice: parseToolChoice(raw.ToolChoice),
+	}, nil
+}
+
+// UnmarshalJSON decodes a message whose content may be a string or blocks.
+func (m *AnthropicMessage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	content, err := parseAnthropicContent(raw.Content)
+	if err != nil {
+		return err
+	}
+	*m = AnthropicMessage{Role: raw.Role, Content: content}
+	return nil
+}
+
+// UnmarshalJSON decodes a block whose nested content (tool results) may be a
+// string or blocks.
+func (b *AnthropicBlock) UnmarshalJSON(data []byte) error {
+	type plain AnthropicBlock
+	var raw struct {
+		plain
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	content, err := parseAnthropicContent(raw.Content)
+	if err != nil {
+		return err
+	}
+	*b = AnthropicBlock(raw.plain)
+	b.Content = content
+	return nil
+}
+
+// parseAnthropicContent decodes string or block-array content into blocks.
+func parseAnthropicContent(raw json.RawMessage) ([]AnthropicBlock, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+	if raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return []AnthropicBlock{{Type: "text", Text: s}}, nil
+	}
+	var blocks []AnthropicBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// CountAnthropicRequest estimates the input tokens of an Anthropic Messages
+// API request using the Claude approximator. The system prompt, text,
+// tool_use and tool_result blocks, documents and images are estimated
+// individually with per-message and per-block overheads, and tools are
+// counted with the tool-use system prompt for the request's tool_choice.
+// System blocks are estimated the same way as message content blocks.
+//
+// Thinking blocks are skipped because the API strips them from prior turns.
+// Parts that cannot be estimated offline are reported in Notes.
+func (c *Counter) CountAnthropicRequest(ctx context.Context, req *AnthropicRequest, model string) (*ChatCountResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	tok := c.tokenizers["claude_approx"]
+	meta := c.registry.get(model)
+
+	result := &ChatCountResult{
+		Model:        model,
+		Encoding:     "claude_approx",
+		IsExact:      tok.IsExact(),
+		Messages:     make([]MessageCount, 0, len(req.Messages)),
+		ReplyPriming: anthropicReplyPriming,
+	}
+	est := &anthropicEstimator{tok: tok, result: result}
+
+	system, err := est.countBlocks(req.System)
+	if err != nil {
+		return nil, fmt.Errorf("counting system prompt: %w", err)
+	}
+	systemFraming := system.blocks * anthropicBlockOverhead
+	result.Breakdown.System = system.text + systemFraming
+	result.Breakdown.Images = system.images
+	result.Breakdown.Documents = system.documents
+
+	total := anthropicReplyPriming + system.text + system.images + system.documents + systemFraming
+	for i, msg := range req.Messages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		counts, err := est.countBlocks(msg.Content)
+		if err != nil {
+			return nil, fmt.Errorf("counting message %d: %w", i, err)
+		}
+
+		content := counts.text + counts.images + counts.documents
+		framing := anthropicMessageOverhead + counts.blocks*anthropicBlockOverhead
+		result.Messages = append(result.Messages, MessageCount{
+			Index:          i,
+			Role:           msg.Role,
+			ContentTokens:  content,
+			OverheadTokens: framing,
+			Tokens:         content + framing,
+		})
+		result.Breakdown.Messages += counts.text + framing
+		result.Breakdown.Images += counts.images
+		result.Breakdown.Documents += counts.documents
+		total += content + framing
+	}
+
+	toolTokens, err := countAnthropicTools(tok, req.Tools, model, req.ToolChoice)
+	if err != nil {
+		return nil, fmt.Errorf("counting tools: %w", err)
+	}
+	result.Breakdown.Tools = toolTokens
+	total += toolTokens
+
+	result.TotalTokens = total
+	result.InputCost = inputCost(meta, total)
+	result.ContextWindow = contextWindow(meta, total)
+	result.OutputHeadroom = outputHeadroom(meta, total)
+	return result, nil
+}
+
+// anthropicEstimator accumulates token estimates for content blocks.
+type anthropicEstimator struct {
+	tok    Tokenizer
+	result *ChatCountResult
+}
+
+// blockCounts holds token estimates for a list of blocks by category.
+type blockCounts struct {
+	text      int
+	images    int
+	documents int
+	blocks    int // non-text blocks that carry wrapper overhead
+}
+
+func (e *anthropicEstimator) countBlocks(blocks []AnthropicBlock) (blockCounts, error) {
+	var counts blockCounts
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			n, err := e.tok.CountTokens(b.Text)
+			if err != nil {
+				return counts, err
+			}
+			counts.text += n
+
+		case "image":
+			counts.images += e.imageTokens(b.Source)
+			counts.blocks++
+
+		case "document":
+			n, err := e.documentTokens(b)
+			if err != nil {
+				return counts, err
+			}
+			counts.documents += n
+			counts.blocks++
+
+		case "tool_use", "server_tool_use":
+			n, err := e.tok.CountTokens(b.ID + b.Name + string(b.Input))
+			if err != nil {
+				return counts, err
+			}
+			counts.text += n
+			counts.blocks++
+
+		case "tool_result":
+			nested, err := e.countBlocks(b.Content)
+			if err != nil {
+				return counts, err
+			}
+			idTokens, err := e.tok.CountTokens(b.ToolUseID)
+			if err != nil {
+				return counts, err
+			}
+			counts.text += nested.text + idTokens
+			counts.images += nested.images
+			counts.documents += nested.documents
+			counts.blocks += nested.blocks + 1
+
+		case "thinking", "redacted_thinking":
+			// Stripped from prior turns by the API.
+
+		default:
+			e.note("unsupported %q block not estimated", b.Type)
+		}
+	}
+	return counts, nil
+}
+
+// imageTokens estimates an image block. Base64 sources are sized from their
+// headers; other sources are assumed to be at the maximum size Anthropic
+// processes.
+func (e *anthropicEstimator) imageTokens(source *AnthropicSource) int {
+	if source != nil && source.Type == "base64" {
+		if info, err := decodeBase64Image(source.Data); err == nil {
+			return AnthropicImageTokens(info.Width, info.Height)
+		}
+	}
+	e.note("image size unknown, assumed maximum of %d tokens", anthropicMaxImageTokens)
+	return anthropicMaxImageTokens
+}
+
+// documentTokens estimates a document block's text, title and context.
+func (e *anthropicEstimator) documentTokens(b AnthropicBlock) (int, error) {
+	total, err := e.tok.CountTokens(b.Title + b.Context)
+	if err != nil {
+		return 0, err
+	}
+	if b.Source == nil {
+		return total, nil
+	}
+
+	switch b.Source.Type {
+	case "text":
+		n, err := e.tok.CountTokens(b.Source.Data)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	case "content":
+		nested, err := e.countBlocks(b.Source.Content)
+		if err != nil {
+			return 0, err
+		}
+		total += nested.text + nested.images + nested.documents
+	case "base64":
+		n, err := e.pdfTokens(b.Source)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	default:
+		e.note("%s document source not estimated", b.Source.Type)
+	}
+	return total, nil
+}
+
+// pdfTokens estimates a base64 PDF document as its extracted text plus the
+// per-page image overhead. Unparseable PDFs are noted and count as zero.
+func (e *anthropicEstimator) pdfTokens(source *AnthropicSource) (int, error) {
+	if source.MediaType != "application/pdf" {
+		e.note("%s document not estimated", source.MediaType)
+		return 0, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(source.Data)
+	if err != nil {
+		e.note("pdf document could not be decoded")
+		return 0, nil
+	}
+	pdf, err := fileops.ParsePDF(raw)
+	if err != nil {
+		e.note("pdf document could not be parsed")
+		return 0, nil
+	}
+	n, err := e.tok.CountTokens(pdf.Text)
+	if err != nil {
+		return 0, err
+	}
+	return n + AnthropicPDFPageTokens(pdf.Pages), nil
+}
+
+// note records a distinct estimation caveat on the result.
+func (e *anthropicEstimator) note(format string, args ...any) {
+	e.result.addNote(format, args...)
+}
+
+// inputCost prices input tokens at the model's registry rate for a prompt
+// of that size. Returns 0 for unregistered or unpriced models.
+func inputCost(meta *ModelMetadata, tokens int) float64 {
+	if meta == nil {
+		return 0
+	}
+	return float64(tokens) * meta.PricesFor(tokens).InputPricePer1M / 1_000_000.0
+}
+
+// contextWindow returns the context window a request of tokens is checked
+// against, or 0 for unknown models.
+func contextWindow(meta *ModelMetadata, tokens int) int {
+	if meta == nil {
+		return 0
+	}
+	return meta.ContextWindowFor(tokens)
+}
+
+// outputHeadroom returns the tokens the model can still generate after a
+// prompt of that size. Returns 0 for unregistered models and models without
+// a context window.
+func outputHeadroom(meta *ModelMetadata, tokens int) int {
+	if meta == nil {
+		return 0
+	}
+	headroom, _ := meta.OutputHeadroom(tokens)
+	return headroom
+}
@@ -0,0 +1,63 @@
+package tokenizer
+
+import (
+	"context"
+	"math"
+
+	"github.com/lancekrogers/go-token-counter/tokenizer/fileops"
+)
+
+// AudioInputTokens estimates the audio input tokens a model bills for a clip
+// of the given length. Returns 0 for models without audio input.
+func AudioInputTokens(meta *ModelMetadata, seconds float64) int {
+	if meta == nil || meta.AudioTokensPerSecond == 0 {
+		return 0
+	}
+	return int(math.Ceil(seconds * meta.AudioTokensPerSecond))
+}
+
+// EstimateAudio reads an audio file's duration from its container headers and
+// estimates its input tokens and cost for every audio-capable model allowed by
+// the counter's provider filter.
+func (c *Counter) EstimateAudio(path string) ([]MediaEstimate, error) {
+	info, err := fileops.ReadAudioInfo(path)
+	if err != nil {
+		return nil, err
+	}
+	seconds := info.Duration.Seconds()
+
+	estimates := []MediaEstimate{}
+	for _, meta := range audioModels(c.registry) {
+		if !c.allowsProvider(meta.Provider) {
+			continue
+		}
+		tokens := AudioInputTokens(&meta, seconds)
+		estimates = append(estimates, MediaEstimate{
+			Path:            path,
+			Kind:            MediaKindAudio,
+			Provider:        meta.Provider,
+			Model:           meta.Name,
+			DurationSeconds: seconds,
+			Tokens:          tokens,
+			Cost:            float64(tokens) * meta.AudioInputPricePer1M / 1_000_000.0,
+		})
+	}
+	return estimates, nil
+}
+
+// EstimateAudioFiles estimates every audio file in paths. Files whose
+// container cannot be parsed are skipped.
+func (c *Counter) EstimateAudioFiles(ctx context.Context, paths []string) ([]MediaEstimate, error) {
+	media := []MediaEstimate{}
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		estimates, err := c.EstimateAudio(path)
+		if err != nil {
+			continue
+		}
+		media = append(media, estimates...)
+	}
+	return media, nil
+}
@@ -0,0 +1,245 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// BatchDiscount is the fraction of the standard input price charged for
+// requests submitted through the OpenAI Batch API, used for models without
+// a batch service tier in the registry.
+const BatchDiscount = 0.5
+
+// Batch API endpoints with countable request bodies.
+const (
+	batchChatURL       = "/v1/chat/completions"
+	batchResponsesURL  = "/v1/responses"
+	batchEmbeddingsURL = "/v1/embeddings"
+)
+
+// BatchResult summarizes the input tokens of a Batch API input file.
+type BatchResult struct {
+	Requests     int                 `json:"requests"`
+	TotalTokens  int                 `json:"total_tokens"`
+	StandardCost float64             `json:"standard_cost"`
+	BatchCost    float64             `json:"batch_cost"`
+	Models       []BatchModelSummary `json:"models"`
+	OverContext  []BatchIssue        `json:"over_context,omitempty"`
+	Errors       []BatchIssue        `json:"errors,omitempty"`
+}
+
+// BatchModelSummary totals the requests for one model. Costs are input
+// costs; BatchCost applies the model's batch service tier multiplier.
+type BatchModelSummary struct {
+	Model         string  `json:"model"`
+	Encoding      string  `json:"encoding"`
+	IsExact       bool    `json:"is_exact"`
+	Requests      int     `json:"requests"`
+	InputTokens   int     `json:"input_tokens"`
+	MaxTokens     int     `json:"max_tokens"`
+	ContextWindow int     `json:"context_window,omitempty"`
+	OverContext   int     `json:"over_context,omitempty"`
+	StandardCost  float64 `json:"standard_cost"`
+	BatchCost     float64 `json:"batch_cost"`
+}
+
+// BatchIssue identifies a request that exceeds its context window or could
+// not be counted. Line is 1-based.
+type BatchIssue struct {
+	Line          int    `json:"line"`
+	CustomID      string `json:"custom_id,omitempty"`
+	Model         string `json:"model,omitempty"`
+	Tokens        int    `json:"tokens,omitempty"`
+	ContextWindow int    `json:"context_window,omitempty"`
+	Message       string `json:"message,omitempty"`
+}
+
+// batchLine is one request of a Batch API input file.
+type batchLine struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// CountBatch counts the input tokens of every request in an OpenAI Batch API
+// JSONL file. Chat completions and Responses bodies are counted like
+// CountChatRequest and embeddings bodies by their input strings, each with
+// the encoding of the body's model. Requests are summarized under their
+// model's registry name, so snapshots and aliases share a row, and each
+// embeddings input is checked against the context window on its own. Lines
+// that cannot be counted are reported in Errors rather than failing the
+// whole file.
+func (c *Counter) CountBatch(ctx context.Context, r io.Reader) (*BatchResult, error) {
+	result := &BatchResult{Models: []BatchModelSummary{}}
+	summaries := make(map[string]*BatchModelSummary)
+
+	br := bufio.NewReader(r)
+	for lineNum := 1; ; lineNum++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		line, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("reading batch line %d: %w", lineNum, err)
+		}
+		done := errors.Is(err, io.EOF)
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			c.countBatchLine(ctx, lineNum, line, result, summaries)
+		}
+		if done {
+			break
+		}
+	}
+
+	for _, s := range summaries {
+		result.Models = append(result.Models, *s)
+	}
+	sort.Slice(result.Models, func(i, j int) bool { return result.Models[i].Model < result.Models[j].Model })
+	return result, nil
+}
+
+// countBatchLine counts one request and adds it to its model's summary.
+func (c *Counter) countBatchLine(ctx context.Context, lineNum int, line []byte, result *BatchResult, summaries map[string]*BatchModelSummary) {
+	var req batchLine
+	if err := json.Unmarshal(line, &req); err != nil {
+		result.Errors = append(result.Errors, BatchIssue{Line: lineNum, Message: fmt.Sprintf("invalid JSON: %v", err)})
+		return
+	}
+	issue := BatchIssue{Line: lineNum, CustomID: req.CustomID}
+
+	var head struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(req.Body, &head); err != nil || head.Model == "" {
+		issue.Message = "request body has no model"
+		result.Errors = append(result.Errors, issue)
+		return
+	}
+	issue.Model = head.Model
+
+	meta := c.registry.get(head.Model)
+	model := head.Model
+	if meta != nil {
+		model = meta.Name
+	}
+
+	// contextTokens is what the context window limits: the whole request,
+	// or for embeddings the largest single input.
+	var tokens, contextTokens int
+	var err error
+	switch req.URL {
+	case batchEmbeddingsURL:
+		tokens, contextTokens, err = c.countEmbeddingInput(req.Body, model)
+	case batchChatURL, batchResponsesURL, "":
+		tokens, err = c.countBatchChat(ctx, req.Body, model)
+		contextTokens = tokens
+	default:
+		err = fmt.Errorf("unsupported endpoint %q", req.URL)
+	}
+	if err != nil {
+		issue.Message = err.Error()
+		result.Errors = append(result.Errors, issue)
+		return
+	}
+
+	tok, encoding, _ := c.tokenizerForModel(model)
+	summary, ok := summaries[model]
+	if !ok {
+		summary = &BatchModelSummary{Model: model, Encoding: encoding, IsExact: tok.IsExact()}
+		if meta != nil {
+			summary.ContextWindow = meta.ContextWindow
+		}
+		summaries[model] = summary
+	}
+
+	cost := inputCost(meta, tokens)
+	discount := BatchDiscount
+	if meta != nil {
+		if multiplier, ok := meta.ServiceTierMultiplier(ServiceTierBatch); ok {
+			discount = multiplier
+		}
+	}
+	summary.Requests++
+	summary.InputTokens += tokens
+	summary.MaxTokens = max(summary.MaxTokens, tokens)
+	summary.StandardCost += cost
+	summary.BatchCost += cost * discount
+
+	result.Requests++
+	result.TotalTokens += tokens
+	result.StandardCost += cost
+	result.BatchCost += cost * discount
+
+	if window := contextWindow(meta, contextTokens); window > 0 && contextTokens > window {
+		summary.OverContext++
+		issue.Tokens = contextTokens
+		issue.ContextWindow = window
+		result.OverContext = append(result.OverContext, issue)
+	}
+}
+
+// countBatchChat counts a chat completions or Responses request body.
+func (c *Counter) countBatchChat(ctx context.Context, body []byte, model string) (int, error) {
+	chat, err := ParseChatRequest(body)
+	if err != nil {
+		return 0, err
+	}
+	counted, err := c.CountChatRequest(ctx, chat, model)
+	if err != nil {
+		return 0, err
+	}
+	return counted.TotalTokens, nil
+}
+
+// countEmbeddingInput counts an embeddings body whose input is a string or
+// an array of strings, returning the total and the largest single input,
+// which is what the model's context window limits. Pre-tokenized inputs
+// (arrays of token IDs) are counted by their length.
+func (c *Counter) countEmbeddingInput(body []byte, model string) (total, largest int, err error) {
+	var req struct {
+		Input json.RawMessage `json:"input"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return 0, 0, fmt.Errorf("parsing embeddings request: %w", err)
+	}
+
+	var inputs []string
+	var single string
+	var tokenIDs []int
+	var tokenBatches [][]int
+	switch {
+	case json.Unmarshal(req.Input, &single) == nil:
+		inputs = []string{single}
+	case json.Unmarshal(req.Input, &inputs) == nil:
+	case json.Unmarshal(req.Input, &tokenIDs) == nil:
+		return len(tokenIDs), len(tokenIDs), nil
+	case json.Unmarshal(req.Input, &tokenBatches) == nil:
+		for _, ids := range tokenBatches {
+			total += len(ids)
+			largest = max(largest, len(ids))
+		}
+		return total, largest, nil
+	default:
+		return 0, 0, fmt.Errorf("embeddings input must be a string or array")
+	}
+
+	tok, _, _ := c.tokenizerForModel(model)
+	for _, input := range inputs {
+		n, err := tok.CountTokens(input)
+		if err != nil {
+			return 0, 0, err
+		}
+		total += n
+		largest = max(largest, n)
+	}
+	return total, largest, nil
+}
@@ -0,0 +1,113 @@
+package tokenizer
+
+// Budget limits checked by CheckBudget. Zero disables a limit.
+type Budget struct {
+	MaxTokens         int     // input tokens
+	MaxCost           float64 // total request cost in USD
+	MaxContextPercent float64 // input and output tokens as a percentage of the context window
+
+	// Model restricts the check to one model. When empty, every model in
+	// the result's cost estimates is checked.
+	Model string
+
+	// Registry supplies context windows; nil uses DefaultRegistry.
+	Registry *Registry
+}
+
+// Budget limit names reported in BudgetViolation.Limit.
+const (
+	BudgetLimitTokens  = "tokens"
+	BudgetLimitCost    = "cost"
+	BudgetLimitContext = "context_percent"
+)
+
+// BudgetResult is the outcome of checking a count against a budget.
+type BudgetResult struct {
+	MaxTokens         int               `json:"max_tokens,omitempty"`
+	MaxCost           float64           `json:"max_cost,omitempty"`
+	MaxContextPercent float64           `json:"max_context_percent,omitempty"`
+	Exceeded          bool              `json:"exceeded"`
+	Violations        []BudgetViolation `json:"violations"`
+
+	// Unchecked lists the limits that could not be checked, such as a cost
+	// limit for a model without pricing.
+	Unchecked []string `json:"unchecked,omitempty"`
+}
+
+// BudgetViolation is one model over one limit. Value is the token count,
+// cost or context percentage that exceeded Max.
+type BudgetViolation struct {
+	Model string  `json:"model,omitempty"`
+	Limit string  `json:"limit"`
+	Value float64 `json:"value"`
+	Max   float64 `json:"max"`
+}
+
+// CheckBudget checks the models of a count result against budget. Each
+// model is checked at its cost estimate's token count and total cost. When
+// no cost estimate matches, the result's best token count is checked and
+// the cost limit is reported in Unchecked. Context usage counts the input
+// and output tokens against the model's context window; models without one
+// are skipped, and the limit is reported in Unchecked when no model has one.
+func CheckBudget(result *CountResult, budget Budget) *BudgetResult {
+	registry := budget.Registry
+	if registry == nil {
+		registry = DefaultRegistry()
+	}
+
+	check := &BudgetResult{
+		MaxTokens:         budget.MaxTokens,
+		MaxCost:           budget.MaxCost,
+		MaxContextPercent: budget.MaxContextPercent,
+		Violations:        []BudgetViolation{},
+	}
+
+	model := budget.Model
+	if name, ok := registry.Resolve(model); ok {
+		model = name
+	}
+
+	var targets []CostEstimate
+	for _, cost := range result.Costs {
+		if model == "" || cost.Model == model {
+			targets = append(targets, cost)
+		}
+	}
+	priced := len(targets) > 0
+	if !priced {
+		targets = []CostEstimate{{Model: model, Tokens: getTokenCount(result.Methods)}}
+		if budget.MaxCost > 0 {
+			check.Unchecked = append(check.Unchecked, BudgetLimitCost)
+		}
+	}
+
+	contextChecked := false
+	for _, t := range targets {
+		if budget.MaxTokens > 0 && t.Tokens > budget.MaxTokens {
+			check.add(t.Model, BudgetLimitTokens, float64(t.Tokens), float64(budget.MaxTokens))
+		}
+		if budget.MaxCost > 0 && priced && t.TotalCost > budget.MaxCost {
+			check.add(t.Model, BudgetLimitCost, t.TotalCost, budget.MaxCost)
+		}
+		if budget.MaxContextPercent > 0 {
+			if meta := registry.get(t.Model); meta != nil && meta.ContextWindow > 0 {
+				contextChecked = true
+				usage := float64(t.Tokens+t.OutputTokens) / float64(meta.ContextWindowFor(t.Tokens+t.OutputTokens)) * 100
+				if usage > budget.MaxContextPercent {
+					check.add(t.Model, BudgetLimitContext, usage, budget.MaxContextPercent)
+				}
+			}
+		}
+	}
+	if budget.MaxContextPercent > 0 && !contextChecked {
+		check.Unchecked = append(check.Unchecked, BudgetLimitContext)
+	}
+
+	return check
+}
+
+// add records a violation.
+func (b *BudgetResult) add(model, limit string, value, maxValue float64) {
+	b.Exceeded = true
+	b.Violations = append(b.Violations, BudgetViolation{Model: model, Limit: limit, Value: value, Max: maxValue})
+}
@@ -0,0 +1,438 @@
+package tokenizer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ChatMessage is a single message from an OpenAI-style chat request.
+// Content is normalized to plain text: string content is used as-is and
+// text parts of array content are concatenated. Image parts are collected
+// into Images.
+type ChatMessage struct {
+	Role       string      `json:"role"`
+	Name       string      `json:"name,omitempty"`
+	Content    string      `json:"content"`
+	Images     []ChatImage `json:"images,omitempty"`
+	ToolCalls  []ToolCall  `json:"tool_calls,omitempty"`
+	ToolCallID string      `json:"tool_call_id,omitempty"`
+}
+
+// ChatImage is an image content part. URL may be a remote URL or a base64
+// "data:" URL; only the latter has dimensions that can be read offline.
+type ChatImage struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ToolCall is a function call requested by an assistant message.
+type ToolCall struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ChatRequest is a parsed chat completion or Responses API request body.
+type ChatRequest struct {
+	Model      string           `json:"model,omitempty"`
+	Messages   []ChatMessage    `json:"messages"`
+	Tools      []ToolDefinition `json:"tools,omitempty"`
+	ToolChoice string           `json:"tool_choice,omitempty"`
+}
+
+// chatOverhead describes the framing tokens an encoding adds around messages.
+type chatOverhead struct {
+	perMessage   int // <|start|>{role}<|message|>...<|end|> framing
+	perName      int // extra token when a message carries a name
+	replyPriming int // <|start|>assistant<|message|> appended to every request
+}
+
+// chatOverheads maps encodings whose chat framing differs from
+// defaultChatOverhead to their costs. The current encodings all use the
+// default.
+var chatOverheads = map[string]chatOverhead{}
+
+// defaultChatOverhead follows OpenAI's published num_tokens_from_messages
+// reference, which o200k_base and cl100k_base share. It also applies to
+// encodings without a published chat format.
+var defaultChatOverhead = chatOverhead{perMessage: 3, perName: 1, replyPriming: 3}
+
+// ParseChatRequest parses a chat request body. It accepts a chat completions
+// body with a "messages" array, a Responses API body with "input" (string or
+// items) and optional "instructions", or a bare JSON array of messages.
+// Tool definitions are read from "tools" (OpenAI or Anthropic shape) and the
+// legacy "functions" field.
+func ParseChatRequest(data []byte) (*ChatRequest, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var messages []ChatMessage
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("parsing messages array: %w", err)
+		}
+		return &ChatRequest{Messages: messages}, nil
+	}
+
+	var raw struct {
+		Model        string            `json:"model"`
+		Messages     []ChatMessage     `json:"messages"`
+		Input        json.RawMessage   `json:"input"`
+		Instructions string            `json:"instructions"`
+		Tools        []json.RawMessage `json:"tools"`
+		Functions    []json.RawMessage `json:"functions"`
+		ToolChoice   json.RawMessage   `json:"tool_choice"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing chat request: %w", err)
+	}
+
+	tools, err := parseToolDefinitions(append(raw.Tools, raw.Functions...))
+	if err != nil {
+		return nil, err
+	}
+
+	req := &ChatRequest{
+		Model:      raw.Model,
+		Messages:   raw.Messages,
+		Tools:      tools,
+		ToolChoice: parseToolChoice(raw.ToolChoice),
+	}
+	if len(raw.Input) > 0 {
+		if raw.Instructions != "" {
+			req.Messages = append(req.Messages, ChatMessage{Role: "system", Content: raw.Instructions})
+		}
+		items, err := parseResponsesInput(raw.Input)
+		if err != nil {
+			return nil, err
+		}
+		req.Messages = append(req.Messages, items...)
+	}
+
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("chat request has no messages or input")
+	}
+	return req, nil
+}
+
+// UnmarshalJSON decodes a message whose content may be a string, null, or an
+// array of content parts.
+func (m *ChatMessage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role       string          `json:"role"`
+		Name       string          `json:"name"`
+		Content    json.RawMessage `json:"content"`
+		ToolCallID string          `json:"tool_call_id"`
+		ToolCalls  []struct {
+			ID       string `json:"id"`
+			Function struct {
+				Name      string `json:"name"`
+				Arguments string `json:"arguments"`
+			} `json:"function"`
+		} `json:"tool_calls"`
+		FunctionCall *struct {
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"`
+		} `json:"function_call"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	content, images, err := parseContent(raw.Content)
+	if err != nil {
+		return err
+	}
+
+	*m = ChatMessage{
+		Role:       raw.Role,
+		Name:       raw.Name,
+		Content:    content,
+		Images:     images,
+		ToolCallID: raw.ToolCallID,
+	}
+	for _, tc := range raw.ToolCalls {
+		m.ToolCalls = append(m.ToolCalls, ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+	}
+	if raw.FunctionCall != nil {
+		m.ToolCalls = append(m.ToolCalls, ToolCall{Name: raw.FunctionCall.Name, Arguments: raw.FunctionCall.Arguments})
+	}
+	return nil
+}
+
+// contentPart is a single element of array-valued message content.
+// ImageURL is an object ({"url", "detail"}) in chat completions and a plain
+// string in the Responses API.
+type contentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text"`
+	Refusal  string          `json:"refusal"`
+	ImageURL json.RawMessage `json:"image_url"`
+	Detail   string          `json:"detail"`
+}
+
+// parseContent flattens string or array content into plain text and images.
+func parseContent(raw json.RawMessage) (string, []ChatImage, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return "", nil, nil
+	}
+
+	if raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return "", nil, fmt.Errorf("parsing message content: %w", err)
+		}
+		return s, nil, nil
+	}
+
+	var parts []contentPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", nil, fmt.Errorf("parsing message content parts: %w", err)
+	}
+
+	var b strings.Builder
+	var images []ChatImage
+	for _, p := range parts {
+		switch p.Type {
+		case "text", "input_text", "output_text":
+			b.WriteString(p.Text)
+		case "refusal":
+			b.WriteString(p.Refusal)
+		case "image_url", "input_image":
+			img := ChatImage{Detail: p.Detail}
+			if json.Unmarshal(p.ImageURL, &img.URL) != nil {
+				_ = json.Unmarshal(p.ImageURL, &img)
+			}
+			images = append(images, img)
+		}
+	}
+	return b.String(), images, nil
+}
+
+// parseResponsesInput converts a Responses API "input" value into messages.
+func parseResponsesInput(raw json.RawMessage) ([]ChatMessage, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("parsing input: %w", err)
+		}
+		return []ChatMessage{{Role: "user", Content: s}}, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("parsing input items: %w", err)
+	}
+
+	messages := make([]ChatMessage, 0, len(items))
+	for i, item := range items {
+		var head struct {
+			Type      string `json:"type"`
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"`
+			CallID    string `json:"call_id"`
+			Output    string `json:"output"`
+		}
+		if err := json.Unmarshal(item, &head); err != nil {
+			return nil, fmt.Errorf("parsing input item %d: %w", i, err)
+		}
+
+		switch head.Type {
+		case "", "message":
+			var msg ChatMessage
+			if err := json.Unmarshal(item, &msg); err != nil {
+				return nil, fmt.Errorf("parsing input item %d: %w", i, err)
+			}
+			messages = append(messages, msg)
+		case "function_call":
+			messages = append(messages, ChatMessage{
+				Role:      "assistant",
+				ToolCalls: []ToolCall{{ID: head.CallID, Name: head.Name, Arguments: head.Arguments}},
+			})
+		case "function_call_output":
+			messages = append(messages, ChatMessage{Role: "tool", Content: head.Output, ToolCallID: head.CallID})
+		}
+	}
+	return messages, nil
+}
+
+// CountMessages counts the prompt tokens of a chat request for the given model,
+// including the per-message framing and reply-priming overhead of its encoding.
+// Unknown models fall back to the encoding inferred from the model name.
+func (c *Counter) CountMessages(ctx context.Context, messages []ChatMessage, model string) (*ChatCountResult, error) {
+	return c.CountChatRequest(ctx, &ChatRequest{Messages: messages}, model)
+}
+
+// CountChatRequest counts the prompt tokens of a full chat request, including
+// tool definitions. Tools are serialized the way the model's provider renders
+// them into the prompt: OpenAI's TypeScript namespace or Anthropic's JSON
+// schemas plus tool-use system prompt. Open-weight models with a chat
+// template are counted by rendering the messages through it. The result
+// breaks the total down into system messages, other messages, and tools.
+func (c *Counter) CountChatRequest(ctx context.Context, req *ChatRequest, model string) (*ChatCountResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if tmpl := c.chatTemplateFor(model); tmpl != nil {
+		return c.countTemplatedChat(ctx, req, model, tmpl)
+	}
+	messages := req.Messages
+
+	tok, encoding, meta := c.tokenizerForModel(model)
+
+	overhead, ok := chatOverheads[encoding]
+	if !ok {
+		overhead = defaultChatOverhead
+	}
+
+	result := &ChatCountResult{
+		Model:        model,
+		Encoding:     encoding,
+		IsExact:      tok.IsExact(),
+		Messages:     make([]MessageCount, 0, len(messages)),
+		ReplyPriming: overhead.replyPriming,
+	}
+	total := overhead.replyPriming
+	for i, msg := range messages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		content, err := countMessageContent(tok, msg)
+		if err != nil {
+			return nil, fmt.Errorf("counting message %d: %w", i, err)
+		}
+		images := result.countChatImages(msg.Images)
+
+		framing := overhead.perMessage
+		roleTokens, err := tok.CountTokens(msg.Role)
+		if err != nil {
+			return nil, fmt.Errorf("counting message %d role: %w", i, err)
+		}
+		framing += roleTokens
+		if msg.Name != "" {
+			nameTokens, err := tok.CountTokens(msg.Name)
+			if err != nil {
+				return nil, fmt.Errorf("counting message %d name: %w", i, err)
+			}
+			framing += nameTokens + overhead.perName
+		}
+
+		result.Messages = append(result.Messages, MessageCount{
+			Index:          i,
+			Role:           msg.Role,
+			ContentTokens:  content + images,
+			OverheadTokens: framing,
+			Tokens:         content + images + framing,
+		})
+		if isSystemRole(msg.Role) {
+			result.Breakdown.System += content + framing
+		} else {
+			result.Breakdown.Messages += content + framing
+		}
+		result.Breakdown.Images += images
+		total += content + images + framing
+	}
+
+	toolTokens, err := countTools(tok, req, model, meta)
+	if err != nil {
+		return nil, fmt.Errorf("counting tools: %w", err)
+	}
+	result.Breakdown.Tools = toolTokens
+	total += toolTokens
+
+	result.TotalTokens = total
+	result.InputCost = inputCost(meta, total)
+	result.ContextWindow = contextWindow(meta, total)
+	result.OutputHeadroom = outputHeadroom(meta, total)
+	return result, nil
+}
+
+// countTools counts tool definitions using the serialization of the model's
+// provider. Unregistered models are treated as OpenAI-compatible.
+func countTools(tok Tokenizer, req *ChatRequest, model string, meta *ModelMetadata) (int, error) {
+	if meta != nil && meta.Provider == ProviderAnthropic {
+		return countAnthropicTools(tok, req.Tools, model, req.ToolChoice)
+	}
+
+	hasSystem := false
+	for _, msg := range req.Messages {
+		if isSystemRole(msg.Role) {
+			hasSystem = true
+			break
+		}
+	}
+	return countOpenAITools(tok, req.Tools, hasSystem)
+}
+
+// isSystemRole reports whether a role carries system instructions.
+func isSystemRole(role string) bool {
+	return role == "system" || role == "developer"
+}
+
+// countChatImages estimates OpenAI image parts. Base64 data URLs are sized
+// from their headers; remote images are assumed to be at the maximum size.
+func (r *ChatCountResult) countChatImages(images []ChatImage) int {
+	total := 0
+	for _, img := range images {
+		info, err := decodeDataURLImage(img.URL)
+		if err != nil {
+			if img.Detail != ImageDetailLow {
+				r.addNote("image size unknown, assumed maximum of %d tokens", openAIMaxImageTokens)
+			}
+			total += OpenAIImageTokens(0, 0, img.Detail)
+			continue
+		}
+		total += OpenAIImageTokens(info.Width, info.Height, img.Detail)
+	}
+	return total
+}
+
+// addNote records a distinct estimation caveat on the result.
+func (r *ChatCountResult) addNote(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	for _, existing := range r.Notes {
+		if existing == msg {
+			return
+		}
+	}
+	r.Notes = append(r.Notes, msg)
+}
+
+// countMessageContent counts the text content and tool calls of a message.
+func countMessageContent(tok Tokenizer, msg ChatMessage) (int, error) {
+	total, err := tok.CountTokens(msg.Content)
+	if err != nil {
+		return 0, err
+	}
+	for _, call := range msg.ToolCalls {
+		n, err := tok.CountTokens(call.Name + call.Arguments)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// tokenizerForModel resolves the tokenizer, encoding name, and registry
+// metadata for a model. Unregistered models use the encoding inferred from
+// their name; metadata is nil in that case.
+func (c *Counter) tokenizerForModel(model string) (Tokenizer, string, *ModelMetadata) {
+	meta := c.registry.get(model)
+
+	encoding := ""
+	if meta != nil {
+		encoding = meta.Encoding
+	} else {
+		encoding, _ = getEncodingForModel(model)
+	}
+
+	if tok, ok := c.tokenizers[encoding]; ok {
+		return tok, encoding, meta
+	}
+	return c.tokenizers["o200k_base"], "o200k_base", meta
+}
@@ -1,7 +1,10 @@
 package tokenizer
 
 import (
+	"math"
+	"sort"
 	"strings"
+	"time"
 )
 
 // mainModels is the ordered set of models shown in default cost output.
@@ -15,33 +18,177 @@ var mainModels = []string{
 // characterBasedMethodPrefix identifies character-based approximation methods.
 const characterBasedMethodPrefix = "character_based"
 
-// CalculateCosts calculates cost estimates based on token counts.
+// CalculateCosts calculates input cost estimates based on token counts.
 // Pricing is sourced from the model registry (single source of truth).
 // Only main models are included in the output.
 func CalculateCosts(methods []MethodResult) []CostEstimate {
+	return CalculateCostsWithOptions(methods, CostOptions{})
+}
+
+// CalculateCostsWithOptions calculates input, output and total cost
+// estimates for a request whose input has the given token counts. The
+// models priced, output token count and cached prefix are taken from opts;
+// models without pricing are skipped and models without prompt-cache
+// pricing are priced uncached. Each model is priced at the count of its own
+// encoding when one is available. Models with long-context tiers are priced
+// at the tier for their input token count. A non-standard service tier in
+// opts is priced alongside the standard estimate. A non-zero opts.AsOf
+// prices every model at the prices in force on that day (see
+// ModelMetadata.AsOf).
+func CalculateCostsWithOptions(methods []MethodResult, opts CostOptions) []CostEstimate {
 	costs := []CostEstimate{}
 
-	tokenCount := getTokenCount(methods)
-	if tokenCount == 0 {
+	if getTokenCount(methods) == 0 {
 		return costs
 	}
 
-	for _, modelName := range mainModels {
-		meta := GetModelMetadata(modelName)
-		if meta == nil || meta.InputPricePer1M == 0 {
+	registry := opts.Registry
+	if registry == nil {
+		registry = DefaultRegistry()
+	}
+
+	for _, modelName := range costModels(registry, opts) {
+		meta := registry.get(modelName)
+		if meta == nil {
+			continue
+		}
+		if !opts.AsOf.IsZero() {
+			historical := meta.AsOf(opts.AsOf)
+			meta = &historical
+		}
+		if meta.InputPricePer1M == 0 {
 			continue
 		}
-		costs = append(costs, CostEstimate{
-			Model:     modelName,
-			Tokens:    tokenCount,
-			RatePer1M: meta.InputPricePer1M,
-			Cost:      float64(tokenCount) * meta.InputPricePer1M / 1_000_000.0,
+		tokenCount, source := tokenCountForModel(methods, meta)
+
+		outputTokens := opts.OutputTokens
+		if outputTokens == 0 && opts.OutputRatio > 0 {
+			outputTokens = int(math.Round(float64(tokenCount) * opts.OutputRatio))
+		}
+
+		cachedTokens := opts.CachedTokens
+		if cachedTokens == 0 && opts.CacheHitRatio > 0 {
+			cachedTokens = int(math.Round(float64(tokenCount) * opts.CacheHitRatio))
+		}
+		cachedTokens = min(cachedTokens, tokenCount)
+
+		prices := meta.PricesFor(tokenCount)
+		estimate := CostEstimate{
+			Model:           meta.Name,
+			Tokens:          tokenCount,
+			TokenSource:     source,
+			RatePer1M:       prices.InputPricePer1M,
+			Cost:            float64(tokenCount) * prices.InputPricePer1M / 1_000_000.0,
+			OutputTokens:    outputTokens,
+			OutputRatePer1M: prices.OutputPricePer1M,
+			OutputCost:      float64(outputTokens) * prices.OutputPricePer1M / 1_000_000.0,
+			TierAbove:       prices.AboveTokens,
+		}
+		if !opts.AsOf.IsZero() {
+			estimate.PricesAsOf = opts.AsOf.Format(time.DateOnly)
+		}
+		estimate.TotalCost = estimate.Cost + estimate.OutputCost
+		if cachedTokens > 0 && prices.CacheReadPricePer1M > 0 {
+			estimate.applyCache(prices, cachedTokens)
+		}
+		if opts.ServiceTier != "" && opts.ServiceTier != ServiceTierStandard {
+			if multiplier, ok := meta.ServiceTierMultiplier(opts.ServiceTier); ok {
+				estimate.ServiceTier = string(opts.ServiceTier)
+				estimate.ServiceTierCost = estimate.TotalCost * multiplier
+			}
+		}
+		costs = append(costs, estimate)
+	}
+
+	if len(opts.Models) == 0 && opts.AllModels {
+		sort.SliceStable(costs, func(i, j int) bool {
+			if costs[i].TotalCost != costs[j].TotalCost {
+				return costs[i].TotalCost < costs[j].TotalCost
+			}
+			return costs[i].Model < costs[j].Model
 		})
 	}
 
 	return costs
 }
 
+// costModels returns the names of the models in r to price for opts. The default
+// set is the selected model followed by the main models of the provider, or
+// every priced model of the provider when it has no main models.
+func costModels(r *Registry, opts CostOptions) []string {
+	if len(opts.Models) > 0 {
+		return opts.Models
+	}
+
+	matches := func(meta *ModelMetadata) bool {
+		return meta != nil && meta.InputPricePer1M > 0 &&
+			(opts.Provider == "" || opts.Provider == "all" || meta.Provider == opts.Provider)
+	}
+
+	models := []string{}
+	if opts.AllModels {
+		for _, meta := range r.Models() {
+			if matches(&meta) {
+				models = append(models, meta.Name)
+			}
+		}
+		return models
+	}
+
+	selected := ""
+	if meta := r.get(opts.Model); matches(meta) {
+		selected = meta.Name
+		models = append(models, selected)
+	}
+	for _, name := range mainModels {
+		if name != selected && matches(r.get(name)) {
+			models = append(models, name)
+		}
+	}
+	if len(models) == 0 && opts.Provider != "" && opts.Provider != "all" {
+		for _, meta := range r.Models() {
+			if meta.Provider == opts.Provider && matches(&meta) {
+				models = append(models, meta.Name)
+			}
+		}
+	}
+	return models
+}
+
+// tokenCountForModel picks the token count to price a model at and reports
+// where it came from: the model's own encoding, an exact count from another
+// encoding, or an approximation.
+func tokenCountForModel(methods []MethodResult, meta *ModelMetadata) (int, string) {
+	for _, method := range methods {
+		if method.Encoding != "" && method.Encoding == meta.Encoding {
+			if method.IsExact {
+				return method.Tokens, TokenSourceExact
+			}
+			return method.Tokens, TokenSourceEstimated
+		}
+	}
+
+	for _, method := range methods {
+		if method.IsExact {
+			return method.Tokens, TokenSourceProxy
+		}
+	}
+
+	return getTokenCount(methods), TokenSourceEstimated
+}
+
+// applyCache prices the first cachedTokens of the input from the prompt
+// cache, with the total at warm-cache (cache read) prices.
+func (e *CostEstimate) applyCache(prices PriceTier, cachedTokens int) {
+	e.CachedTokens = cachedTokens
+	e.UncachedCost = float64(e.Tokens-cachedTokens) * prices.InputPricePer1M / 1_000_000.0
+	e.CacheWriteCost = float64(cachedTokens) * prices.CacheWritePricePer1M / 1_000_000.0
+	e.CacheReadCost = float64(cachedTokens) * prices.CacheReadPricePer1M / 1_000_000.0
+	e.CacheSavings = e.Cost - e.UncachedCost - e.CacheReadCost
+	e.TotalCost = e.UncachedCost + e.CacheReadCost + e.OutputCost
+	e.WarmCache = true
+}
+
 // getTokenCount finds the best token count to use for cost calculation.
 // Prefers exact BPE counts, then falls back to approximations.
 func getTokenCount(methods []MethodResult) int {
@@ -65,22 +212,10 @@ func getTokenCount(methods []MethodResult) int {
 }
 
 // GetPricingForModel returns pricing information for a specific model.
-// Pricing is sourced from the model registry.
+// Pricing is sourced from the model registry. Names are matched like
+// GetModelMetadata after lower-casing, so dated snapshots and
+// provider-prefixed names find their model, but a prefix such as "gpt-4"
+// never matches "gpt-4o".
 func GetPricingForModel(model string) *ModelMetadata {
-	model = strings.ToLower(model)
-
-	// Exact match
-	if meta := GetModelMetadata(model); meta != nil {
-		return meta
-	}
-
-	// Fuzzy match: check all models for substring containment
-	for name, meta := range modelRegistry {
-		if strings.Contains(strings.ToLower(name), model) ||
-			strings.Contains(model, strings.ToLower(name)) {
-			return &meta
-		}
-	}
-
-	return nil
+	return GetModelMetadata(strings.ToLower(model))
 }
@@ -13,11 +13,20 @@ import (
 
 // Counter handles token counting.
 type Counter struct {
-	charsPerToken float64
-	wordsPerToken float64
-	vocabFile     string
-	provider      Provider
-	tokenizers    map[string]Tokenizer
+	charsPerToken   float64
+	wordsPerToken   float64
+	vocabFile       string
+	provider        Provider
+	includeImages   bool
+	includeAudio    bool
+	imageDetail     string
+	pdfPageOverhead bool
+	noExtract       bool
+	chatTemplate    *ChatTemplate
+	noChatTemplate  bool
+	strictModels    bool
+	registry        *Registry
+	tokenizers      map[string]Tokenizer
 }
 
 // NewCounter creates a new token counter.
@@ -29,13 +38,36 @@ func NewCounter(opts CounterOptions) (*Counter, error) {
 	if opts.WordsPerToken == 0 {
 		opts.WordsPerToken = 0.75
 	}
+	if opts.ImageDetail == "" {
+		opts.ImageDetail = ImageDetailAuto
+	}
 
 	c := &Counter{
-		charsPerToken: opts.CharsPerToken,
-		wordsPerToken: opts.WordsPerToken,
-		vocabFile:     opts.VocabFile,
-		provider:      opts.Provider,
-		tokenizers:    make(map[string]Tokenizer),
+		charsPerToken:   opts.CharsPerToken,
+		wordsPerToken:   opts.WordsPerToken,
+		vocabFile:       opts.VocabFile,
+		provider:        opts.Provider,
+		includeImages:   opts.IncludeImages,
+		includeAudio:    opts.IncludeAudio,
+		imageDetail:     opts.ImageDetail,
+		pdfPageOverhead: opts.PDFPageOverhead,
+		noExtract:       opts.NoExtract,
+		chatTemplate:    opts.ChatTemplate,
+		noChatTemplate:  opts.NoChatTemplate,
+		strictModels:    opts.StrictModels,
+		registry:        opts.Registry,
+		tokenizers:      make(map[string]Tokenizer),
+	}
+	if c.registry == nil {
+		c.registry = DefaultRegistry()
+	}
+	if opts.RegistryOverrides != nil {
+		// Overrides stay private to the counter; the registry it was given,
+		// often the default one, is left unchanged.
+		c.registry = c.registry.copy()
+		if err := c.registry.ApplyOverrides(opts.RegistryOverrides); err != nil {
+			return nil, fmt.Errorf("applying registry overrides: %w", err)
+		}
 	}
 
 	if err := c.initializeTokenizers(); err != nil {
@@ -45,7 +77,16 @@ func NewCounter(opts CounterOptions) (*Counter, error) {
 	return c, nil
 }
 
-// Count performs token counting using specified methods.
+// Registry returns the registry the counter reads model metadata from,
+// including its RegistryOverrides. Pass it as CostOptions.Registry to price
+// results with the same overrides.
+func (c *Counter) Registry() *Registry {
+	return c.registry
+}
+
+// Count performs token counting using specified methods. A model that is
+// not in the registry is counted with approximations, or fails with
+// ErrModelNotFound when the counter was created with StrictModels.
 func (c *Counter) Count(ctx context.Context, text string, model string, all bool) (*CountResult, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
@@ -74,11 +115,25 @@ func (c *Counter) Count(ctx context.Context, text string, model string, all bool
 // CountFile counts tokens in a single file.
 // It checks for context cancellation, rejects binary files, reads the file
 // content, and delegates to Count. The result includes FilePath and FileSize.
+// When IncludeImages or IncludeAudio is set, image or audio files are
+// estimated into Media instead of being rejected. PDF, Office, ODF and EPUB
+// documents are counted from their extracted text unless NoExtract is set,
+// and PDFs report their page count.
 func (c *Counter) CountFile(ctx context.Context, path string, model string, all bool) (*CountResult, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
+	if c.includeImages && fileops.IsImageFile(path) {
+		return c.countMediaFile(path, c.EstimateImage)
+	}
+	if c.includeAudio && fileops.IsAudioFile(path) {
+		return c.countMediaFile(path, c.EstimateAudio)
+	}
+	if !c.noExtract && fileops.IsDocumentFile(path) {
+		return c.countDocumentFile(ctx, path, model, all)
+	}
+
 	isBinary, err := fileops.IsBinaryFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("checking if file is binary %q: %w", path, err)
@@ -110,6 +165,8 @@ func (c *Counter) CountFile(ctx context.Context, path string, model string, all
 // CountDirectory counts tokens across all text files in a directory.
 // It walks the directory respecting .gitignore rules and skipping binary files,
 // aggregates all file contents, and counts tokens on the combined text.
+// Documents are extracted unless NoExtract is set. When IncludeImages or
+// IncludeAudio is set, media files are estimated into Media.
 // Context cancellation is checked between each major operation.
 //
 // Note: this operation loads all text file content into memory before counting.
@@ -124,7 +181,19 @@ func (c *Counter) CountDirectory(ctx context.Context, path string, model string,
 		return nil, fmt.Errorf("walking directory %q: %w", path, err)
 	}
 
-	if len(walkResult.Files) == 0 {
+	files := walkResult.Files
+	if !c.noExtract {
+		files = append(files, walkResult.Documents...)
+	}
+
+	mediaCount := 0
+	if c.includeImages {
+		mediaCount += len(walkResult.Images)
+	}
+	if c.includeAudio {
+		mediaCount += len(walkResult.Audio)
+	}
+	if len(files) == 0 && mediaCount == 0 {
 		return nil, fmt.Errorf("no text files found in directory %q", path)
 	}
 
@@ -132,7 +201,7 @@ func (c *Counter) CountDirectory(ctx context.Context, path string, model string,
 		return nil, err
 	}
 
-	content, err := fileops.AggregateFileContents(ctx, walkResult.Files)
+	agg, err := fileops.AggregateFiles(ctx, files)
 	if err != nil {
 		return nil, fmt.Errorf("reading files in %q: %w", path, err)
 	}
@@ -141,19 +210,97 @@ func (c *Counter) CountDirectory(ctx context.Context, path string, model string,
 		return nil, err
 	}
 
-	result, err := c.Count(ctx, string(content), model, all)
+	result, err := c.Count(ctx, string(agg.Content), model, all)
 	if err != nil {
 		return nil, err
 	}
 
 	result.FilePath = path
-	result.FileSize = len(content)
+	result.FileSize = len(agg.Content)
 	result.IsDirectory = true
-	result.FileCount = len(walkResult.Files)
+	result.FileCount = len(files)
+	result.Pages = agg.Pages()
+	result.Media = append(result.Media, c.EstimatePDFPages(agg.PDFs)...)
+
+	if c.includeImages {
+		media, err := c.EstimateImages(ctx, walkResult.Images)
+		if err != nil {
+			return nil, err
+		}
+		result.Media = append(result.Media, media...)
+	}
+	if c.includeAudio {
+		media, err := c.EstimateAudioFiles(ctx, walkResult.Audio)
+		if err != nil {
+			return nil, err
+		}
+		result.Media = append(result.Media, media...)
+	}
+	result.FileCount += mediaCount
+
+	return result, nil
+}
+
+// countDocumentFile counts the extracted text of a document file.
+func (c *Counter) countDocumentFile(ctx context.Context, path string, model string, all bool) (*CountResult, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %q: %w", path, err)
+	}
+	doc, err := fileops.ExtractDocument(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.Count(ctx, doc.Text, model, all)
+	if err != nil {
+		return nil, err
+	}
 
+	result.FilePath = path
+	result.FileSize = int(info.Size())
+	if doc.Format == "pdf" {
+		result.Pages = doc.Pages
+		result.Media = c.EstimatePDFPages([]fileops.PDFPages{{Path: path, Pages: doc.Pages}})
+	}
 	return result, nil
 }
 
+// countMediaFile builds a result for a single media file with no text content.
+func (c *Counter) countMediaFile(path string, estimate func(string) ([]MediaEstimate, error)) (*CountResult, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %q: %w", path, err)
+	}
+	media, err := estimate(path)
+	if err != nil {
+		return nil, fmt.Errorf("estimating media %q: %w", path, err)
+	}
+	return &CountResult{
+		FilePath: path,
+		FileSize: int(info.Size()),
+		Methods:  []MethodResult{},
+		Media:    media,
+	}, nil
+}
+
+// EstimateImages estimates every image in paths. Images whose format cannot
+// be decoded are skipped.
+func (c *Counter) EstimateImages(ctx context.Context, paths []string) ([]MediaEstimate, error) {
+	media := []MediaEstimate{}
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		estimates, err := c.EstimateImage(path)
+		if err != nil {
+			continue
+		}
+		media = append(media, estimates...)
+	}
+	return media, nil
+}
+
 // countAllMethods counts tokens using all available encodings (deduplicated).
 func (c *Counter) countAllMethods(text string) []MethodResult {
 	methods := []MethodResult{}
@@ -169,7 +316,7 @@ func (c *Counter) countAllMethods(text string) []MethodResult {
 		tokenizer := c.tokenizers[encoding]
 
 		if c.provider != "" && c.provider != "all" {
-			if !encodingMatchesProvider(encoding, c.provider) {
+			if !c.encodingMatchesProvider(encoding, c.provider) {
 				continue
 			}
 		}
@@ -183,6 +330,7 @@ func (c *Counter) countAllMethods(text string) []MethodResult {
 			methods = append(methods, MethodResult{
 				Name:        tokenizer.Name(),
 				DisplayName: tokenizer.DisplayName(),
+				Encoding:    encoding,
 				Tokens:      count,
 				IsExact:     tokenizer.IsExact(),
 			})
@@ -194,15 +342,14 @@ func (c *Counter) countAllMethods(text string) []MethodResult {
 	return methods
 }
 
-// encodingMatchesProvider checks if an encoding should be included for a provider filter.
-func encodingMatchesProvider(encoding string, provider Provider) bool {
-	switch encoding {
-	case "o200k_base":
-		return provider == ProviderOpenAI
-	case "cl100k_base":
-		return provider == ProviderOpenAI || provider == ProviderMeta || provider == ProviderDeepSeek || provider == ProviderAlibaba || provider == ProviderMicrosoft
-	case "claude_approx":
-		return provider == ProviderAnthropic
+// encodingMatchesProvider checks if an encoding should be included for a
+// provider filter: whether any registered model of the provider uses it, so
+// custom models and providers from registry overrides are included.
+func (c *Counter) encodingMatchesProvider(encoding string, provider Provider) bool {
+	for _, meta := range c.registry.Models() {
+		if meta.Provider == provider && meta.Encoding == encoding {
+			return true
+		}
 	}
 	return false
 }
@@ -211,7 +358,7 @@ func encodingMatchesProvider(encoding string, provider Provider) bool {
 func (c *Counter) countSpecificModel(text string, model string) ([]MethodResult, error) {
 	methods := []MethodResult{}
 
-	meta := GetModelMetadata(model)
+	meta := c.registry.get(model)
 	if meta != nil {
 		if tokenizer, ok := c.tokenizers[meta.Encoding]; ok {
 			count, err := tokenizer.CountTokens(text)
@@ -219,11 +366,13 @@ func (c *Counter) countSpecificModel(text string, model string) ([]MethodResult,
 				return nil, err
 			}
 			methods = append(methods, MethodResult{
-				Name:          fmt.Sprintf("bpe_%s", strings.ReplaceAll(model, "-", "_")),
-				DisplayName:   fmt.Sprintf("%s (%s)", meta.Encoding, model),
-				Tokens:        count,
-				IsExact:       tokenizer.IsExact(),
-				ContextWindow: meta.ContextWindow,
+				Name:           fmt.Sprintf("bpe_%s", strings.ReplaceAll(meta.Name, "-", "_")),
+				DisplayName:    fmt.Sprintf("%s (%s)", meta.Encoding, meta.Name),
+				Encoding:       meta.Encoding,
+				Tokens:         count,
+				IsExact:        tokenizer.IsExact(),
+				ContextWindow:  meta.ContextWindowFor(count),
+				OutputHeadroom: outputHeadroom(meta, count),
 			})
 			return methods, nil
 		}
@@ -237,16 +386,22 @@ func (c *Counter) countSpecificModel(text string, model string) ([]MethodResult,
 		result := MethodResult{
 			Name:        tokenizer.Name(),
 			DisplayName: tokenizer.DisplayName(),
+			Encoding:    model,
 			Tokens:      count,
 			IsExact:     tokenizer.IsExact(),
 		}
 		if meta != nil {
-			result.ContextWindow = meta.ContextWindow
+			result.ContextWindow = meta.ContextWindowFor(count)
+			result.OutputHeadroom = outputHeadroom(meta, count)
 		}
 		methods = append(methods, result)
 		return methods, nil
 	}
 
+	if meta == nil && c.strictModels {
+		return nil, c.registry.unknownModelError(model)
+	}
+
 	methods = append(methods, c.getApproximations(text)...)
 	return methods, nil
 }
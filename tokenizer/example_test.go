@@ -147,3 +147,88 @@ func ExampleCalculateCosts() {
 	// claude-sonnet-4.6: $0.000012
 	// claude-sonnet-4.5: $0.000012
 }
+
+func ExampleCounter_CountMessages() {
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{})
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	req, err := tokenizer.ParseChatRequest([]byte(`{
+		"model": "gpt-4o",
+		"messages": [
+			{"role": "system", "content": "You are a helpful assistant."},
+			{"role": "user", "content": "Hello, world!"}
+		]
+	}`))
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	ctx := context.Background()
+	result, err := counter.CountMessages(ctx, req.Messages, req.Model)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	for _, m := range result.Messages {
+		fmt.Printf("%s: %d\n", m.Role, m.Tokens)
+	}
+	fmt.Printf("Total: %d\n", result.TotalTokens)
+	// Output:
+	// system: 10
+	// user: 8
+	// Total: 21
+}
+
+func ExampleOpenAIImageTokens() {
+	fmt.Println(tokenizer.OpenAIImageTokens(1024, 1024, tokenizer.ImageDetailHigh))
+	fmt.Println(tokenizer.OpenAIImageTokens(1024, 1024, tokenizer.ImageDetailLow))
+	fmt.Println(tokenizer.AnthropicImageTokens(1024, 1024))
+	// Output:
+	// 765
+	// 85
+	// 1399
+}
+
+func ExampleRegistry() {
+	registry := tokenizer.NewRegistry()
+	err := registry.Register(tokenizer.ModelMetadata{
+		Name:             "acme-gpt-4o-ft",
+		Provider:         tokenizer.ProviderOpenAI,
+		Encoding:         "o200k_base",
+		ContextWindow:    128000,
+		InputPricePer1M:  3.75,
+		OutputPricePer1M: 15.00,
+	})
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	_ = registry.RegisterAlias("acme-latest", "acme-gpt-4o-ft")
+	_ = registry.Update("gpt-4o", func(m *tokenizer.ModelMetadata) {
+		m.InputPricePer1M = 2.00
+	})
+
+	counter, err := tokenizer.NewCounter(tokenizer.CounterOptions{Registry: registry})
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	result, _ := counter.Count(context.Background(), "Hello, world!", "acme-latest", false)
+	fmt.Println(result.Methods[0].DisplayName, result.Methods[0].Tokens)
+
+	costs := tokenizer.CalculateCostsWithOptions(result.Methods, tokenizer.CostOptions{
+		Registry: registry,
+		Models:   []string{"acme-gpt-4o-ft", "gpt-4o"},
+	})
+	for _, c := range costs {
+		fmt.Printf("%s: $%.2f/1M\n", c.Model, c.RatePer1M)
+	}
+	fmt.Println(tokenizer.GetModelMetadata("gpt-4o").InputPricePer1M)
+	// Output:
+	// o200k_base (acme-gpt-4o-ft) 4
+	// acme-gpt-4o-ft: $3.75/1M
+	// gpt-4o: $2.00/1M
+	// 2.5
+}
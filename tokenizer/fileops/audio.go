@@ -0,0 +1,303 @@
+package fileops
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedAudio is returned when an audio container cannot be parsed.
+var ErrUnsupportedAudio = errors.New("unsupported audio format")
+
+// audioExtensions lists audio containers whose duration can be read locally.
+var audioExtensions = map[string]bool{
+	".wav": true, ".mp3": true, ".flac": true, ".ogg": true, ".oga": true, ".opus": true,
+}
+
+// AudioInfo describes an audio file's container format and duration.
+type AudioInfo struct {
+	Format     string
+	Duration   time.Duration
+	SampleRate int
+	Channels   int
+}
+
+// IsAudioFile reports whether a path has a supported audio extension.
+func IsAudioFile(path string) bool {
+	return audioExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// ReadAudioInfo reads the duration of an audio file from its container
+// headers without decoding the audio. Supports WAV, MP3, FLAC and Ogg
+// (Vorbis and Opus).
+func ReadAudioInfo(path string) (*AudioInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 12)
+	n, err := io.ReadFull(file, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("reading audio %s: %w", path, err)
+	}
+	header = header[:n]
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var info *AudioInfo
+	switch {
+	case len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WAVE")):
+		info, err = readWAVInfo(file)
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		info, err = readFLACInfo(file)
+	case bytes.HasPrefix(header, []byte("OggS")):
+		info, err = readOggInfo(file, stat.Size())
+	case bytes.HasPrefix(header, []byte("ID3")) || (len(header) >= 2 && header[0] == 0xff && header[1]&0xe0 == 0xe0):
+		info, err = readMP3Info(file, stat.Size())
+	default:
+		err = ErrUnsupportedAudio
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading audio %s: %w", path, err)
+	}
+	return info, nil
+}
+
+// readWAVInfo walks RIFF chunks for the format and data sizes.
+func readWAVInfo(r io.ReadSeeker) (*AudioInfo, error) {
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	info := &AudioInfo{Format: "wav"}
+	var byteRate uint32
+	chunk := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, fmt.Errorf("wav data chunk not found: %w", ErrUnsupportedAudio)
+		}
+		id := string(chunk[0:4])
+		size := binary.LittleEndian.Uint32(chunk[4:8])
+
+		switch id {
+		case "fmt ":
+			fmtData := make([]byte, 16)
+			if _, err := io.ReadFull(r, fmtData); err != nil {
+				return nil, err
+			}
+			info.Channels = int(binary.LittleEndian.Uint16(fmtData[2:4]))
+			info.SampleRate = int(binary.LittleEndian.Uint32(fmtData[4:8]))
+			byteRate = binary.LittleEndian.Uint32(fmtData[8:12])
+			if _, err := r.Seek(int64(size)-16+int64(size%2), io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		case "data":
+			if byteRate == 0 {
+				return nil, fmt.Errorf("wav data before fmt chunk: %w", ErrUnsupportedAudio)
+			}
+			info.Duration = time.Duration(float64(size) / float64(byteRate) * float64(time.Second))
+			return info, nil
+		default:
+			if _, err := r.Seek(int64(size)+int64(size%2), io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// readFLACInfo reads the STREAMINFO metadata block.
+func readFLACInfo(r io.Reader) (*AudioInfo, error) {
+	// "fLaC" marker, 4-byte block header, then 34 bytes of STREAMINFO.
+	buf := make([]byte, 42)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	if buf[4]&0x7f != 0 {
+		return nil, fmt.Errorf("flac STREAMINFO missing: %w", ErrUnsupportedAudio)
+	}
+
+	// Bytes 18..25: 20-bit sample rate, 3-bit channels-1, 5-bit bps-1, 36-bit total samples.
+	packed := binary.BigEndian.Uint64(buf[18:26])
+	sampleRate := int(packed >> 44)
+	channels := int((packed>>41)&0x7) + 1
+	totalSamples := packed & 0xfffffffff
+	if sampleRate == 0 {
+		return nil, fmt.Errorf("flac sample rate is zero: %w", ErrUnsupportedAudio)
+	}
+
+	return &AudioInfo{
+		Format:     "flac",
+		Duration:   time.Duration(float64(totalSamples) / float64(sampleRate) * float64(time.Second)),
+		SampleRate: sampleRate,
+		Channels:   channels,
+	}, nil
+}
+
+// oggTailSize is how much of the end of an Ogg file is scanned for the last page.
+const oggTailSize = 64 * 1024
+
+// readOggInfo reads the codec header from the first page and the final
+// granule position from the last page.
+func readOggInfo(r io.ReadSeeker, size int64) (*AudioInfo, error) {
+	head := make([]byte, 128)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	info := &AudioInfo{}
+	var preSkip uint64
+	switch {
+	case bytes.Contains(head, []byte("\x01vorbis")):
+		i := bytes.Index(head, []byte("\x01vorbis")) + 7
+		if len(head) < i+9 {
+			return nil, fmt.Errorf("truncated vorbis header: %w", ErrUnsupportedAudio)
+		}
+		info.Format = "vorbis"
+		info.Channels = int(head[i+4])
+		info.SampleRate = int(binary.LittleEndian.Uint32(head[i+5 : i+9]))
+	case bytes.Contains(head, []byte("OpusHead")):
+		i := bytes.Index(head, []byte("OpusHead")) + 8
+		if len(head) < i+4 {
+			return nil, fmt.Errorf("truncated opus header: %w", ErrUnsupportedAudio)
+		}
+		info.Format = "opus"
+		info.Channels = int(head[i+1])
+		info.SampleRate = 48000 // Opus granule positions are always 48 kHz
+		preSkip = uint64(binary.LittleEndian.Uint16(head[i+2 : i+4]))
+	default:
+		return nil, fmt.Errorf("unknown ogg codec: %w", ErrUnsupportedAudio)
+	}
+	if info.SampleRate == 0 {
+		return nil, fmt.Errorf("ogg sample rate is zero: %w", ErrUnsupportedAudio)
+	}
+
+	offset := size - oggTailSize
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	tail, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	last := bytes.LastIndex(tail, []byte("OggS"))
+	if last < 0 || len(tail) < last+14 {
+		return nil, fmt.Errorf("ogg final page not found: %w", ErrUnsupportedAudio)
+	}
+	granule := binary.LittleEndian.Uint64(tail[last+6 : last+14])
+	if granule > preSkip {
+		granule -= preSkip
+	}
+
+	info.Duration = time.Duration(float64(granule) / float64(info.SampleRate) * float64(time.Second))
+	return info, nil
+}
+
+// MPEG audio Layer III tables indexed by header fields.
+var (
+	mp3BitratesV1 = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mp3BitratesV2 = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+	mp3Rates      = map[int][3]int{
+		3: {44100, 48000, 32000}, // MPEG-1
+		2: {22050, 24000, 16000}, // MPEG-2
+		0: {11025, 12000, 8000},  // MPEG-2.5
+	}
+)
+
+// readMP3Info reads the first Layer III frame header. Files with a Xing/Info
+// header report an exact frame count; others are treated as constant bitrate.
+func readMP3Info(r io.ReadSeeker, size int64) (*AudioInfo, error) {
+	start := int64(0)
+	id3 := make([]byte, 10)
+	if _, err := io.ReadFull(r, id3); err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(id3, []byte("ID3")) {
+		// Syncsafe tag size excludes the 10-byte header.
+		tagSize := int64(id3[6])<<21 | int64(id3[7])<<14 | int64(id3[8])<<7 | int64(id3[9])
+		start = 10 + tagSize
+	}
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	frame := make([]byte, 4+32+12)
+	n, err := io.ReadFull(r, frame)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	frame = frame[:n]
+	if len(frame) < 4 || frame[0] != 0xff || frame[1]&0xe0 != 0xe0 {
+		return nil, fmt.Errorf("mp3 frame sync not found: %w", ErrUnsupportedAudio)
+	}
+
+	version := int(frame[1]>>3) & 0x3
+	layer := int(frame[1]>>1) & 0x3
+	bitrateIdx := int(frame[2] >> 4)
+	rateIdx := int(frame[2]>>2) & 0x3
+	mono := frame[3]>>6 == 3
+
+	rates, ok := mp3Rates[version]
+	if !ok || layer != 1 || rateIdx == 3 {
+		return nil, fmt.Errorf("not an MPEG Layer III stream: %w", ErrUnsupportedAudio)
+	}
+	sampleRate := rates[rateIdx]
+
+	bitrate := mp3BitratesV2[bitrateIdx]
+	samplesPerFrame := 576
+	sideInfo := 17
+	if mono {
+		sideInfo = 9
+	}
+	if version == 3 {
+		bitrate = mp3BitratesV1[bitrateIdx]
+		samplesPerFrame = 1152
+		sideInfo = 32
+		if mono {
+			sideInfo = 17
+		}
+	}
+	if bitrate == 0 {
+		return nil, fmt.Errorf("free-format mp3 bitrate: %w", ErrUnsupportedAudio)
+	}
+
+	channels := 2
+	if mono {
+		channels = 1
+	}
+	info := &AudioInfo{Format: "mp3", SampleRate: sampleRate, Channels: channels}
+
+	if x := 4 + sideInfo; len(frame) >= x+12 {
+		tag := string(frame[x : x+4])
+		flags := binary.BigEndian.Uint32(frame[x+4 : x+8])
+		if (tag == "Xing" || tag == "Info") && flags&0x1 != 0 {
+			frames := binary.BigEndian.Uint32(frame[x+8 : x+12])
+			seconds := float64(frames) * float64(samplesPerFrame) / float64(sampleRate)
+			info.Duration = time.Duration(seconds * float64(time.Second))
+			return info, nil
+		}
+	}
+
+	seconds := float64(size-start) * 8 / float64(bitrate*1000)
+	info.Duration = time.Duration(seconds * float64(time.Second))
+	return info, nil
+}
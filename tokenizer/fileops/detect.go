@@ -19,6 +19,7 @@ var binaryExtensions = map[string]bool{
 	// Documents
 	".pdf": true, ".doc": true, ".docx": true,
 	".xls": true, ".xlsx": true, ".ppt": true, ".pptx": true,
+	".odt": true, ".ods": true, ".odp": true, ".epub": true,
 
 	// Archives
 	".zip": true, ".tar": true, ".gz": true,
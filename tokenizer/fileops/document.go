@@ -0,0 +1,60 @@
+package fileops
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupportedDocument is returned when a document's text cannot be extracted.
+var ErrUnsupportedDocument = errors.New("unsupported document")
+
+// DocumentInfo holds the visible text extracted from a document. Pages is
+// only known for PDFs.
+type DocumentInfo struct {
+	Format string
+	Pages  int
+	Text   string
+}
+
+// documentExtractors maps document extensions to their text extractors.
+var documentExtractors = map[string]func(path string) (string, error){
+	".docx": extractDOCX, ".docm": extractDOCX, ".dotx": extractDOCX,
+	".xlsx": extractXLSX, ".xlsm": extractXLSX,
+	".pptx": extractPPTX, ".pptm": extractPPTX,
+	".odt": extractODF, ".ods": extractODF, ".odp": extractODF,
+	".epub": extractEPUB,
+}
+
+// IsDocumentFile reports whether a path is a PDF, Office Open XML, ODF or
+// EPUB document whose text can be extracted.
+func IsDocumentFile(path string) bool {
+	if IsPDFFile(path) {
+		return true
+	}
+	_, ok := documentExtractors[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// ExtractDocument extracts the visible text of a document file.
+func ExtractDocument(path string) (*DocumentInfo, error) {
+	if IsPDFFile(path) {
+		pdf, err := ReadPDF(path)
+		if err != nil {
+			return nil, err
+		}
+		return &DocumentInfo{Format: "pdf", Pages: pdf.Pages, Text: pdf.Text}, nil
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	extract, ok := documentExtractors[ext]
+	if !ok {
+		return nil, fmt.Errorf("extracting %s: %w", path, ErrUnsupportedDocument)
+	}
+	text, err := extract(path)
+	if err != nil {
+		return nil, fmt.Errorf("extracting %s: %w", path, err)
+	}
+	return &DocumentInfo{Format: strings.TrimPrefix(ext, "."), Text: text}, nil
+}
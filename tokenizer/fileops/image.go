@@ -0,0 +1,117 @@
+package fileops
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF decoder for image.DecodeConfig
+	_ "image/jpeg" // register JPEG decoder for image.DecodeConfig
+	_ "image/png"  // register PNG decoder for image.DecodeConfig
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupportedImage is returned when an image format cannot be decoded.
+var ErrUnsupportedImage = errors.New("unsupported image format")
+
+// imageExtensions lists image formats accepted by vision-capable models.
+var imageExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true,
+}
+
+// ImageInfo describes an image's format and pixel dimensions.
+type ImageInfo struct {
+	Format string
+	Width  int
+	Height int
+}
+
+// IsImageFile reports whether a path has an image extension supported by
+// vision models.
+func IsImageFile(path string) bool {
+	return imageExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// ReadImageInfo reads the dimensions of an image file from its header
+// without decoding the pixel data.
+func ReadImageInfo(path string) (*ImageInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := DecodeImageInfo(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading image %s: %w", path, err)
+	}
+	return info, nil
+}
+
+// DecodeImageInfo reads image dimensions from a stream. PNG, JPEG and GIF are
+// decoded with the standard library; WebP headers are parsed directly.
+func DecodeImageInfo(r io.Reader) (*ImageInfo, error) {
+	br := bufio.NewReader(r)
+
+	header, err := br.Peek(30)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WEBP")) {
+		return decodeWebPInfo(header)
+	}
+
+	cfg, format, err := image.DecodeConfig(br)
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, ErrUnsupportedImage
+		}
+		return nil, err
+	}
+	return &ImageInfo{Format: format, Width: cfg.Width, Height: cfg.Height}, nil
+}
+
+// decodeWebPInfo parses the canvas size from a WebP file header.
+// Supports lossy (VP8), lossless (VP8L) and extended (VP8X) bitstreams.
+func decodeWebPInfo(header []byte) (*ImageInfo, error) {
+	if len(header) < 30 {
+		return nil, fmt.Errorf("webp header too short: %w", ErrUnsupportedImage)
+	}
+
+	chunk := string(header[12:16])
+	data := header[20:]
+
+	switch chunk {
+	case "VP8 ":
+		// Frame tag (3 bytes), start code 9d 01 2a, then 14-bit width and height.
+		if data[3] != 0x9d || data[4] != 0x01 || data[5] != 0x2a {
+			return nil, fmt.Errorf("invalid VP8 start code: %w", ErrUnsupportedImage)
+		}
+		w := int(binary.LittleEndian.Uint16(data[6:8]) & 0x3fff)
+		h := int(binary.LittleEndian.Uint16(data[8:10]) & 0x3fff)
+		return &ImageInfo{Format: "webp", Width: w, Height: h}, nil
+
+	case "VP8L":
+		// Signature byte 0x2f, then 14-bit width-1 and height-1.
+		if data[0] != 0x2f {
+			return nil, fmt.Errorf("invalid VP8L signature: %w", ErrUnsupportedImage)
+		}
+		bits := binary.LittleEndian.Uint32(data[1:5])
+		w := int(bits&0x3fff) + 1
+		h := int((bits>>14)&0x3fff) + 1
+		return &ImageInfo{Format: "webp", Width: w, Height: h}, nil
+
+	case "VP8X":
+		// Flags (4 bytes), then 24-bit canvas width-1 and height-1.
+		w := int(uint32(data[4])|uint32(data[5])<<8|uint32(data[6])<<16) + 1
+		h := int(uint32(data[7])|uint32(data[8])<<8|uint32(data[9])<<16) + 1
+		return &ImageInfo{Format: "webp", Width: w, Height: h}, nil
+	}
+
+	return nil, fmt.Errorf("unknown webp chunk %q: %w", chunk, ErrUnsupportedImage)
+}
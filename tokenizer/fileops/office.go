@@ -0,0 +1,563 @@
+package fileops
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxArchiveEntrySize bounds how much of a single archive entry is read,
+// guarding against zip bombs.
+const maxArchiveEntrySize = 64 << 20
+
+// xmlTextRules describes how an XML vocabulary renders as plain text.
+// Element names are matched by local name, ignoring namespaces.
+type xmlTextRules struct {
+	text   map[string]bool // character data is visible only inside these; nil means everywhere
+	blocks map[string]bool // end with a line break
+	breaks map[string]bool // render as a line break
+	tabs   map[string]bool // render as a tab
+	spaces map[string]bool // render as spaces, repeated by the "c" attribute (ODF text:s)
+	skip   map[string]bool // subtrees are ignored
+	html   bool            // lenient parsing with HTML entities and whitespace collapsing
+}
+
+func elementSet(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[n] = true
+	}
+	return set
+}
+
+var (
+	docxRules = xmlTextRules{
+		text:   elementSet("t"),
+		blocks: elementSet("p"),
+		breaks: elementSet("br", "cr"),
+		tabs:   elementSet("tab"),
+		skip:   elementSet("pPr", "rPr"),
+	}
+
+	pptxRules = xmlTextRules{
+		text:   elementSet("t"),
+		blocks: elementSet("p"),
+		breaks: elementSet("br"),
+	}
+
+	odfRules = xmlTextRules{
+		text:   elementSet("p", "h"),
+		blocks: elementSet("p", "h"),
+		breaks: elementSet("line-break"),
+		tabs:   elementSet("tab"),
+		spaces: elementSet("s"),
+		skip:   elementSet("annotation", "tracked-changes", "notes"),
+	}
+
+	htmlRules = xmlTextRules{
+		blocks: elementSet("p", "div", "h1", "h2", "h3", "h4", "h5", "h6", "li", "tr",
+			"blockquote", "section", "article", "pre", "dd", "dt", "figcaption", "table", "ul", "ol"),
+		breaks: elementSet("br"),
+		tabs:   elementSet("td", "th"),
+		skip:   elementSet("head", "script", "style"),
+		html:   true,
+	}
+)
+
+// extractDOCX reads the paragraphs of a Word document's main body.
+func extractDOCX(path string) (string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = zr.Close() }()
+
+	return zipXMLText(&zr.Reader, "word/document.xml", docxRules)
+}
+
+// extractPPTX reads the text of every slide in slide order.
+func extractPPTX(path string) (string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = zr.Close() }()
+
+	var slides []string
+	for _, name := range zipNumbered(&zr.Reader, "ppt/slides/slide", ".xml") {
+		text, err := zipXMLText(&zr.Reader, name, pptxRules)
+		if err != nil {
+			return "", err
+		}
+		if text != "" {
+			slides = append(slides, text)
+		}
+	}
+	return strings.Join(slides, "\n\n"), nil
+}
+
+// extractODF reads the body of an OpenDocument text, spreadsheet or
+// presentation.
+func extractODF(path string) (string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = zr.Close() }()
+
+	return zipXMLText(&zr.Reader, "content.xml", odfRules)
+}
+
+// extractXLSX reads every worksheet in sheet order, one row per line with
+// cells separated by tabs. Shared strings are resolved into their cells.
+func extractXLSX(path string) (string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = zr.Close() }()
+
+	var shared []string
+	if f := zipEntry(&zr.Reader, "xl/sharedStrings.xml"); f != nil {
+		shared, err = readSharedStrings(f)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var sheets []string
+	for _, name := range zipNumbered(&zr.Reader, "xl/worksheets/sheet", ".xml") {
+		text, err := readWorksheet(zipEntry(&zr.Reader, name), shared)
+		if err != nil {
+			return "", err
+		}
+		if text != "" {
+			sheets = append(sheets, text)
+		}
+	}
+	return strings.Join(sheets, "\n\n"), nil
+}
+
+// readSharedStrings returns the text of each <si> entry, including rich
+// text runs but not phonetic hints.
+func readSharedStrings(f *zip.File) ([]string, error) {
+	rc, err := openZipEntry(f)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	var strs []string
+	var cur strings.Builder
+	inText, skip := false, 0
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return strs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing shared strings: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case skip > 0 || t.Name.Local == "rPh":
+				skip++
+			case t.Name.Local == "si":
+				cur.Reset()
+			case t.Name.Local == "t":
+				inText = true
+			}
+		case xml.EndElement:
+			switch {
+			case skip > 0:
+				skip--
+			case t.Name.Local == "si":
+				strs = append(strs, cur.String())
+			case t.Name.Local == "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText && skip == 0 {
+				cur.Write(t)
+			}
+		}
+	}
+}
+
+// readWorksheet renders a worksheet's non-empty cells.
+func readWorksheet(f *zip.File, shared []string) (string, error) {
+	rc, err := openZipEntry(f)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = rc.Close() }()
+
+	var b strings.Builder
+	var cells []string
+	var cellType string
+	var value strings.Builder
+	inValue := false
+
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return strings.TrimRight(b.String(), "\n"), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("parsing worksheet: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "c":
+				cellType = xmlAttr(t, "t")
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				if text := cellText(cellType, value.String(), shared); text != "" {
+					cells = append(cells, text)
+				}
+			case "row":
+				if len(cells) > 0 {
+					b.WriteString(strings.Join(cells, "\t"))
+					b.WriteByte('\n')
+				}
+				cells = cells[:0]
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	}
+}
+
+// cellText resolves a cell's raw value by its type attribute.
+func cellText(cellType, raw string, shared []string) string {
+	switch cellType {
+	case "s":
+		i, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || i < 0 || i >= len(shared) {
+			return ""
+		}
+		return shared[i]
+	case "b":
+		if strings.TrimSpace(raw) == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return raw
+}
+
+// extractEPUB reads the chapters listed in the package spine, falling back
+// to every XHTML file in name order when the package cannot be read.
+func extractEPUB(filename string) (string, error) {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = zr.Close() }()
+
+	chapters := epubSpine(&zr.Reader)
+	if len(chapters) == 0 {
+		for _, f := range zr.File {
+			switch strings.ToLower(path.Ext(f.Name)) {
+			case ".xhtml", ".html", ".htm":
+				chapters = append(chapters, f.Name)
+			}
+		}
+		sort.Strings(chapters)
+	}
+
+	var texts []string
+	for _, name := range chapters {
+		text, err := zipXMLText(&zr.Reader, name, htmlRules)
+		if err != nil {
+			continue
+		}
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n\n"), nil
+}
+
+// epubSpine returns the archive paths of the package's spine documents in
+// reading order.
+func epubSpine(zr *zip.Reader) []string {
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := zipXMLDecode(zr, "META-INF/container.xml", &container); err != nil || len(container.Rootfiles) == 0 {
+		return nil
+	}
+	opfPath := container.Rootfiles[0].FullPath
+
+	var pkg struct {
+		Items []struct {
+			ID        string `xml:"id,attr"`
+			Href      string `xml:"href,attr"`
+			MediaType string `xml:"media-type,attr"`
+		} `xml:"manifest>item"`
+		Spine []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"spine>itemref"`
+	}
+	if err := zipXMLDecode(zr, opfPath, &pkg); err != nil {
+		return nil
+	}
+
+	hrefs := make(map[string]string, len(pkg.Items))
+	for _, item := range pkg.Items {
+		if strings.Contains(item.MediaType, "html") {
+			hrefs[item.ID] = item.Href
+		}
+	}
+
+	var chapters []string
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+		chapters = append(chapters, path.Join(path.Dir(opfPath), href))
+	}
+	return chapters
+}
+
+// zipEntry returns the archive entry with the given name, or nil.
+func zipEntry(zr *zip.Reader, name string) *zip.File {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// zipNumbered returns entries named prefix+N+suffix ordered by N, so
+// slide10.xml sorts after slide9.xml.
+func zipNumbered(zr *zip.Reader, prefix, suffix string) []string {
+	pattern := regexp.MustCompile("^" + regexp.QuoteMeta(prefix) + `(\d+)` + regexp.QuoteMeta(suffix) + "$")
+	type numbered struct {
+		name string
+		n    int
+	}
+	var entries []numbered
+	for _, f := range zr.File {
+		if m := pattern.FindStringSubmatch(f.Name); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			entries = append(entries, numbered{name: f.Name, n: n})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].n < entries[j].n })
+
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.name
+	}
+	return names
+}
+
+// openZipEntry opens an archive entry, limiting how much can be read.
+func openZipEntry(f *zip.File) (io.ReadCloser, error) {
+	if f == nil {
+		return nil, fmt.Errorf("missing archive entry: %w", ErrUnsupportedDocument)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(rc, maxArchiveEntrySize), rc}, nil
+}
+
+func zipXMLDecode(zr *zip.Reader, name string, v any) error {
+	rc, err := openZipEntry(zipEntry(zr, name))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+func zipXMLText(zr *zip.Reader, name string, rules xmlTextRules) (string, error) {
+	rc, err := openZipEntry(zipEntry(zr, name))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	text, err := extractXMLText(rc, rules)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return text, nil
+}
+
+// extractXMLText renders an XML document as plain text using rules.
+// Lenient (HTML) parsing returns whatever text precedes a syntax error.
+func extractXMLText(r io.Reader, rules xmlTextRules) (string, error) {
+	dec := xml.NewDecoder(r)
+	if rules.html {
+		dec.Strict = false
+		dec.AutoClose = xml.HTMLAutoClose
+		dec.Entity = xml.HTMLEntity
+	}
+
+	w := &textWriter{}
+	textDepth, skipDepth := 0, 0
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if rules.html {
+				break
+			}
+			return "", err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := elementName(t.Name, rules.html)
+			if skipDepth > 0 || rules.skip[name] {
+				skipDepth++
+				continue
+			}
+			if rules.text[name] {
+				textDepth++
+			}
+			switch {
+			case rules.breaks[name]:
+				w.newline()
+			case rules.tabs[name]:
+				w.write("\t")
+			case rules.spaces[name]:
+				n, err := strconv.Atoi(xmlAttr(t, "c"))
+				if err != nil || n < 1 {
+					n = 1
+				}
+				w.write(strings.Repeat(" ", n))
+			}
+
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+			name := elementName(t.Name, rules.html)
+			if rules.text[name] {
+				textDepth--
+			}
+			if rules.blocks[name] {
+				w.newline()
+			}
+
+		case xml.CharData:
+			if skipDepth > 0 || (rules.text != nil && textDepth == 0) {
+				continue
+			}
+			if rules.html {
+				w.writeCollapsed(string(t))
+			} else {
+				w.write(string(t))
+			}
+		}
+	}
+	return w.String(), nil
+}
+
+func elementName(name xml.Name, html bool) string {
+	if html {
+		return strings.ToLower(name.Local)
+	}
+	return name.Local
+}
+
+func xmlAttr(el xml.StartElement, local string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// textWriter builds extracted text, collapsing runs of blank lines.
+type textWriter struct {
+	b    strings.Builder
+	last byte
+}
+
+func (w *textWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	w.b.WriteString(s)
+	w.last = s[len(s)-1]
+}
+
+// writeCollapsed writes HTML character data with whitespace runs collapsed
+// to single spaces and no leading space on a line.
+func (w *textWriter) writeCollapsed(s string) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" && w.b.Len() > 0 && w.last != ' ' && w.last != '\n' && w.last != '\t' {
+			w.write(" ")
+		}
+		return
+	}
+	if isSpaceByte(s[0]) && w.b.Len() > 0 && w.last != ' ' && w.last != '\n' && w.last != '\t' {
+		w.write(" ")
+	}
+	w.write(strings.Join(fields, " "))
+	if isSpaceByte(s[len(s)-1]) {
+		w.write(" ")
+	}
+}
+
+func (w *textWriter) newline() {
+	if w.b.Len() > 0 && w.last != '\n' {
+		w.write("\n")
+	}
+}
+
+// String returns the text with trailing spaces trimmed from each line.
+func (w *textWriter) String() string {
+	lines := strings.Split(w.b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
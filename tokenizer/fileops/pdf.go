@@ -0,0 +1,877 @@
+package fileops
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedPDF is returned when a PDF cannot be parsed or is encrypted.
+var ErrUnsupportedPDF = errors.New("unsupported pdf")
+
+// PDFInfo holds the text extracted from a PDF and its page count.
+type PDFInfo struct {
+	Pages int
+	Text  string
+}
+
+// IsPDFFile reports whether a path has a .pdf extension.
+func IsPDFFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".pdf"
+}
+
+// ReadPDF extracts the text of every page of a PDF file.
+func ReadPDF(path string) (*PDFInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := ParsePDF(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading pdf %s: %w", path, err)
+	}
+	return info, nil
+}
+
+// ParsePDF extracts text from PDF data. Objects are located by scanning the
+// file rather than trusting the cross-reference table, so damaged or
+// incrementally updated files still parse. Page content streams are decoded
+// (Flate, ASCIIHex, ASCII85) and their text operators interpreted using each
+// font's ToUnicode map or its simple encoding (Standard, WinAnsi, MacRoman
+// and Differences). Encrypted files are not supported.
+func ParsePDF(data []byte) (*PDFInfo, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\n\f\r "), []byte("%PDF-")) {
+		return nil, fmt.Errorf("missing %%PDF header: %w", ErrUnsupportedPDF)
+	}
+
+	f := &pdfFile{data: data, objects: make(map[int]any)}
+	f.scanObjects()
+	if len(f.objects) == 0 {
+		return nil, fmt.Errorf("no objects found: %w", ErrUnsupportedPDF)
+	}
+	f.loadObjectStreams()
+
+	trailer := f.trailer()
+	if _, ok := trailer["Encrypt"]; ok {
+		return nil, fmt.Errorf("encrypted pdf: %w", ErrUnsupportedPDF)
+	}
+
+	pages := f.pages(trailer)
+	texts := make([]string, 0, len(pages))
+	for _, p := range pages {
+		ex := &pdfTextExtractor{file: f}
+		ex.run(f.pageContent(p.dict), p.resources, 0)
+		if text := strings.TrimSpace(ex.buf.String()); text != "" {
+			texts = append(texts, text)
+		}
+	}
+
+	return &PDFInfo{Pages: len(pages), Text: strings.Join(texts, "\n\n")}, nil
+}
+
+// PDF object model. Numbers are float64, strings are raw bytes and
+// references are resolved lazily through pdfFile.resolve.
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfString  []byte
+	pdfDict    map[string]any
+	pdfRef     struct{ num, gen int }
+	pdfStream  struct {
+		dict pdfDict
+		raw  []byte
+	}
+)
+
+// pdfFile indexes the objects of a PDF by object number.
+type pdfFile struct {
+	data    []byte
+	objects map[int]any
+}
+
+var pdfObjectHeader = regexp.MustCompile(`\b(\d+)\s+(\d+)\s+obj\b`)
+
+// scanObjects parses every "N G obj" definition in file order, so objects
+// redefined by incremental updates take their latest value.
+func (f *pdfFile) scanObjects() {
+	skipUntil := 0
+	for _, m := range pdfObjectHeader.FindAllSubmatchIndex(f.data, -1) {
+		if m[0] < skipUntil {
+			continue
+		}
+		num, err := strconv.Atoi(string(f.data[m[2]:m[3]]))
+		if err != nil {
+			continue
+		}
+
+		lex := &pdfLexer{data: f.data, pos: m[1]}
+		obj, err := lex.readObject()
+		if err != nil {
+			continue
+		}
+
+		if dict, ok := obj.(pdfDict); ok {
+			if start, end, ok := f.streamBounds(lex, dict); ok {
+				obj = &pdfStream{dict: dict, raw: f.data[start:end]}
+				skipUntil = end
+			}
+		}
+		f.objects[num] = obj
+	}
+}
+
+// streamBounds locates the data of a stream that follows a dictionary,
+// preferring a direct /Length and falling back to the endstream marker. A
+// negative or out-of-range /Length is ignored.
+func (f *pdfFile) streamBounds(lex *pdfLexer, dict pdfDict) (int, int, bool) {
+	lex.skipSpace()
+	if !bytes.HasPrefix(f.data[lex.pos:], []byte("stream")) {
+		return 0, 0, false
+	}
+	start := lex.pos + len("stream")
+	if start < len(f.data) && f.data[start] == '\r' {
+		start++
+	}
+	if start < len(f.data) && f.data[start] == '\n' {
+		start++
+	}
+
+	if n, ok := dict["Length"].(float64); ok && n >= 0 && n <= float64(len(f.data)-start) {
+		end := start + int(n)
+		if bytes.HasPrefix(bytes.TrimLeft(f.data[end:], "\r\n\t "), []byte("endstream")) {
+			return start, end, true
+		}
+	}
+
+	i := bytes.Index(f.data[start:], []byte("endstream"))
+	if i < 0 {
+		return 0, 0, false
+	}
+	end := start + i
+	for end > start && (f.data[end-1] == '\n' || f.data[end-1] == '\r') {
+		end--
+	}
+	return start, end, true
+}
+
+// loadObjectStreams adds objects compressed into /ObjStm streams (PDF 1.5+).
+// Objects already defined directly take precedence.
+func (f *pdfFile) loadObjectStreams() {
+	for _, obj := range f.objects {
+		s, ok := obj.(*pdfStream)
+		if !ok || s.dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		data, err := f.decodeStream(s)
+		if err != nil {
+			continue
+		}
+		n, _ := s.dict["N"].(float64)
+		first, _ := s.dict["First"].(float64)
+
+		header := &pdfLexer{data: data}
+		for i := 0; i < int(n); i++ {
+			numObj, err1 := header.readObject()
+			offObj, err2 := header.readObject()
+			num, ok1 := numObj.(float64)
+			off, ok2 := offObj.(float64)
+			if err1 != nil || err2 != nil || !ok1 || !ok2 {
+				break
+			}
+			if _, exists := f.objects[int(num)]; exists {
+				continue
+			}
+			pos := int(first) + int(off)
+			if pos < 0 || pos >= len(data) {
+				continue
+			}
+			lex := &pdfLexer{data: data, pos: pos}
+			if obj, err := lex.readObject(); err == nil {
+				f.objects[int(num)] = obj
+			}
+		}
+	}
+}
+
+// trailer merges every trailer dictionary and cross-reference stream
+// dictionary in the file.
+func (f *pdfFile) trailer() pdfDict {
+	trailer := pdfDict{}
+	for _, obj := range f.objects {
+		if s, ok := obj.(*pdfStream); ok && s.dict["Type"] == pdfName("XRef") {
+			for k, v := range s.dict {
+				trailer[k] = v
+			}
+		}
+	}
+	data := f.data
+	for {
+		i := bytes.Index(data, []byte("trailer"))
+		if i < 0 {
+			break
+		}
+		lex := &pdfLexer{data: data, pos: i + len("trailer")}
+		if dict, err := lex.readObject(); err == nil {
+			if d, ok := dict.(pdfDict); ok {
+				for k, v := range d {
+					trailer[k] = v
+				}
+			}
+		}
+		data = data[i+len("trailer"):]
+	}
+	return trailer
+}
+
+// resolve follows indirect references.
+func (f *pdfFile) resolve(v any) any {
+	for depth := 0; depth < 32; depth++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = f.objects[ref.num]
+	}
+	return nil
+}
+
+func (f *pdfFile) dict(v any) pdfDict {
+	switch d := f.resolve(v).(type) {
+	case pdfDict:
+		return d
+	case *pdfStream:
+		return d.dict
+	}
+	return nil
+}
+
+// pdfPage is a page dictionary with its inherited resources.
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pages returns the pages in document order by walking the page tree from
+// the catalog. Files without a usable catalog fall back to every /Page object
+// in object-number order.
+func (f *pdfFile) pages(trailer pdfDict) []pdfPage {
+	catalog := f.dict(trailer["Root"])
+	if catalog == nil {
+		for _, obj := range f.objects {
+			if d, ok := obj.(pdfDict); ok && d["Type"] == pdfName("Catalog") {
+				catalog = d
+				break
+			}
+		}
+	}
+
+	var pages []pdfPage
+	if catalog != nil {
+		visited := make(map[int]bool)
+		f.walkPageTree(catalog["Pages"], nil, visited, &pages)
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	nums := make([]int, 0, len(f.objects))
+	for num := range f.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		if d, ok := f.objects[num].(pdfDict); ok && d["Type"] == pdfName("Page") {
+			pages = append(pages, pdfPage{dict: d, resources: f.dict(d["Resources"])})
+		}
+	}
+	return pages
+}
+
+func (f *pdfFile) walkPageTree(node any, resources pdfDict, visited map[int]bool, pages *[]pdfPage) {
+	if ref, ok := node.(pdfRef); ok {
+		if visited[ref.num] {
+			return
+		}
+		visited[ref.num] = true
+	}
+	d := f.dict(node)
+	if d == nil {
+		return
+	}
+	if r := f.dict(d["Resources"]); r != nil {
+		resources = r
+	}
+
+	kids, ok := f.resolve(d["Kids"]).([]any)
+	if !ok {
+		if d["Type"] == pdfName("Page") || d["Contents"] != nil {
+			*pages = append(*pages, pdfPage{dict: d, resources: resources})
+		}
+		return
+	}
+	for _, kid := range kids {
+		f.walkPageTree(kid, resources, visited, pages)
+	}
+}
+
+// pageContent concatenates a page's decoded content streams.
+func (f *pdfFile) pageContent(page pdfDict) []byte {
+	var streams []any
+	switch c := f.resolve(page["Contents"]).(type) {
+	case []any:
+		streams = c
+	case *pdfStream:
+		streams = []any{c}
+	}
+
+	var out []byte
+	for _, s := range streams {
+		stream, ok := f.resolve(s).(*pdfStream)
+		if !ok {
+			continue
+		}
+		data, err := f.decodeStream(stream)
+		if err != nil {
+			continue
+		}
+		out = append(out, data...)
+		out = append(out, '\n')
+	}
+	return out
+}
+
+// decodeStream applies a stream's filters in order.
+func (f *pdfFile) decodeStream(s *pdfStream) ([]byte, error) {
+	var filters []any
+	switch v := f.resolve(s.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []any{v}
+	case []any:
+		filters = v
+	}
+
+	data := s.raw
+	for _, filter := range filters {
+		name, _ := f.resolve(filter).(pdfName)
+		var err error
+		switch name {
+		case "FlateDecode", "Fl":
+			data, err = inflate(data)
+		case "ASCIIHexDecode", "AHx":
+			data, err = decodeASCIIHex(data)
+		case "ASCII85Decode", "A85":
+			data, err = decodeASCII85(data)
+		default:
+			err = fmt.Errorf("filter %s: %w", name, ErrUnsupportedPDF)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// inflate decompresses zlib data, falling back to raw deflate and keeping
+// whatever was recovered from truncated streams. Streams that inflate past
+// maxArchiveEntrySize are rejected, guarding against deflate bombs.
+func inflate(data []byte) ([]byte, error) {
+	var r io.ReadCloser
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		r = flate.NewReader(bytes.NewReader(data))
+	}
+	defer func() { _ = r.Close() }()
+
+	out, err := io.ReadAll(io.LimitReader(r, maxArchiveEntrySize+1))
+	if len(out) > maxArchiveEntrySize {
+		return nil, fmt.Errorf("stream inflates past %d MB: %w", maxArchiveEntrySize>>20, ErrUnsupportedPDF)
+	}
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+func decodeASCIIHex(data []byte) ([]byte, error) {
+	var digits []byte
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if !isPDFWhitespace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	return hex.DecodeString(string(digits))
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	out := make([]byte, len(data))
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, err
+	}
+	return out[:n], nil
+}
+
+// pdfTextExtractor interprets content stream text operators. Line breaks are
+// inferred from vertical moves of the text position, and word breaks from
+// horizontal moves and large TJ adjustments.
+type pdfTextExtractor struct {
+	file *pdfFile
+	buf  strings.Builder
+	last byte
+
+	y, shownY float64
+	leading   float64
+	shown     bool
+	moved     bool
+}
+
+// pdfTJSpace is the TJ adjustment, in thousandths of an em, treated as a
+// word break.
+const pdfTJSpace = -200
+
+// pdfMaxFormDepth bounds recursion into nested form XObjects.
+const pdfMaxFormDepth = 8
+
+func (e *pdfTextExtractor) run(content []byte, resources pdfDict, depth int) {
+	lex := &pdfLexer{data: content}
+	fonts := make(map[string]*pdfFont)
+	var font *pdfFont
+	var operands []any
+
+	for {
+		obj, err := lex.readObject()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			lex.pos++
+			operands = operands[:0]
+			continue
+		}
+		op, ok := obj.(pdfKeyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+
+		switch op {
+		case "BT":
+			e.y = 0
+			e.moved = true
+		case "Tf":
+			if len(operands) >= 1 {
+				name, _ := operands[0].(pdfName)
+				font = e.font(fonts, resources, string(name))
+			}
+		case "TL":
+			if n, ok := lastNumber(operands); ok {
+				e.leading = n
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				ty, _ := operands[1].(float64)
+				e.y += ty
+				if op == "TD" {
+					e.leading = -ty
+				}
+			}
+			e.moved = true
+		case "Tm":
+			if len(operands) >= 6 {
+				e.y, _ = operands[5].(float64)
+			}
+			e.moved = true
+		case "T*":
+			e.nextLine()
+		case "Tj":
+			if s, ok := lastString(operands); ok {
+				e.show(font, s)
+			}
+		case "'", "\"":
+			e.nextLine()
+			if s, ok := lastString(operands); ok {
+				e.show(font, s)
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				arr, _ := operands[len(operands)-1].([]any)
+				for _, item := range arr {
+					switch v := item.(type) {
+					case pdfString:
+						e.show(font, v)
+					case float64:
+						if v <= pdfTJSpace {
+							e.space()
+						}
+					}
+				}
+			}
+		case "Do":
+			if len(operands) >= 1 && depth < pdfMaxFormDepth {
+				name, _ := operands[0].(pdfName)
+				e.form(resources, string(name), depth)
+			}
+		case "ID":
+			lex.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+}
+
+// nextLine moves to the next line by the current leading.
+func (e *pdfTextExtractor) nextLine() {
+	leading := e.leading
+	if leading == 0 {
+		leading = 1
+	}
+	e.y -= leading
+	e.moved = true
+}
+
+func (e *pdfTextExtractor) show(font *pdfFont, s pdfString) {
+	text := font.decode(s)
+	if text == "" {
+		return
+	}
+	if e.shown && math.Abs(e.y-e.shownY) > 0.5 {
+		e.newline()
+	} else if e.moved {
+		e.space()
+	}
+	e.buf.WriteString(text)
+	e.last = text[len(text)-1]
+	e.shown = true
+	e.shownY = e.y
+	e.moved = false
+}
+
+func (e *pdfTextExtractor) space() {
+	if e.buf.Len() > 0 && e.last != ' ' && e.last != '\n' {
+		e.buf.WriteByte(' ')
+		e.last = ' '
+	}
+}
+
+func (e *pdfTextExtractor) newline() {
+	if e.buf.Len() > 0 && e.last != '\n' {
+		e.buf.WriteByte('\n')
+		e.last = '\n'
+	}
+}
+
+// font loads and caches a font from the current resources.
+func (e *pdfTextExtractor) font(cache map[string]*pdfFont, resources pdfDict, name string) *pdfFont {
+	if font, ok := cache[name]; ok {
+		return font
+	}
+	fonts := e.file.dict(resources["Font"])
+	font := e.file.loadFont(e.file.dict(fonts[name]))
+	cache[name] = font
+	return font
+}
+
+// form interprets a form XObject's content with its own resources.
+func (e *pdfTextExtractor) form(resources pdfDict, name string, depth int) {
+	xobjects := e.file.dict(resources["XObject"])
+	stream, ok := e.file.resolve(xobjects[name]).(*pdfStream)
+	if !ok || stream.dict["Subtype"] != pdfName("Form") {
+		return
+	}
+	data, err := e.file.decodeStream(stream)
+	if err != nil {
+		return
+	}
+	formResources := e.file.dict(stream.dict["Resources"])
+	if formResources == nil {
+		formResources = resources
+	}
+	e.run(data, formResources, depth+1)
+}
+
+func lastNumber(operands []any) (float64, bool) {
+	if len(operands) == 0 {
+		return 0, false
+	}
+	n, ok := operands[len(operands)-1].(float64)
+	return n, ok
+}
+
+func lastString(operands []any) (pdfString, bool) {
+	if len(operands) == 0 {
+		return nil, false
+	}
+	s, ok := operands[len(operands)-1].(pdfString)
+	return s, ok
+}
+
+// pdfLexer reads PDF objects from a byte slice.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+var errPDFSyntax = fmt.Errorf("syntax error: %w", ErrUnsupportedPDF)
+
+func isPDFWhitespace(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// skipSpace skips whitespace and comments.
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isPDFWhitespace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// readObject reads the next object or keyword. Integers followed by
+// "G R" are returned as references.
+func (l *pdfLexer) readObject() (any, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+
+	switch c := l.data[l.pos]; {
+	case c == '/':
+		return l.readName(), nil
+	case c == '(':
+		return l.readLiteralString(), nil
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return l.readDict()
+	case c == '<':
+		return l.readHexString(), nil
+	case c == '[':
+		l.pos++
+		return l.readArray()
+	case c == '{' || c == '}':
+		l.pos++
+		return pdfKeyword(c), nil
+	case isPDFDelimiter(c):
+		return nil, errPDFSyntax
+	}
+
+	word := l.readWord()
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	n, err := strconv.ParseFloat(word, 64)
+	if err != nil {
+		return pdfKeyword(word), nil
+	}
+	if ref, ok := l.tryRef(word); ok {
+		return ref, nil
+	}
+	return n, nil
+}
+
+// tryRef looks ahead for the "G R" of an indirect reference.
+func (l *pdfLexer) tryRef(numWord string) (pdfRef, bool) {
+	num, err := strconv.Atoi(numWord)
+	if err != nil {
+		return pdfRef{}, false
+	}
+	save := l.pos
+	l.skipSpace()
+	gen, err := strconv.Atoi(l.readWord())
+	if err == nil {
+		l.skipSpace()
+		if l.readWord() == "R" {
+			return pdfRef{num: num, gen: gen}, true
+		}
+	}
+	l.pos = save
+	return pdfRef{}, false
+}
+
+func (l *pdfLexer) readWord() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFWhitespace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+func (l *pdfLexer) readName() pdfName {
+	l.pos++ // '/'
+	var name []byte
+	for l.pos < len(l.data) && !isPDFWhitespace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) {
+			if b, err := hex.DecodeString(string(l.data[l.pos+1 : l.pos+3])); err == nil {
+				name = append(name, b[0])
+				l.pos += 3
+				continue
+			}
+		}
+		name = append(name, c)
+		l.pos++
+	}
+	return pdfName(name)
+}
+
+func (l *pdfLexer) readLiteralString() pdfString {
+	l.pos++ // '('
+	var s []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return s
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		s = append(s, c)
+	}
+	return s
+}
+
+func (l *pdfLexer) readHexString() pdfString {
+	l.pos++ // '<'
+	start := l.pos
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		l.pos++
+	}
+	s, _ := decodeASCIIHex(l.data[start:l.pos])
+	l.pos++
+	return s
+}
+
+func (l *pdfLexer) readArray() ([]any, error) {
+	arr := []any{}
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.data) {
+			return nil, errPDFSyntax
+		}
+		if l.data[l.pos] == ']' {
+			l.pos++
+			return arr, nil
+		}
+		obj, err := l.readObject()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, obj)
+	}
+}
+
+func (l *pdfLexer) readDict() (pdfDict, error) {
+	dict := pdfDict{}
+	for {
+		l.skipSpace()
+		if l.pos+1 < len(l.data) && l.data[l.pos] == '>' && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return dict, nil
+		}
+		key, err := l.readObject()
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(pdfName)
+		if !ok {
+			return nil, errPDFSyntax
+		}
+		value, err := l.readObject()
+		if err != nil {
+			return nil, err
+		}
+		dict[string(name)] = value
+	}
+}
+
+// skipInlineImage skips the binary data of an inline image up to its EI
+// operator.
+func (l *pdfLexer) skipInlineImage() {
+	for i := l.pos + 1; i+2 <= len(l.data); i++ {
+		if l.data[i] == 'E' && l.data[i+1] == 'I' && isPDFWhitespace(l.data[i-1]) &&
+			(i+2 == len(l.data) || isPDFWhitespace(l.data[i+2])) {
+			l.pos = i + 2
+			return
+		}
+	}
+	l.pos = len(l.data)
+}
@@ -0,0 +1,391 @@
+package fileops
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// pdfFont maps the character codes of a PDF font to text.
+type pdfFont struct {
+	toUnicode *pdfCMap
+	encoding  *[256]string
+	composite bool // Type0 fonts use multi-byte CIDs
+}
+
+// loadFont builds a decoder from a font dictionary. A nil dictionary yields
+// a font using the standard encoding.
+func (f *pdfFile) loadFont(dict pdfDict) *pdfFont {
+	font := &pdfFont{encoding: &pdfStandardEncoding}
+	if dict == nil {
+		return font
+	}
+	font.composite = dict["Subtype"] == pdfName("Type0")
+
+	if s, ok := f.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+		if data, err := f.decodeStream(s); err == nil {
+			font.toUnicode = parseCMap(data)
+		}
+	}
+
+	switch enc := f.resolve(dict["Encoding"]).(type) {
+	case pdfName:
+		font.encoding = pdfBaseEncoding(enc)
+	case pdfDict:
+		base := pdfBaseEncoding(pdfName(""))
+		if name, ok := f.resolve(enc["BaseEncoding"]).(pdfName); ok {
+			base = pdfBaseEncoding(name)
+		}
+		table := *base
+		differences, _ := f.resolve(enc["Differences"]).([]any)
+		code := 0
+		for _, d := range differences {
+			switch v := f.resolve(d).(type) {
+			case float64:
+				code = int(v)
+			case pdfName:
+				if code >= 0 && code < len(table) {
+					table[code] = glyphText(string(v))
+				}
+				code++
+			}
+		}
+		font.encoding = &table
+	}
+	return font
+}
+
+// decode converts a shown string to text. Codes missing from a composite
+// font's ToUnicode map are dropped, since CIDs carry no meaning on their own.
+func (ft *pdfFont) decode(s []byte) string {
+	if ft == nil {
+		ft = &pdfFont{encoding: &pdfStandardEncoding}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		n := 1
+		if ft.toUnicode != nil {
+			n = ft.toUnicode.codeLength(s[i:], ft.composite)
+		} else if ft.composite {
+			n = 2
+		}
+		if i+n > len(s) {
+			n = len(s) - i
+		}
+		code := s[i : i+n]
+		i += n
+
+		if ft.toUnicode != nil {
+			if text, ok := ft.toUnicode.lookup(code); ok {
+				b.WriteString(text)
+				continue
+			}
+		}
+		if !ft.composite && len(code) == 1 {
+			b.WriteString(ft.encoding[code[0]])
+		}
+	}
+	return b.String()
+}
+
+// pdfCMap is a parsed ToUnicode CMap.
+type pdfCMap struct {
+	codespaces []pdfCodeRange
+	chars      map[string]string
+	ranges     []pdfBFRange
+}
+
+type pdfCodeRange struct {
+	lo, hi []byte
+}
+
+type pdfBFRange struct {
+	lo, hi uint32
+	width  int
+	dst    []rune   // incremented per code when dsts is empty
+	dsts   []string // explicit destination per code
+}
+
+// parseCMap reads codespace ranges and bfchar/bfrange mappings.
+func parseCMap(data []byte) *pdfCMap {
+	cm := &pdfCMap{chars: make(map[string]string)}
+	lex := &pdfLexer{data: data}
+	var operands []any
+
+	for {
+		obj, err := lex.readObject()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			lex.pos++
+			continue
+		}
+		kw, ok := obj.(pdfKeyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+
+		switch kw {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 && len(lo) == len(hi) && len(lo) > 0 {
+					cm.codespaces = append(cm.codespaces, pdfCodeRange{lo: lo, hi: hi})
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok := operands[i].(pdfString)
+				if !ok {
+					continue
+				}
+				switch dst := operands[i+1].(type) {
+				case pdfString:
+					cm.chars[string(src)] = decodeUTF16BE(dst)
+				case pdfName:
+					cm.chars[string(src)] = glyphText(string(dst))
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 || len(lo) == 0 || len(lo) != len(hi) {
+					continue
+				}
+				r := pdfBFRange{lo: codeValue(lo), hi: codeValue(hi), width: len(lo)}
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					r.dst = []rune(decodeUTF16BE(dst))
+				case []any:
+					for _, d := range dst {
+						s, _ := d.(pdfString)
+						r.dsts = append(r.dsts, decodeUTF16BE(s))
+					}
+				}
+				cm.ranges = append(cm.ranges, r)
+			}
+		}
+		if strings.HasPrefix(string(kw), "end") || strings.HasPrefix(string(kw), "begin") {
+			operands = operands[:0]
+		}
+	}
+	return cm
+}
+
+// codeLength returns the byte length of the next code, using the codespace
+// ranges when present.
+func (cm *pdfCMap) codeLength(s []byte, composite bool) int {
+	for n := 1; n <= 4 && n <= len(s); n++ {
+		for _, cr := range cm.codespaces {
+			if len(cr.lo) == n && inCodeRange(s[:n], cr) {
+				return n
+			}
+		}
+	}
+	if composite {
+		return 2
+	}
+	return 1
+}
+
+func inCodeRange(code []byte, cr pdfCodeRange) bool {
+	for i, c := range code {
+		if c < cr.lo[i] || c > cr.hi[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (cm *pdfCMap) lookup(code []byte) (string, bool) {
+	if text, ok := cm.chars[string(code)]; ok {
+		return text, true
+	}
+	v := codeValue(code)
+	for _, r := range cm.ranges {
+		if r.width != len(code) || v < r.lo || v > r.hi {
+			continue
+		}
+		offset := int(v - r.lo)
+		if r.dsts != nil {
+			if offset < len(r.dsts) {
+				return r.dsts[offset], true
+			}
+			return "", false
+		}
+		if len(r.dst) == 0 {
+			return "", false
+		}
+		dst := append([]rune(nil), r.dst...)
+		dst[len(dst)-1] += rune(offset)
+		return string(dst), true
+	}
+	return "", false
+}
+
+func codeValue(code []byte) uint32 {
+	var v uint32
+	for _, c := range code {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+func decodeUTF16BE(s []byte) string {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// glyphNames maps common Adobe glyph names to text. Single-character names,
+// uniXXXX names and accented letters are handled by glyphText.
+var glyphNames = map[string]string{
+	"space": " ", "exclam": "!", "quotedbl": "\"", "numbersign": "#", "dollar": "$",
+	"percent": "%", "ampersand": "&", "quotesingle": "'", "quoteright": "’",
+	"parenleft": "(", "parenright": ")", "asterisk": "*", "plus": "+", "comma": ",",
+	"hyphen": "-", "minus": "−", "period": ".", "slash": "/", "colon": ":",
+	"semicolon": ";", "less": "<", "equal": "=", "greater": ">", "question": "?",
+	"at": "@", "bracketleft": "[", "backslash": "\\", "bracketright": "]",
+	"asciicircum": "^", "underscore": "_", "grave": "`", "quoteleft": "‘",
+	"braceleft": "{", "bar": "|", "braceright": "}", "asciitilde": "~",
+	"zero": "0", "one": "1", "two": "2", "three": "3", "four": "4",
+	"five": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9",
+	"quotedblleft": "“", "quotedblright": "”", "quotesinglbase": "‚",
+	"quotedblbase": "„", "endash": "–", "emdash": "—", "bullet": "•",
+	"ellipsis": "…", "dagger": "†", "daggerdbl": "‡", "section": "§",
+	"paragraph": "¶", "copyright": "©", "registered": "®", "trademark": "™",
+	"degree": "°", "plusminus": "±", "multiply": "×", "divide": "÷",
+	"fi": "fi", "fl": "fl", "ff": "ff", "ffi": "ffi", "ffl": "ffl",
+	"germandbls": "ß", "AE": "Æ", "ae": "æ", "OE": "Œ", "oe": "œ",
+	"Oslash": "Ø", "oslash": "ø", "dotlessi": "ı", "nbspace": " ",
+	"Euro": "€", "sterling": "£", "yen": "¥", "cent": "¢",
+	"guillemotleft": "«", "guillemotright": "»", "exclamdown": "¡",
+	"questiondown": "¿", "florin": "ƒ", "perthousand": "‰",
+}
+
+// glyphAccents maps accent suffixes of glyph names like "eacute" to
+// combining marks.
+var glyphAccents = map[string]string{
+	"acute": "́", "grave": "̀", "circumflex": "̂", "dieresis": "̈",
+	"tilde": "̃", "ring": "̊", "cedilla": "̧", "caron": "̌",
+}
+
+// glyphText converts a glyph name to text. Unknown names map to nothing.
+func glyphText(name string) string {
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		name = name[:i] // drop variant suffixes like ".sc"
+	}
+	if text, ok := glyphNames[name]; ok {
+		return text
+	}
+	if len(name) == 1 {
+		return name
+	}
+	if strings.Contains(name, "_") {
+		var b strings.Builder
+		for _, part := range strings.Split(name, "_") {
+			b.WriteString(glyphText(part))
+		}
+		return b.String()
+	}
+	if hexCode, ok := strings.CutPrefix(name, "uni"); ok && len(hexCode)%4 == 0 {
+		var b strings.Builder
+		for i := 0; i+4 <= len(hexCode); i += 4 {
+			v, err := strconv.ParseUint(hexCode[i:i+4], 16, 32)
+			if err != nil {
+				return ""
+			}
+			b.WriteRune(rune(v))
+		}
+		return b.String()
+	}
+	if hexCode, ok := strings.CutPrefix(name, "u"); ok && len(hexCode) >= 4 && len(hexCode) <= 6 {
+		if v, err := strconv.ParseUint(hexCode, 16, 32); err == nil {
+			return string(rune(v))
+		}
+	}
+	for accent, mark := range glyphAccents {
+		if base, ok := strings.CutSuffix(name, accent); ok && len(base) == 1 {
+			return base + mark
+		}
+	}
+	return ""
+}
+
+// pdfBaseEncoding returns a named simple font encoding. Unknown names fall
+// back to the standard encoding.
+func pdfBaseEncoding(name pdfName) *[256]string {
+	switch name {
+	case "WinAnsiEncoding":
+		return &pdfWinAnsiEncoding
+	case "MacRomanEncoding":
+		return &pdfMacRomanEncoding
+	}
+	return &pdfStandardEncoding
+}
+
+var (
+	pdfStandardEncoding = buildEncoding(map[byte]rune{
+		0x27: '’', 0x60: '‘', 0xa1: '¡', 0xa2: '¢', 0xa3: '£',
+		0xa5: '¥', 0xa7: '§', 0xa9: '\'', 0xaa: '“', 0xab: '«',
+		0xae: 'ﬁ', 0xaf: 'ﬂ', 0xb1: '–', 0xb2: '†', 0xb3: '‡',
+		0xb7: '•', 0xb9: '„', 0xba: '”', 0xbb: '»', 0xbc: '…',
+		0xbf: '¿', 0xd0: '—', 0xe1: 'Æ', 0xe9: 'Ø', 0xea: 'Œ',
+		0xf1: 'æ', 0xf5: 'ı', 0xf9: 'ø', 0xfa: 'œ', 0xfb: 'ß',
+	}, false)
+
+	pdfWinAnsiEncoding = buildEncoding(map[byte]rune{
+		0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…',
+		0x86: '†', 0x87: '‡', 0x88: 'ˆ', 0x89: '‰', 0x8a: 'Š',
+		0x8b: '‹', 0x8c: 'Œ', 0x8e: 'Ž', 0x91: '‘', 0x92: '’',
+		0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+		0x98: '˜', 0x99: '™', 0x9a: 'š', 0x9b: '›', 0x9c: 'œ',
+		0x9e: 'ž', 0x9f: 'Ÿ',
+	}, true)
+
+	pdfMacRomanEncoding = buildMacRoman()
+)
+
+// buildEncoding starts from printable ASCII (plus Latin-1 above 0xA0 when
+// latin1 is set) and applies overrides.
+func buildEncoding(overrides map[byte]rune, latin1 bool) [256]string {
+	var table [256]string
+	for c := 0x20; c < 0x7f; c++ {
+		table[c] = string(rune(c))
+	}
+	table['\t'], table['\n'], table['\r'] = "\t", "\n", "\r"
+	if latin1 {
+		for c := 0xa0; c <= 0xff; c++ {
+			table[c] = string(rune(c))
+		}
+	}
+	for c, r := range overrides {
+		table[c] = string(r)
+	}
+	return table
+}
+
+// macRomanHigh is MacRomanEncoding for codes 0x80 through 0xFF.
+const macRomanHigh = "ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü" +
+	"†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø" +
+	"¿¡¬√ƒ≈∆«»… ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ" +
+	"‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ"
+
+func buildMacRoman() [256]string {
+	table := buildEncoding(nil, false)
+	c := 0x80
+	for _, r := range macRomanHigh {
+		table[c] = string(r)
+		c++
+	}
+	return table
+}
@@ -12,15 +12,23 @@ import (
 )
 
 // WalkResult contains information about walked files.
+// Images and Audio list the media files among the skipped binaries so
+// callers can estimate them separately. Documents lists PDF, Office, ODF
+// and EPUB files, which are not in Files; callers that extract their text
+// pass them to AggregateFiles.
 type WalkResult struct {
 	Files         []string
+	Images        []string
+	Audio         []string
+	Documents     []string
 	TotalFiles    int
 	SkippedBinary int
 	SkippedIgnore int
 }
 
 // WalkDirectory recursively walks a directory, respecting .gitignore files
-// and filtering out binary files.
+// and filtering out binary files. Extractable documents are listed separately
+// in Documents.
 func WalkDirectory(ctx context.Context, rootPath string) (*WalkResult, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
@@ -67,6 +75,11 @@ func WalkDirectory(ctx context.Context, rootPath string) (*WalkResult, error) {
 			return nil
 		}
 
+		if IsDocumentFile(path) {
+			result.Documents = append(result.Documents, path)
+			return nil
+		}
+
 		isBinary, err := IsBinaryFile(path)
 		if err != nil {
 			result.SkippedBinary++
@@ -74,6 +87,11 @@ func WalkDirectory(ctx context.Context, rootPath string) (*WalkResult, error) {
 		}
 		if isBinary {
 			result.SkippedBinary++
+			if IsImageFile(path) {
+				result.Images = append(result.Images, path)
+			} else if IsAudioFile(path) {
+				result.Audio = append(result.Audio, path)
+			}
 			return nil
 		}
 
@@ -88,9 +106,41 @@ func WalkDirectory(ctx context.Context, rootPath string) (*WalkResult, error) {
 	return result, nil
 }
 
+// PDFPages records the page count of a PDF extracted by AggregateFiles.
+type PDFPages struct {
+	Path  string
+	Pages int
+}
+
+// Aggregate is the combined text of a set of files.
+type Aggregate struct {
+	Content []byte
+	PDFs    []PDFPages
+}
+
+// Pages returns the total page count of the aggregated PDFs.
+func (a *Aggregate) Pages() int {
+	total := 0
+	for _, p := range a.PDFs {
+		total += p.Pages
+	}
+	return total
+}
+
 // AggregateFileContents reads all files and returns combined content.
 // Pre-allocates the result buffer based on file sizes to minimize allocations.
 func AggregateFileContents(ctx context.Context, files []string) ([]byte, error) {
+	agg, err := AggregateFiles(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	return agg.Content, nil
+}
+
+// AggregateFiles reads all files and returns their combined text. Documents
+// contribute their extracted text, and PDFs are listed with their page
+// counts; documents that cannot be extracted are skipped.
+func AggregateFiles(ctx context.Context, files []string) (*Aggregate, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
@@ -102,19 +152,31 @@ func AggregateFileContents(ctx context.Context, files []string) ([]byte, error)
 		}
 	}
 
-	totalContent := make([]byte, 0, totalSize)
+	agg := &Aggregate{Content: make([]byte, 0, totalSize)}
 
 	for _, file := range files {
 		if ctxErr := ctx.Err(); ctxErr != nil {
 			return nil, ctxErr
 		}
 
+		if IsDocumentFile(file) {
+			doc, err := ExtractDocument(file)
+			if err != nil {
+				continue
+			}
+			agg.Content = append(agg.Content, doc.Text...)
+			if doc.Format == "pdf" {
+				agg.PDFs = append(agg.PDFs, PDFPages{Path: file, Pages: doc.Pages})
+			}
+			continue
+		}
+
 		content, err := os.ReadFile(file)
 		if err != nil {
 			return nil, fmt.Errorf("reading file %s: %w", file, err)
 		}
-		totalContent = append(totalContent, content...)
+		agg.Content = append(agg.Content, content...)
 	}
 
-	return totalContent, nil
+	return agg, nil
 }
@@ -0,0 +1,295 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Epoch defaults OpenAI applies when a fine-tuning job does not set
+// n_epochs: three epochs, adjusted so the job trains on 100 to 25,000
+// examples in total.
+const (
+	finetuneTargetEpochs      = 3
+	finetuneMinTargetExamples = 100
+	finetuneMaxTargetExamples = 25000
+	finetuneMaxDefaultEpochs  = 25
+)
+
+// finetuneRoles are the message roles accepted in chat-format training data.
+var finetuneRoles = map[string]bool{
+	"system": true, "developer": true, "user": true, "assistant": true, "tool": true, "function": true,
+}
+
+// finetuneMessageKeys are the message fields accepted in training data.
+var finetuneMessageKeys = map[string]bool{
+	"role": true, "content": true, "name": true, "weight": true,
+	"tool_calls": true, "tool_call_id": true, "function_call": true,
+}
+
+// FinetuneOptions configures CountFinetune.
+type FinetuneOptions struct {
+	Model  string
+	Epochs int // 0 selects the number of epochs the way OpenAI does by default
+}
+
+// FinetuneResult summarizes a chat-format fine-tuning dataset.
+type FinetuneResult struct {
+	Model           string          `json:"model"`
+	Encoding        string          `json:"encoding"`
+	IsExact         bool            `json:"is_exact"`
+	Examples        int             `json:"examples"`
+	ExampleLimit    int             `json:"example_limit,omitempty"`
+	Epochs          int             `json:"epochs"`
+	AutoEpochs      bool            `json:"auto_epochs,omitempty"`
+	ExampleTokens   TokenStats      `json:"example_tokens"`
+	PromptTokens    TokenStats      `json:"prompt_tokens"`
+	AssistantTokens TokenStats      `json:"assistant_tokens"`
+	BilledPerEpoch  int             `json:"billed_tokens_per_epoch"`
+	TrainingTokens  int             `json:"training_tokens"`
+	TrainingCost    float64         `json:"training_cost"`
+	MissingSystem   int             `json:"missing_system,omitempty"`
+	MissingUser     int             `json:"missing_user,omitempty"`
+	OverLimit       []FinetuneIssue `json:"over_limit,omitempty"`
+	Errors          []FinetuneIssue `json:"errors,omitempty"`
+	Notes           []string        `json:"notes,omitempty"`
+}
+
+// TokenStats describes the distribution of a per-example token count.
+type TokenStats struct {
+	Total  int     `json:"total"`
+	Min    int     `json:"min"`
+	Max    int     `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median int     `json:"median"`
+	P90    int     `json:"p90"`
+}
+
+// FinetuneIssue identifies a training example that is invalid or exceeds
+// the model's example limit. Line is 1-based.
+type FinetuneIssue struct {
+	Line    int    `json:"line"`
+	Tokens  int    `json:"tokens,omitempty"`
+	Message string `json:"message"`
+}
+
+// CountFinetune validates a chat-format fine-tuning JSONL file and counts
+// the tokens of every example with the encoding of opts.Model. Each example
+// is counted like CountChatRequest; assistant tokens are the content and
+// tool calls of its assistant messages and prompt tokens are the rest.
+// Billed tokens cap every example at the model's training example limit,
+// since longer examples are truncated. Invalid lines are reported in Errors
+// and excluded from the statistics.
+func (c *Counter) CountFinetune(ctx context.Context, r io.Reader, opts FinetuneOptions) (*FinetuneResult, error) {
+	tok, encoding, meta := c.tokenizerForModel(opts.Model)
+	result := &FinetuneResult{
+		Model:    opts.Model,
+		Encoding: encoding,
+		IsExact:  tok.IsExact(),
+	}
+	if meta != nil {
+		result.ExampleLimit = meta.TrainingContextWindow
+		if result.ExampleLimit == 0 {
+			result.ExampleLimit = meta.ContextWindow
+		}
+	}
+
+	var totals, prompts, assistants []int
+	br := bufio.NewReader(r)
+	for lineNum := 1; ; lineNum++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		line, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("reading training line %d: %w", lineNum, err)
+		}
+		done := errors.Is(err, io.EOF)
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			total, assistant, err := c.countFinetuneExample(ctx, line, opts.Model, result)
+			if err != nil {
+				result.Errors = append(result.Errors, FinetuneIssue{Line: lineNum, Message: err.Error()})
+			} else {
+				totals = append(totals, total)
+				prompts = append(prompts, total-assistant)
+				assistants = append(assistants, assistant)
+
+				billed := total
+				if result.ExampleLimit > 0 && total > result.ExampleLimit {
+					billed = result.ExampleLimit
+					result.OverLimit = append(result.OverLimit, FinetuneIssue{
+						Line:    lineNum,
+						Tokens:  total,
+						Message: fmt.Sprintf("truncated to %d tokens", result.ExampleLimit),
+					})
+				}
+				result.BilledPerEpoch += billed
+			}
+		}
+		if done {
+			break
+		}
+	}
+
+	result.Examples = len(totals)
+	result.ExampleTokens = newTokenStats(totals)
+	result.PromptTokens = newTokenStats(prompts)
+	result.AssistantTokens = newTokenStats(assistants)
+
+	result.Epochs = opts.Epochs
+	if result.Epochs <= 0 {
+		result.Epochs = defaultFinetuneEpochs(result.Examples)
+		result.AutoEpochs = true
+	}
+	result.TrainingTokens = result.BilledPerEpoch * result.Epochs
+
+	switch {
+	case meta == nil:
+		result.Notes = append(result.Notes, "unknown model, training cost not estimated")
+	case meta.TrainingPricePer1M == 0:
+		result.Notes = append(result.Notes, fmt.Sprintf("%s has no tracked fine-tuning price", meta.Name))
+	default:
+		result.TrainingCost = float64(result.TrainingTokens) * meta.TrainingPricePer1M / 1_000_000.0
+	}
+	return result, nil
+}
+
+// countFinetuneExample validates one training example and returns its total
+// and assistant token counts. Missing system and user messages are tallied
+// on result as warnings.
+func (c *Counter) countFinetuneExample(ctx context.Context, line []byte, model string, result *FinetuneResult) (total, assistant int, err error) {
+	var raw struct {
+		Messages []map[string]json.RawMessage `json:"messages"`
+	}
+	if line[0] != '{' {
+		return 0, 0, fmt.Errorf("example must be a JSON object")
+	}
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return 0, 0, fmt.Errorf("invalid JSON: %v", err)
+	}
+	if len(raw.Messages) == 0 {
+		return 0, 0, fmt.Errorf("example has no messages")
+	}
+	if err := validateFinetuneMessages(raw.Messages); err != nil {
+		return 0, 0, err
+	}
+
+	req, err := ParseChatRequest(line)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	hasSystem, hasUser := false, false
+	for _, msg := range req.Messages {
+		hasSystem = hasSystem || isSystemRole(msg.Role)
+		hasUser = hasUser || msg.Role == "user"
+	}
+	if !hasSystem {
+		result.MissingSystem++
+	}
+	if !hasUser {
+		result.MissingUser++
+	}
+
+	counted, err := c.CountChatRequest(ctx, req, model)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, msg := range counted.Messages {
+		if msg.Role == "assistant" {
+			assistant += msg.ContentTokens
+		}
+	}
+	return counted.TotalTokens, assistant, nil
+}
+
+// validateFinetuneMessages checks the messages of a training example against
+// the chat fine-tuning format.
+func validateFinetuneMessages(messages []map[string]json.RawMessage) error {
+	hasAssistant := false
+	for i, msg := range messages {
+		for key := range msg {
+			if !finetuneMessageKeys[key] {
+				return fmt.Errorf("message %d has unrecognized key %q", i, key)
+			}
+		}
+
+		var role string
+		if err := json.Unmarshal(msg["role"], &role); err != nil || role == "" {
+			return fmt.Errorf("message %d has no role", i)
+		}
+		if !finetuneRoles[role] {
+			return fmt.Errorf("message %d has unrecognized role %q", i, role)
+		}
+		hasAssistant = hasAssistant || role == "assistant"
+
+		content, ok := msg["content"]
+		_, hasCalls := msg["tool_calls"]
+		_, hasFunction := msg["function_call"]
+		if (!ok || string(content) == "null") && !hasCalls && !hasFunction {
+			return fmt.Errorf("message %d has no content", i)
+		}
+
+		if weight, ok := msg["weight"]; ok {
+			if role != "assistant" {
+				return fmt.Errorf("message %d: weight is only allowed on assistant messages", i)
+			}
+			if w := strings.TrimSpace(string(weight)); w != "0" && w != "1" {
+				return fmt.Errorf("message %d: weight must be 0 or 1", i)
+			}
+		}
+	}
+	if !hasAssistant {
+		return fmt.Errorf("example has no assistant message")
+	}
+	return nil
+}
+
+// defaultFinetuneEpochs returns the number of epochs OpenAI trains for when
+// n_epochs is not set.
+func defaultFinetuneEpochs(examples int) int {
+	switch {
+	case examples == 0:
+		return finetuneTargetEpochs
+	case examples*finetuneTargetEpochs < finetuneMinTargetExamples:
+		return min(finetuneMaxDefaultEpochs, finetuneMinTargetExamples/examples)
+	case examples*finetuneTargetEpochs > finetuneMaxTargetExamples:
+		return max(1, finetuneMaxTargetExamples/examples)
+	default:
+		return finetuneTargetEpochs
+	}
+}
+
+// newTokenStats computes the distribution of a set of counts.
+func newTokenStats(counts []int) TokenStats {
+	if len(counts) == 0 {
+		return TokenStats{}
+	}
+
+	sorted := append([]int(nil), counts...)
+	sort.Ints(sorted)
+
+	stats := TokenStats{Min: sorted[0], Max: sorted[len(sorted)-1]}
+	for _, n := range sorted {
+		stats.Total += n
+	}
+	stats.Mean = float64(stats.Total) / float64(len(sorted))
+	stats.Median = percentile(sorted, 0.5)
+	stats.P90 = percentile(sorted, 0.9)
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted counts.
+func percentile(sorted []int, p float64) int {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
@@ -0,0 +1,145 @@
+package tokenizer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/lancekrogers/go-token-counter/tokenizer/fileops"
+)
+
+// Image detail levels accepted by OpenAI vision models.
+const (
+	ImageDetailAuto = "auto"
+	ImageDetailLow  = "low"
+	ImageDetailHigh = "high"
+)
+
+// OpenAI tile-based image pricing for GPT-4o class models.
+const (
+	openAIImageBaseTokens = 85  // flat cost, and the whole cost at low detail
+	openAIImageTileTokens = 170 // per 512px tile at high detail
+	openAIImageTileSize   = 512
+	openAIImageMaxSide    = 2048 // images are first scaled to fit this square
+	openAIImageShortSide  = 768  // then the shortest side is scaled to this
+
+	// openAIMaxImageTokens is the high-detail cost of the largest image,
+	// 768×2048 after scaling or 2×4 tiles, used when dimensions are unknown.
+	openAIMaxImageTokens = openAIImageBaseTokens + 8*openAIImageTileTokens
+)
+
+// Anthropic image pricing: tokens = width × height / 750 after resizing.
+const (
+	anthropicImagePixelsPerToken = 750
+	anthropicImageMaxEdge        = 1568
+)
+
+// OpenAIImageTokens estimates the input tokens of an image for OpenAI vision
+// models. Low detail costs a flat 85 tokens. High (and auto) detail scales the
+// image to fit 2048×2048, then to a 768px shortest side, and charges 170
+// tokens per 512px tile plus the 85 token base. Unknown dimensions are
+// charged as the largest image, 1,445 tokens.
+func OpenAIImageTokens(width, height int, detail string) int {
+	if detail == ImageDetailLow {
+		return openAIImageBaseTokens
+	}
+	if width <= 0 || height <= 0 {
+		return openAIMaxImageTokens
+	}
+
+	w, h := float64(width), float64(height)
+	if w > openAIImageMaxSide || h > openAIImageMaxSide {
+		scale := openAIImageMaxSide / math.Max(w, h)
+		w, h = math.Floor(w*scale), math.Floor(h*scale)
+	}
+	if shortest := math.Min(w, h); shortest > openAIImageShortSide {
+		scale := openAIImageShortSide / shortest
+		w, h = math.Floor(w*scale), math.Floor(h*scale)
+	}
+
+	tiles := int(math.Ceil(w/openAIImageTileSize) * math.Ceil(h/openAIImageTileSize))
+	return openAIImageBaseTokens + tiles*openAIImageTileTokens
+}
+
+// AnthropicImageTokens estimates the input tokens of an image for Claude
+// models as width × height / 750. Images whose long edge exceeds 1568px or
+// whose cost exceeds the ~1,600 token cap are first scaled down, preserving
+// aspect ratio.
+func AnthropicImageTokens(width, height int) int {
+	if width <= 0 || height <= 0 {
+		return anthropicMaxImageTokens
+	}
+
+	w, h := float64(width), float64(height)
+	if long := math.Max(w, h); long > anthropicImageMaxEdge {
+		scale := anthropicImageMaxEdge / long
+		w, h = w*scale, h*scale
+	}
+	if maxPixels := float64(anthropicMaxImageTokens * anthropicImagePixelsPerToken); w*h > maxPixels {
+		scale := math.Sqrt(maxPixels / (w * h))
+		w, h = w*scale, h*scale
+	}
+
+	return int(math.Ceil(w * h / anthropicImagePixelsPerToken))
+}
+
+// EstimateImage reads an image's dimensions and estimates its input tokens
+// for each vision provider allowed by the counter's provider filter.
+func (c *Counter) EstimateImage(path string) ([]MediaEstimate, error) {
+	info, err := fileops.ReadImageInfo(path)
+	if err != nil {
+		return nil, err
+	}
+
+	estimates := []MediaEstimate{}
+	if c.allowsProvider(ProviderOpenAI) {
+		estimates = append(estimates, MediaEstimate{
+			Path:     path,
+			Kind:     MediaKindImage,
+			Provider: ProviderOpenAI,
+			Width:    info.Width,
+			Height:   info.Height,
+			Detail:   c.imageDetail,
+			Tokens:   OpenAIImageTokens(info.Width, info.Height, c.imageDetail),
+		})
+	}
+	if c.allowsProvider(ProviderAnthropic) {
+		estimates = append(estimates, MediaEstimate{
+			Path:     path,
+			Kind:     MediaKindImage,
+			Provider: ProviderAnthropic,
+			Width:    info.Width,
+			Height:   info.Height,
+			Tokens:   AnthropicImageTokens(info.Width, info.Height),
+		})
+	}
+	return estimates, nil
+}
+
+// allowsProvider reports whether the counter's provider filter includes p.
+func (c *Counter) allowsProvider(p Provider) bool {
+	return c.provider == "" || c.provider == "all" || c.provider == p
+}
+
+// decodeDataURLImage returns the dimensions of a base64 "data:" URL image.
+func decodeDataURLImage(url string) (*fileops.ImageInfo, error) {
+	if !strings.HasPrefix(url, "data:") {
+		return nil, fmt.Errorf("not a data URL")
+	}
+	comma := strings.IndexByte(url, ',')
+	if comma < 0 || !strings.Contains(url[:comma], ";base64") {
+		return nil, fmt.Errorf("data URL is not base64 encoded")
+	}
+	return decodeBase64Image(url[comma+1:])
+}
+
+// decodeBase64Image returns the dimensions of base64-encoded image data.
+func decodeBase64Image(data string) (*fileops.ImageInfo, error) {
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("decoding base64 image: %w", err)
+	}
+	return fileops.DecodeImageInfo(bytes.NewReader(raw))
+}
@@ -0,0 +1,863 @@
+package jinja
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Render renders the template with the given variables. Values may be Go
+// strings, bools, numbers, nil, []any, []string, map[string]any and nested
+// combinations of those.
+func (t *Template) Render(vars map[string]any) (string, error) {
+	root := &frame{vars: make(map[string]any, len(globals)), calls: new(int)}
+	for k, v := range globals {
+		root.vars[k] = v
+	}
+	f := root.child()
+	for k, v := range vars {
+		f.vars[k] = fromGo(v)
+	}
+
+	var b strings.Builder
+	if _, err := execNodes(t.body, f, &b); err != nil {
+		return "", fmt.Errorf("jinja: %w", err)
+	}
+	return b.String(), nil
+}
+
+// undefined is the value of a missing variable, attribute or item.
+type undefined struct {
+	name string
+}
+
+// function is a callable value: a global, a macro or a bound method.
+type function func(args []any, kwargs map[string]any) (any, error)
+
+// dict is an insertion-ordered mapping with string keys. Namespaces are
+// dicts whose attributes can be assigned with {% set ns.attr = ... %}.
+type dict struct {
+	keys      []string
+	values    map[string]any
+	namespace bool
+}
+
+func newDict() *dict {
+	return &dict{values: make(map[string]any)}
+}
+
+func (d *dict) get(key string) (any, bool) {
+	v, ok := d.values[key]
+	return v, ok
+}
+
+func (d *dict) set(key string, v any) {
+	if _, ok := d.values[key]; !ok {
+		d.keys = append(d.keys, key)
+	}
+	d.values[key] = v
+}
+
+// fromGo converts a Go value into the template's value representation.
+func fromGo(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		d := newDict()
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			d.set(k, fromGo(v[k]))
+		}
+		return d
+	case []any:
+		out := make([]any, len(v))
+		for i, x := range v {
+			out[i] = fromGo(x)
+		}
+		return out
+	case []string:
+		out := make([]any, len(v))
+		for i, x := range v {
+			out[i] = x
+		}
+		return out
+	case []map[string]any:
+		out := make([]any, len(v))
+		for i, x := range v {
+			out[i] = fromGo(x)
+		}
+		return out
+	case int64:
+		return int(v)
+	case int32:
+		return int(v)
+	case float32:
+		return float64(v)
+	}
+	return v
+}
+
+// maxCallDepth bounds nested macro calls, so a self-calling macro fails
+// with an error instead of exhausting the stack.
+const maxCallDepth = 100
+
+// frame is a variable scope. For loops and macros run in child frames so
+// their assignments do not leak out. calls counts the macro calls in
+// progress and is shared by every frame of a render.
+type frame struct {
+	vars   map[string]any
+	parent *frame
+	calls  *int
+}
+
+func (f *frame) child() *frame {
+	return &frame{vars: make(map[string]any), parent: f, calls: f.calls}
+}
+
+func (f *frame) lookup(name string) (any, bool) {
+	for s := f; s != nil; s = s.parent {
+		if v, ok := s.vars[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// control signals loop control flow out of a block.
+type control int
+
+const (
+	ctrlNone control = iota
+	ctrlBreak
+	ctrlContinue
+)
+
+// node is an executable template node.
+type node interface {
+	exec(f *frame, w *strings.Builder) (control, error)
+}
+
+func execNodes(nodes []node, f *frame, w *strings.Builder) (control, error) {
+	for _, n := range nodes {
+		c, err := n.exec(f, w)
+		if err != nil || c != ctrlNone {
+			return c, err
+		}
+	}
+	return ctrlNone, nil
+}
+
+type textNode struct {
+	text string
+}
+
+func (n *textNode) exec(_ *frame, w *strings.Builder) (control, error) {
+	w.WriteString(n.text)
+	return ctrlNone, nil
+}
+
+type outputNode struct {
+	expr expr
+}
+
+func (n *outputNode) exec(f *frame, w *strings.Builder) (control, error) {
+	v, err := n.expr.eval(f)
+	if err != nil {
+		return ctrlNone, err
+	}
+	w.WriteString(toString(v))
+	return ctrlNone, nil
+}
+
+type groupNode struct {
+	body []node
+}
+
+func (n *groupNode) exec(f *frame, w *strings.Builder) (control, error) {
+	return execNodes(n.body, f, w)
+}
+
+type controlNode struct {
+	ctrl control
+}
+
+func (n *controlNode) exec(*frame, *strings.Builder) (control, error) {
+	return n.ctrl, nil
+}
+
+type ifNode struct {
+	conds    []expr
+	bodies   [][]node
+	elseBody []node
+}
+
+func (n *ifNode) exec(f *frame, w *strings.Builder) (control, error) {
+	for i, cond := range n.conds {
+		v, err := cond.eval(f)
+		if err != nil {
+			return ctrlNone, err
+		}
+		if truthy(v) {
+			return execNodes(n.bodies[i], f, w)
+		}
+	}
+	return execNodes(n.elseBody, f, w)
+}
+
+type forNode struct {
+	targets  []string
+	iter     expr
+	filter   expr
+	body     []node
+	elseBody []node
+}
+
+func (n *forNode) exec(f *frame, w *strings.Builder) (control, error) {
+	v, err := n.iter.eval(f)
+	if err != nil {
+		return ctrlNone, err
+	}
+	items, err := iterate(v)
+	if err != nil {
+		return ctrlNone, err
+	}
+
+	if n.filter != nil {
+		kept := items[:0:0]
+		for _, item := range items {
+			lf := f.child()
+			if err := n.bind(lf, item); err != nil {
+				return ctrlNone, err
+			}
+			ok, err := n.filter.eval(lf)
+			if err != nil {
+				return ctrlNone, err
+			}
+			if truthy(ok) {
+				kept = append(kept, item)
+			}
+		}
+		items = kept
+	}
+
+	if len(items) == 0 {
+		return execNodes(n.elseBody, f, w)
+	}
+
+	for i, item := range items {
+		lf := f.child()
+		if err := n.bind(lf, item); err != nil {
+			return ctrlNone, err
+		}
+		lf.vars["loop"] = loopVar(items, i)
+
+		c, err := execNodes(n.body, lf, w)
+		if err != nil {
+			return ctrlNone, err
+		}
+		if c == ctrlBreak {
+			break
+		}
+	}
+	return ctrlNone, nil
+}
+
+// bind assigns a loop item to the loop's targets, unpacking sequences when
+// there is more than one target.
+func (n *forNode) bind(f *frame, item any) error {
+	if len(n.targets) == 1 {
+		f.vars[n.targets[0]] = item
+		return nil
+	}
+	return unpack(f, n.targets, item)
+}
+
+// loopVar builds the special "loop" variable for iteration i.
+func loopVar(items []any, i int) *dict {
+	d := newDict()
+	d.set("index", i+1)
+	d.set("index0", i)
+	d.set("revindex", len(items)-i)
+	d.set("revindex0", len(items)-i-1)
+	d.set("first", i == 0)
+	d.set("last", i == len(items)-1)
+	d.set("length", len(items))
+	if i > 0 {
+		d.set("previtem", items[i-1])
+	}
+	if i < len(items)-1 {
+		d.set("nextitem", items[i+1])
+	}
+	d.set("cycle", function(func(args []any, _ map[string]any) (any, error) {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("loop.cycle requires at least one argument")
+		}
+		return args[i%len(args)], nil
+	}))
+	return d
+}
+
+func unpack(f *frame, names []string, v any) error {
+	items, err := iterate(v)
+	if err != nil {
+		return err
+	}
+	if len(items) != len(names) {
+		return fmt.Errorf("cannot unpack %d values into %d names", len(items), len(names))
+	}
+	for i, name := range names {
+		f.vars[name] = items[i]
+	}
+	return nil
+}
+
+type setNode struct {
+	names []string
+	attr  string
+	value expr
+	body  []node
+}
+
+func (n *setNode) exec(f *frame, _ *strings.Builder) (control, error) {
+	var v any
+	if n.value != nil {
+		var err error
+		if v, err = n.value.eval(f); err != nil {
+			return ctrlNone, err
+		}
+	} else {
+		var b strings.Builder
+		if _, err := execNodes(n.body, f, &b); err != nil {
+			return ctrlNone, err
+		}
+		v = b.String()
+	}
+
+	switch {
+	case n.attr != "":
+		target, _ := f.lookup(n.names[0])
+		ns, ok := target.(*dict)
+		if !ok || !ns.namespace {
+			return ctrlNone, fmt.Errorf("cannot assign attribute %q on non-namespace object %q", n.attr, n.names[0])
+		}
+		ns.set(n.attr, v)
+	case len(n.names) > 1:
+		if err := unpack(f, n.names, v); err != nil {
+			return ctrlNone, err
+		}
+	default:
+		f.vars[n.names[0]] = v
+	}
+	return ctrlNone, nil
+}
+
+type macroNode struct {
+	name     string
+	params   []string
+	defaults []expr
+	body     []node
+}
+
+func (n *macroNode) exec(f *frame, _ *strings.Builder) (control, error) {
+	f.vars[n.name] = function(func(args []any, kwargs map[string]any) (any, error) {
+		if len(args) > len(n.params) {
+			return nil, fmt.Errorf("macro %q takes %d arguments, got %d", n.name, len(n.params), len(args))
+		}
+		if *f.calls >= maxCallDepth {
+			return nil, fmt.Errorf("macro %q exceeds the maximum call depth of %d", n.name, maxCallDepth)
+		}
+		*f.calls++
+		defer func() { *f.calls-- }()
+		mf := f.child()
+		for i, param := range n.params {
+			v, ok := kwargs[param]
+			switch {
+			case i < len(args):
+				v = args[i]
+			case ok:
+			case n.defaults[i] != nil:
+				var err error
+				if v, err = n.defaults[i].eval(mf); err != nil {
+					return nil, err
+				}
+			default:
+				v = undefined{name: param}
+			}
+			mf.vars[param] = v
+		}
+		var b strings.Builder
+		if _, err := execNodes(n.body, mf, &b); err != nil {
+			return nil, err
+		}
+		return b.String(), nil
+	})
+	return ctrlNone, nil
+}
+
+// expr is an evaluable expression.
+type expr interface {
+	eval(f *frame) (any, error)
+}
+
+type literalExpr struct {
+	value any
+}
+
+func (e *literalExpr) eval(*frame) (any, error) { return e.value, nil }
+
+type nameExpr struct {
+	name string
+}
+
+func (e *nameExpr) eval(f *frame) (any, error) {
+	if v, ok := f.lookup(e.name); ok {
+		return v, nil
+	}
+	return undefined{name: e.name}, nil
+}
+
+type listExpr struct {
+	items []expr
+}
+
+func (e *listExpr) eval(f *frame) (any, error) {
+	out := make([]any, len(e.items))
+	for i, item := range e.items {
+		v, err := item.eval(f)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+type dictExpr struct {
+	keys   []expr
+	values []expr
+}
+
+func (e *dictExpr) eval(f *frame) (any, error) {
+	d := newDict()
+	for i := range e.keys {
+		k, err := e.keys[i].eval(f)
+		if err != nil {
+			return nil, err
+		}
+		v, err := e.values[i].eval(f)
+		if err != nil {
+			return nil, err
+		}
+		d.set(toString(k), v)
+	}
+	return d, nil
+}
+
+type attrExpr struct {
+	obj  expr
+	name string
+}
+
+func (e *attrExpr) eval(f *frame) (any, error) {
+	obj, err := e.obj.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	if m := method(obj, e.name); m != nil {
+		return m, nil
+	}
+	return getItem(obj, e.name), nil
+}
+
+type indexExpr struct {
+	obj   expr
+	index expr
+}
+
+func (e *indexExpr) eval(f *frame) (any, error) {
+	obj, err := e.obj.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := e.index.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	v := getItem(obj, idx)
+	if _, missing := v.(undefined); missing {
+		if name, ok := idx.(string); ok {
+			if m := method(obj, name); m != nil {
+				return m, nil
+			}
+		}
+	}
+	return v, nil
+}
+
+type sliceExpr struct {
+	obj               expr
+	start, stop, step expr
+}
+
+func (e *sliceExpr) eval(f *frame) (any, error) {
+	obj, err := e.obj.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	var bounds [3]*int
+	for i, part := range []expr{e.start, e.stop, e.step} {
+		if part == nil {
+			continue
+		}
+		v, err := part.eval(f)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		n, ok := v.(int)
+		if !ok {
+			return nil, fmt.Errorf("slice indices must be integers")
+		}
+		bounds[i] = &n
+	}
+	return slice(obj, bounds[0], bounds[1], bounds[2])
+}
+
+type callExpr struct {
+	fn      expr
+	args    []expr
+	kwnames []string
+	kwvals  []expr
+}
+
+func (e *callExpr) eval(f *frame) (any, error) {
+	fn, err := e.fn.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	args, kwargs, err := evalArgs(f, e.args, e.kwnames, e.kwvals)
+	if err != nil {
+		return nil, err
+	}
+	switch fn := fn.(type) {
+	case function:
+		return fn(args, kwargs)
+	case undefined:
+		return nil, fmt.Errorf("%q is undefined", fn.name)
+	}
+	return nil, fmt.Errorf("%s is not callable", typeName(fn))
+}
+
+func evalArgs(f *frame, argExprs []expr, kwnames []string, kwvals []expr) ([]any, map[string]any, error) {
+	args := make([]any, len(argExprs))
+	for i, a := range argExprs {
+		v, err := a.eval(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		args[i] = v
+	}
+	var kwargs map[string]any
+	if len(kwnames) > 0 {
+		kwargs = make(map[string]any, len(kwnames))
+		for i, name := range kwnames {
+			v, err := kwvals[i].eval(f)
+			if err != nil {
+				return nil, nil, err
+			}
+			kwargs[name] = v
+		}
+	}
+	return args, kwargs, nil
+}
+
+type filterExpr struct {
+	obj     expr
+	name    string
+	args    []expr
+	kwnames []string
+	kwvals  []expr
+}
+
+func (e *filterExpr) eval(f *frame) (any, error) {
+	fn, ok := filters[e.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown filter %q", e.name)
+	}
+	v, err := e.obj.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	args, kwargs, err := evalArgs(f, e.args, e.kwnames, e.kwvals)
+	if err != nil {
+		return nil, err
+	}
+	return fn(v, args, kwargs)
+}
+
+type testExpr struct {
+	obj    expr
+	name   string
+	args   []expr
+	negate bool
+}
+
+func (e *testExpr) eval(f *frame) (any, error) {
+	fn, ok := tests[e.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown test %q", e.name)
+	}
+	v, err := e.obj.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	args, _, err := evalArgs(f, e.args, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	ok, err = fn(v, args)
+	if err != nil {
+		return nil, err
+	}
+	return ok != e.negate, nil
+}
+
+type unaryExpr struct {
+	op string
+	x  expr
+}
+
+func (e *unaryExpr) eval(f *frame) (any, error) {
+	v, err := e.x.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	switch n := v.(type) {
+	case int:
+		if e.op == "-" {
+			return -n, nil
+		}
+		return n, nil
+	case float64:
+		if e.op == "-" {
+			return -n, nil
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("bad operand type for unary %s: %s", e.op, typeName(v))
+}
+
+type notExpr struct {
+	x expr
+}
+
+func (e *notExpr) eval(f *frame) (any, error) {
+	v, err := e.x.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(v), nil
+}
+
+// logicExpr is a short-circuiting "and" or "or" that, like Python, yields
+// one of its operands.
+type logicExpr struct {
+	and         bool
+	left, right expr
+}
+
+func (e *logicExpr) eval(f *frame) (any, error) {
+	l, err := e.left.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(l) != e.and {
+		return l, nil
+	}
+	return e.right.eval(f)
+}
+
+type condExpr struct {
+	cond, then, els expr
+}
+
+func (e *condExpr) eval(f *frame) (any, error) {
+	c, err := e.cond.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(c) {
+		return e.then.eval(f)
+	}
+	if e.els == nil {
+		return undefined{}, nil
+	}
+	return e.els.eval(f)
+}
+
+// compareExpr is a possibly chained comparison such as "a < b <= c".
+type compareExpr struct {
+	operands []expr
+	ops      []string
+}
+
+func (e *compareExpr) eval(f *frame) (any, error) {
+	left, err := e.operands[0].eval(f)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range e.ops {
+		right, err := e.operands[i+1].eval(f)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := compareOp(op, left, right)
+		if err != nil || !ok {
+			return false, err
+		}
+		left = right
+	}
+	return true, nil
+}
+
+func compareOp(op string, a, b any) (bool, error) {
+	switch op {
+	case "==":
+		return equal(a, b), nil
+	case "!=":
+		return !equal(a, b), nil
+	case "in":
+		return contains(b, a)
+	case "not in":
+		ok, err := contains(b, a)
+		return !ok, err
+	}
+	c, err := compare(a, b)
+	if err != nil {
+		return false, err
+	}
+	switch op {
+	case "<":
+		return c < 0, nil
+	case ">":
+		return c > 0, nil
+	case "<=":
+		return c <= 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+type binaryExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e *binaryExpr) eval(f *frame) (any, error) {
+	a, err := e.left.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	b, err := e.right.eval(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.op == "~" {
+		return toString(a) + toString(b), nil
+	}
+
+	switch e.op {
+	case "+":
+		if as, ok := a.(string); ok {
+			if bs, ok := b.(string); ok {
+				return as + bs, nil
+			}
+		}
+		if al, ok := a.([]any); ok {
+			if bl, ok := b.([]any); ok {
+				return append(append([]any{}, al...), bl...), nil
+			}
+		}
+	case "*":
+		if s, ok := a.(string); ok {
+			if n, ok := b.(int); ok {
+				return strings.Repeat(s, max(n, 0)), nil
+			}
+		}
+		if l, ok := a.([]any); ok {
+			if n, ok := b.(int); ok {
+				out := make([]any, 0, len(l)*max(n, 0))
+				for i := 0; i < n; i++ {
+					out = append(out, l...)
+				}
+				return out, nil
+			}
+		}
+	}
+
+	return arithmetic(e.op, a, b)
+}
+
+// arithmetic applies a numeric operator with Python's int/float semantics.
+func arithmetic(op string, a, b any) (any, error) {
+	ai, aInt := a.(int)
+	bi, bInt := b.(int)
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if !aok || !bok {
+		return nil, fmt.Errorf("unsupported operand types for %s: %s and %s", op, typeName(a), typeName(b))
+	}
+
+	if aInt && bInt {
+		switch op {
+		case "+":
+			return ai + bi, nil
+		case "-":
+			return ai - bi, nil
+		case "*":
+			return ai * bi, nil
+		case "//":
+			if bi == 0 {
+				return nil, fmt.Errorf("integer division by zero")
+			}
+			return int(math.Floor(float64(ai) / float64(bi))), nil
+		case "%":
+			if bi == 0 {
+				return nil, fmt.Errorf("integer modulo by zero")
+			}
+			return ((ai % bi) + bi) % bi, nil
+		}
+	}
+
+	switch op {
+	case "+":
+		return af + bf, nil
+	case "-":
+		return af - bf, nil
+	case "*":
+		return af * bf, nil
+	case "/":
+		if bf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return af / bf, nil
+	case "//":
+		if bf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Floor(af / bf), nil
+	case "%":
+		if bf == 0 {
+			return nil, fmt.Errorf("modulo by zero")
+		}
+		return af - bf*math.Floor(af/bf), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", op)
+}
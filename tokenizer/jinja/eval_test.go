@@ -0,0 +1,134 @@
+package jinja
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	vars := map[string]any{
+		"messages": []any{
+			map[string]any{"role": "system", "content": "Be brief."},
+			map[string]any{"role": "user", "content": "  Hi  "},
+			map[string]any{"role": "assistant", "content": "<think>plan</think>Hello"},
+		},
+		"tools": []map[string]any{{"name": "get_weather", "args": []string{"city"}}},
+		"n":     3,
+		"s":     "a,b,c",
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		// Statements.
+		{"if elif else", "{% if n > 5 %}big{% elif n > 2 %}mid{% else %}small{% endif %}", "mid"},
+		{"for with loop vars", "{% for m in messages %}{{ loop.index }}{{ m.role[0] }}{% if not loop.last %},{% endif %}{% endfor %}", "1s,2u,3a"},
+		{"for with filter", "{% for m in messages if m.role != 'system' %}{{ loop.index0 }}{{ m.role }} {% endfor %}", "0user 1assistant "},
+		{"for else", "{% for x in [] %}x{% else %}empty{% endfor %}", "empty"},
+		{"break and continue", "{% for i in range(10) %}{% if i == 1 %}{% continue %}{% endif %}{% if i == 4 %}{% break %}{% endif %}{{ i }}{% endfor %}", "023"},
+		{"unpacking dict items", "{% for k, v in {'a': 1, 'b': 2}.items() %}{{ k }}={{ v }};{% endfor %}", "a=1;b=2;"},
+		{"set does not leak from loops", "{% set x = 1 %}{% for i in [1, 2] %}{% set x = i %}{% endfor %}{{ x }}", "1"},
+		{"namespace carries loop state", "{% set ns = namespace(count=0) %}{% for m in messages %}{% set ns.count = ns.count + 1 %}{% endfor %}{{ ns.count }}", "3"},
+		{"block set", "{% set x %}{{ n }}!{% endset %}{{ x }}{{ x }}", "3!3!"},
+		{"macro with default", "{% macro tag(name, close=false) %}<{% if close %}/{% endif %}{{ name }}>{% endmacro %}{{ tag('b') }}{{ tag('b', close=true) }}", "<b></b>"},
+		{"generation renders its body", "{% generation %}reply{% endgeneration %}", "reply"},
+		{"trim_blocks and lstrip_blocks", "{% for i in [1, 2] %}\n  {% if i %}\n{{ i }}\n  {% endif %}\n{% endfor %}", "1\n2\n"},
+
+		// Expressions.
+		{"arithmetic", "{{ 7 // 2 }} {{ -7 // 2 }} {{ 7 % 3 }} {{ 7 / 2 }} {{ 2 * 3 + 1 }}", "3 -4 1 3.5 7"},
+		{"concatenation", "{{ 'n=' ~ n }} {{ 'a' + 'b' }} {{ [1] + [2] }}", "n=3 ab [1, 2]"},
+		{"comparison chain", "{{ 1 < n <= 3 }} {{ 'b' in s }} {{ 'x' not in s }}", "True True True"},
+		{"logic returns operands", "{{ none or 'fallback' }} {{ 0 and 1 }}", "fallback 0"},
+		{"conditional expression", "{{ 'yes' if n else 'no' }}{{ 'x' if false }}", "yes"},
+		{"reverse slice", "{% for m in messages[::-1] %}{{ m.role[0] }}{% endfor %}", "aus"},
+		{"negative index", "{{ messages[-1].role }} {{ s[1:4] }}", "assistant ,b,"},
+		{"undefined renders empty", "[{{ missing }}][{{ messages[0].missing }}]", "[][]"},
+		{"python repr", "{{ [1, 'a', none, true, 1.0] }} {{ {'k': 'v'} }}", "[1, 'a', None, True, 1.0] {'k': 'v'}"},
+
+		// Filters.
+		{"trim and length", "{{ messages[1].content | trim }}|{{ messages | length }}|{{ s | count }}", "Hi|3|5"},
+		{"case filters", "{{ 'hELLo' | upper }} {{ 'hELLo' | lower }} {{ 'hELLo' | capitalize }}", "HELLO hello Hello"},
+		{"default", "{{ missing | default('d') }} {{ '' | d('e', true) }} {{ n | default(0) }}", "d e 3"},
+		{"join", "{{ ['a', 'b'] | join(', ') }} {{ messages | join('/', attribute='role') }}", "a, b system/user/assistant"},
+		{"type conversion", "{{ '42' | int + 1 }} {{ 'x' | int }} {{ 5 | string ~ '!' }}", "43 0 5!"},
+		{"first last list", "{{ [1, 2, 3] | first }}{{ [1, 2, 3] | last }}{{ 'ab' | list }}", "13['a', 'b']"},
+		{"items filter", "{% for k, v in tools[0] | items %}{{ k }} {% endfor %}", "args name "},
+		{"replace and indent", "{{ 'a-b-c' | replace('-', '+') }}|{{ 'x\ny\n\nz' | indent(2) }}", "a+b+c|x\n  y\n\n  z"},
+		{"select and reject", "{{ [0, 1, '', 'a'] | select | list }} {{ [1, none] | reject('none') | list }}", "[1, 'a'] [1]"},
+		{"selectattr and map", "{{ messages | selectattr('role', 'equalto', 'user') | map(attribute='content') | map('trim') | join }}", "Hi"},
+		{"rejectattr", "{{ messages | rejectattr('role', 'in', ['system', 'user']) | map(attribute='role') | first }}", "assistant"},
+		{"tojson", `{{ tools | tojson }}`, `[{"args": ["city"], "name": "get_weather"}]`},
+		{"tojson indent and unicode", "{{ {'b': 'é\"', 'a': 1.5} | tojson(indent=2) }}", "{\n  \"b\": \"é\\\"\",\n  \"a\": 1.5\n}"},
+		{"safe", "{{ '<b>' | safe }}", "<b>"},
+
+		// Tests.
+		{"type tests", "{{ s is string }} {{ n is integer }} {{ n is number }} {{ tools is sequence }} {{ tools[0] is mapping }} {{ true is boolean }}", "True True True True True True"},
+		{"defined tests", "{{ n is defined }} {{ missing is undefined }} {{ none is none }} {{ n is not none }}", "True True True True"},
+		{"value tests", "{{ true is true }} {{ 0 is false }} {{ 'b' is in ['a', 'b'] }} {{ n is eq 3 }} {{ n is ne 3 }}", "True False True True False"},
+		{"iterable test", "{{ s is iterable }} {{ n is iterable }}", "True False"},
+
+		// Methods and globals.
+		{"string methods", "{{ '  x  '.strip() }}|{{ 'xxy'.lstrip('x') }}|{{ 'yxx'.rstrip('x') }}|{{ 'Ab'.upper() }}{{ 'Ab'.lower() }}", "x|y|y|ABab"},
+		{"startswith and endswith", "{{ s.startswith('a') }} {{ s.endswith(('x', 'c')) }}", "True True"},
+		{"split", "{{ s.split(',') }} {{ 'a b  c'.split() }} {{ s.split(',', 1) }}", "['a', 'b', 'c'] ['a', 'b', 'c'] ['a', 'b,c']"},
+		{"reasoning split", "{{ messages[-1].content.split('</think>')[-1].lstrip('\\n') }}", "Hello"},
+		{"replace and join methods", "{{ s.replace(',', ';') }} {{ '-'.join(['a', 'b']) }}", "a;b;c a-b"},
+		{"dict methods", "{{ tools[0].keys() | list }} {{ tools[0].get('name') }} {{ tools[0].get('x', 'd') }} {{ {'a': 1}.values() | list }}", "['args', 'name'] get_weather d [1]"},
+		{"range", "{{ range(3) | list }} {{ range(1, 7, 2) | list }} {{ range(3, 0, -1) | list }}", "[0, 1, 2] [1, 3, 5] [3, 2, 1]"},
+		{"strftime_now", "{{ strftime_now('%Y') | length }}", "4"},
+		{"recursive macro within depth", "{% macro count(i) %}{{ i }}{% if i > 0 %}{{ count(i - 1) }}{% endif %}{% endmacro %}{{ count(3) }}", "3210"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := Parse(tc.src)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			got, err := tmpl.Render(vars)
+			if err != nil {
+				t.Fatalf("Render error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Render(%q) = %q, want %q", tc.src, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"self-calling macro", "{% macro loop() %}{{ loop() }}{% endmacro %}{{ loop() }}", `macro "loop" exceeds the maximum call depth of 100`},
+		{"mutually recursive macros", "{% macro a() %}{{ b() }}{% endmacro %}{% macro b() %}{{ a() }}{% endmacro %}{{ a() }}", "exceeds the maximum call depth of 100"},
+		{"raise_exception", "{{ raise_exception('bad role') }}", "template error: bad role"},
+		{"unknown filter", "{{ [2, 1] | sort }}", `unknown filter "sort"`},
+		{"unknown test", "{{ 2 is even }}", `unknown test "even"`},
+		{"undefined call", "{{ nope() }}", `"nope" is undefined`},
+		{"not callable", "{{ 'x'() }}", "str is not callable"},
+		{"too many macro arguments", "{% macro m(a) %}{% endmacro %}{{ m(1, 2) }}", `macro "m" takes 1 arguments, got 2`},
+		{"assign to non-namespace", "{% set d = {} %}{% set d.x = 1 %}", "non-namespace object"},
+		{"unpack mismatch", "{% for a, b in [[1, 2, 3]] %}{% endfor %}", "cannot unpack 3 values into 2 names"},
+		{"division by zero", "{{ 1 // 0 }}", "integer division by zero"},
+		{"bad operands", "{{ 'a' - 1 }}", "unsupported operand types for -: str and int"},
+		{"not iterable", "{% for x in 3 %}{% endfor %}", "int is not iterable"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := Parse(tc.src)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			_, err = tmpl.Render(nil)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Render(%q) error = %v, want %q", tc.src, err, tc.want)
+			}
+		})
+	}
+}
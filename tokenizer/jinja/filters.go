@@ -0,0 +1,580 @@
+package jinja
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// filterFunc applies a filter to a value.
+type filterFunc func(v any, args []any, kwargs map[string]any) (any, error)
+
+// testFunc implements an "is" test.
+type testFunc func(v any, args []any) (bool, error)
+
+// filters is populated in init because the select and map filters refer
+// back to it.
+var filters map[string]filterFunc
+
+func init() {
+	filters = map[string]filterFunc{
+		"trim":       filterTrim,
+		"length":     filterLength,
+		"count":      filterLength,
+		"tojson":     filterToJSON,
+		"upper":      stringFilter(strings.ToUpper),
+		"lower":      stringFilter(strings.ToLower),
+		"capitalize": stringFilter(capitalize),
+		"default":    filterDefault,
+		"d":          filterDefault,
+		"join":       filterJoin,
+		"string":     func(v any, _ []any, _ map[string]any) (any, error) { return toString(v), nil },
+		"safe":       func(v any, _ []any, _ map[string]any) (any, error) { return v, nil },
+		"int":        filterInt,
+		"first":      filterFirst,
+		"last":       filterLast,
+		"list":       filterList,
+		"items":      filterItems,
+		"replace":    filterReplace,
+		"indent":     filterIndent,
+		"select":     selectFilter(false, false),
+		"reject":     selectFilter(true, false),
+		"selectattr": selectFilter(false, true),
+		"rejectattr": selectFilter(true, true),
+		"map":        filterMap,
+	}
+}
+
+// arg returns positional argument i, or keyword argument name, or def.
+func arg(args []any, kwargs map[string]any, i int, name string, def any) any {
+	if i < len(args) {
+		return args[i]
+	}
+	if v, ok := kwargs[name]; ok {
+		return v
+	}
+	return def
+}
+
+func stringFilter(fn func(string) string) filterFunc {
+	return func(v any, _ []any, _ map[string]any) (any, error) {
+		return fn(toString(v)), nil
+	}
+}
+
+func filterTrim(v any, args []any, kwargs map[string]any) (any, error) {
+	s := toString(v)
+	if chars := arg(args, kwargs, 0, "chars", nil); chars != nil {
+		return strings.Trim(s, toString(chars)), nil
+	}
+	return strings.TrimSpace(s), nil
+}
+
+func filterLength(v any, _ []any, _ map[string]any) (any, error) {
+	return length(v)
+}
+
+func filterDefault(v any, args []any, kwargs map[string]any) (any, error) {
+	def := arg(args, kwargs, 0, "default_value", "")
+	boolean := truthy(arg(args, kwargs, 1, "boolean", false))
+	if _, missing := v.(undefined); missing || (boolean && !truthy(v)) {
+		return def, nil
+	}
+	return v, nil
+}
+
+func filterJoin(v any, args []any, kwargs map[string]any) (any, error) {
+	items, err := iterate(v)
+	if err != nil {
+		return nil, err
+	}
+	sep := toString(arg(args, kwargs, 0, "d", ""))
+	attr := arg(args, kwargs, 1, "attribute", nil)
+	parts := make([]string, len(items))
+	for i, item := range items {
+		if attr != nil {
+			item = getItem(item, attr)
+		}
+		parts[i] = toString(item)
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func filterInt(v any, args []any, kwargs map[string]any) (any, error) {
+	def := arg(args, kwargs, 0, "default", 0)
+	switch v := v.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n, nil
+		}
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return int(f), nil
+		}
+	}
+	return def, nil
+}
+
+func filterFirst(v any, _ []any, _ map[string]any) (any, error) {
+	items, err := iterate(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return undefined{name: "first"}, nil
+	}
+	return items[0], nil
+}
+
+func filterLast(v any, _ []any, _ map[string]any) (any, error) {
+	items, err := iterate(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return undefined{name: "last"}, nil
+	}
+	return items[len(items)-1], nil
+}
+
+func filterList(v any, _ []any, _ map[string]any) (any, error) {
+	items, err := iterate(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]any{}, items...), nil
+}
+
+func filterItems(v any, _ []any, _ map[string]any) (any, error) {
+	if _, ok := v.(undefined); ok {
+		return []any{}, nil
+	}
+	d, ok := v.(*dict)
+	if !ok {
+		return nil, fmt.Errorf("items filter requires a mapping, got %s", typeName(v))
+	}
+	return dictMethod(d, "items")(nil, nil)
+}
+
+func filterReplace(v any, args []any, kwargs map[string]any) (any, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("replace filter expects 2 arguments")
+	}
+	n := -1
+	if c, ok := arg(args, kwargs, 2, "count", nil).(int); ok {
+		n = c
+	}
+	return strings.Replace(toString(v), toString(args[0]), toString(args[1]), n), nil
+}
+
+func filterIndent(v any, args []any, kwargs map[string]any) (any, error) {
+	width := arg(args, kwargs, 0, "width", 4)
+	first := truthy(arg(args, kwargs, 1, "first", false))
+	blank := truthy(arg(args, kwargs, 2, "blank", false))
+	pad := toString(width)
+	if n, ok := width.(int); ok {
+		pad = strings.Repeat(" ", n)
+	}
+
+	lines := strings.Split(toString(v), "\n")
+	for i, line := range lines {
+		if (i == 0 && !first) || (!blank && strings.TrimSpace(line) == "") {
+			continue
+		}
+		lines[i] = pad + line
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// selectFilter builds select, reject, selectattr and rejectattr.
+func selectFilter(reject, byAttr bool) filterFunc {
+	return func(v any, args []any, _ map[string]any) (any, error) {
+		items, err := iterate(v)
+		if err != nil {
+			return nil, err
+		}
+		var attr any
+		if byAttr {
+			if len(args) == 0 {
+				return nil, fmt.Errorf("selectattr requires an attribute name")
+			}
+			attr, args = args[0], args[1:]
+		}
+
+		test := func(x any) (bool, error) { return truthy(x), nil }
+		if len(args) > 0 {
+			fn, ok := tests[toString(args[0])]
+			if !ok {
+				return nil, fmt.Errorf("unknown test %q", toString(args[0]))
+			}
+			testArgs := args[1:]
+			test = func(x any) (bool, error) { return fn(x, testArgs) }
+		}
+
+		out := []any{}
+		for _, item := range items {
+			x := item
+			if byAttr {
+				x = getItem(item, attr)
+			}
+			ok, err := test(x)
+			if err != nil {
+				return nil, err
+			}
+			if ok != reject {
+				out = append(out, item)
+			}
+		}
+		return out, nil
+	}
+}
+
+func filterMap(v any, args []any, kwargs map[string]any) (any, error) {
+	items, err := iterate(v)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]any, len(items))
+	if attr, ok := kwargs["attribute"]; ok {
+		def, hasDefault := kwargs["default"]
+		for i, item := range items {
+			x := getItem(item, attr)
+			if _, missing := x.(undefined); missing && hasDefault {
+				x = def
+			}
+			out[i] = x
+		}
+		return out, nil
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("map requires a filter name or attribute")
+	}
+	fn, ok := filters[toString(args[0])]
+	if !ok {
+		return nil, fmt.Errorf("unknown filter %q", toString(args[0]))
+	}
+	for i, item := range items {
+		if out[i], err = fn(item, args[1:], nil); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// filterToJSON serializes a value like HuggingFace's tojson filter, which
+// calls json.dumps with ensure_ascii disabled.
+func filterToJSON(v any, args []any, kwargs map[string]any) (any, error) {
+	indent := -1
+	if n, ok := arg(args, kwargs, 0, "indent", nil).(int); ok {
+		indent = n
+	}
+	sortKeys := truthy(kwargs["sort_keys"])
+	var b strings.Builder
+	if err := writeJSON(&b, v, indent, 0, sortKeys); err != nil {
+		return nil, err
+	}
+	return b.String(), nil
+}
+
+// writeJSON writes v in Python json.dumps format: ", " and ": " separators
+// without indentation, "," and ": " with it.
+func writeJSON(b *strings.Builder, v any, indent, depth int, sortKeys bool) error {
+	newline := func(d int) {
+		if indent >= 0 {
+			b.WriteByte('\n')
+			b.WriteString(strings.Repeat(" ", indent*d))
+		}
+	}
+	sep := ", "
+	if indent >= 0 {
+		sep = ","
+	}
+
+	switch v := v.(type) {
+	case nil, undefined:
+		b.WriteString("null")
+	case bool:
+		if v {
+			b.WriteString("true")
+		} else {
+			b.WriteString("false")
+		}
+	case int:
+		b.WriteString(strconv.Itoa(v))
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			b.WriteString("NaN")
+		case math.IsInf(v, 1):
+			b.WriteString("Infinity")
+		case math.IsInf(v, -1):
+			b.WriteString("-Infinity")
+		default:
+			b.WriteString(formatFloat(v))
+		}
+	case string:
+		writeJSONString(b, v)
+	case []any:
+		if len(v) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		b.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				b.WriteString(sep)
+			}
+			newline(depth + 1)
+			if err := writeJSON(b, item, indent, depth+1, sortKeys); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		b.WriteByte(']')
+	case *dict:
+		if len(v.keys) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		keys := v.keys
+		if sortKeys {
+			keys = append([]string{}, keys...)
+			sort.Strings(keys)
+		}
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteString(sep)
+			}
+			newline(depth + 1)
+			writeJSONString(b, k)
+			b.WriteString(": ")
+			if err := writeJSON(b, v.values[k], indent, depth+1, sortKeys); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		b.WriteByte('}')
+	default:
+		return fmt.Errorf("object of type %s is not JSON serializable", typeName(v))
+	}
+	return nil
+}
+
+func writeJSONString(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
+
+// tests holds the "is" tests.
+var tests = map[string]testFunc{
+	"defined": func(v any, _ []any) (bool, error) {
+		_, missing := v.(undefined)
+		return !missing, nil
+	},
+	"undefined": func(v any, _ []any) (bool, error) {
+		_, missing := v.(undefined)
+		return missing, nil
+	},
+	"none": func(v any, _ []any) (bool, error) { return v == nil, nil },
+	"boolean": func(v any, _ []any) (bool, error) {
+		_, ok := v.(bool)
+		return ok, nil
+	},
+	"true":  func(v any, _ []any) (bool, error) { return v == true, nil },
+	"false": func(v any, _ []any) (bool, error) { return v == false, nil },
+	"integer": func(v any, _ []any) (bool, error) {
+		_, ok := v.(int)
+		return ok, nil
+	},
+	"number": func(v any, _ []any) (bool, error) {
+		switch v.(type) {
+		case int, float64:
+			return true, nil
+		}
+		return false, nil
+	},
+	"string": func(v any, _ []any) (bool, error) {
+		_, ok := v.(string)
+		return ok, nil
+	},
+	"mapping": func(v any, _ []any) (bool, error) {
+		_, ok := v.(*dict)
+		return ok, nil
+	},
+	"sequence": isIterable,
+	"iterable": isIterable,
+	"in":       func(v any, args []any) (bool, error) { return contains(firstArg(args), v) },
+	"eq":       compareTest("=="),
+	"equalto":  compareTest("=="),
+	"==":       compareTest("=="),
+	"ne":       compareTest("!="),
+	"!=":       compareTest("!="),
+}
+
+func firstArg(args []any) any {
+	if len(args) == 0 {
+		return undefined{}
+	}
+	return args[0]
+}
+
+func isIterable(v any, _ []any) (bool, error) {
+	switch v.(type) {
+	case string, []any, *dict:
+		return true, nil
+	}
+	return false, nil
+}
+
+func compareTest(op string) testFunc {
+	return func(v any, args []any) (bool, error) {
+		return compareOp(op, v, firstArg(args))
+	}
+}
+
+// globals are the functions available to every template, including the
+// helpers HuggingFace adds for chat templates.
+var globals = map[string]any{
+	"range":           function(globalRange),
+	"namespace":       function(globalNamespace),
+	"raise_exception": function(globalRaiseException),
+	"strftime_now":    function(globalStrftimeNow),
+}
+
+func globalRange(args []any, _ map[string]any) (any, error) {
+	ints := make([]int, len(args))
+	for i, a := range args {
+		n, ok := a.(int)
+		if !ok {
+			return nil, fmt.Errorf("range arguments must be integers")
+		}
+		ints[i] = n
+	}
+	start, stop, step := 0, 0, 1
+	switch len(ints) {
+	case 1:
+		stop = ints[0]
+	case 2:
+		start, stop = ints[0], ints[1]
+	case 3:
+		start, stop, step = ints[0], ints[1], ints[2]
+	default:
+		return nil, fmt.Errorf("range expected 1 to 3 arguments, got %d", len(args))
+	}
+	if step == 0 {
+		return nil, fmt.Errorf("range step cannot be zero")
+	}
+	out := []any{}
+	for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+		out = append(out, i)
+	}
+	return out, nil
+}
+
+func globalNamespace(_ []any, kwargs map[string]any) (any, error) {
+	ns := newDict()
+	ns.namespace = true
+	keys := make([]string, 0, len(kwargs))
+	for k := range kwargs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		ns.set(k, kwargs[k])
+	}
+	return ns, nil
+}
+
+func globalRaiseException(args []any, _ map[string]any) (any, error) {
+	return nil, fmt.Errorf("template error: %s", toString(firstArg(args)))
+}
+
+func globalStrftimeNow(args []any, _ map[string]any) (any, error) {
+	return strftime(time.Now(), toString(firstArg(args))), nil
+}
+
+// strftime formats t with the common C strftime directives.
+func strftime(t time.Time, format string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 >= len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'd':
+			fmt.Fprintf(&b, "%02d", t.Day())
+		case 'e':
+			fmt.Fprintf(&b, "%2d", t.Day())
+		case 'm':
+			fmt.Fprintf(&b, "%02d", int(t.Month()))
+		case 'Y':
+			fmt.Fprintf(&b, "%d", t.Year())
+		case 'y':
+			fmt.Fprintf(&b, "%02d", t.Year()%100)
+		case 'H':
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		case 'I':
+			fmt.Fprintf(&b, "%02d", (t.Hour()+11)%12+1)
+		case 'M':
+			fmt.Fprintf(&b, "%02d", t.Minute())
+		case 'S':
+			fmt.Fprintf(&b, "%02d", t.Second())
+		case 'p':
+			b.WriteString(t.Format("PM"))
+		case 'b', 'h':
+			b.WriteString(t.Format("Jan"))
+		case 'B':
+			b.WriteString(t.Format("January"))
+		case 'a':
+			b.WriteString(t.Format("Mon"))
+		case 'A':
+			b.WriteString(t.Format("Monday"))
+		case 'j':
+			fmt.Fprintf(&b, "%03d", t.YearDay())
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(format[i])
+		}
+	}
+	return b.String()
+}
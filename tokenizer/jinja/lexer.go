@@ -0,0 +1,354 @@
+// Package jinja implements the subset of the Jinja2 template language used by
+// HuggingFace chat templates.
+//
+// Templates are parsed with the environment HuggingFace uses for
+// chat_template strings: trim_blocks and lstrip_blocks are enabled, loop
+// controls (break and continue) are available, and rendering is sandboxed so
+// lists and dicts cannot be mutated. Supported tags are if/elif/else, for
+// (with loop filters and else), set (including namespace attributes and
+// block sets), macro, raw, break, continue and generation. Filters, tests,
+// globals and methods are limited to the ones chat templates rely on, and
+// nesting and macro call depth are bounded so a malformed template fails
+// with an error.
+package jinja
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// segmentKind identifies the kind of a template segment.
+type segmentKind int
+
+const (
+	segText      segmentKind = iota // literal template data
+	segOutput                       // {{ expression }}
+	segStatement                    // {% statement %}
+)
+
+// segment is a run of template data or the body of a single tag, with
+// delimiters and whitespace-control markers removed.
+type segment struct {
+	kind segmentKind
+	body string
+	line int
+}
+
+// endRaw matches the tag closing a {% raw %} block.
+var endRaw = regexp.MustCompile(`\{%[-+]?\s*endraw\s*-?%\}`)
+
+// splitTemplate splits a template into text, output and statement segments,
+// applying whitespace control, trim_blocks and lstrip_blocks.
+func splitTemplate(src string) ([]segment, error) {
+	var segs []segment
+	pos := 0
+	stripLeading := false // previous tag ended with "-": strip all leading whitespace
+	trimNewline := false  // previous tag was a block or comment: drop one newline
+
+	addText := func(start, end int, stripTrailing, lstrip bool) {
+		text := src[start:end]
+		if stripLeading {
+			text = strings.TrimLeft(text, " \t\r\n")
+		} else if trimNewline {
+			if strings.HasPrefix(text, "\r\n") {
+				text, start = text[2:], start+2
+			} else if strings.HasPrefix(text, "\n") {
+				text, start = text[1:], start+1
+			}
+		}
+		switch {
+		case stripTrailing:
+			text = strings.TrimRight(text, " \t\r\n")
+		case lstrip:
+			text = lstripBlock(src, start, text)
+		}
+		if text != "" {
+			segs = append(segs, segment{kind: segText, body: text, line: lineAt(src, end)})
+		}
+		stripLeading, trimNewline = false, false
+	}
+
+	for pos < len(src) {
+		open := nextTag(src, pos)
+		if open < 0 {
+			addText(pos, len(src), false, false)
+			break
+		}
+
+		kind := src[open+1]
+		bodyStart := open + 2
+		stripTrailing, keepSpace := false, false
+		if bodyStart < len(src) {
+			switch src[bodyStart] {
+			case '-':
+				stripTrailing = true
+				bodyStart++
+			case '+':
+				keepSpace = true
+				bodyStart++
+			}
+		}
+		addText(pos, open, stripTrailing, kind != '{' && !keepSpace)
+
+		line := lineAt(src, open)
+		var closeAt int
+		if kind == '#' {
+			closeAt = strings.Index(src[bodyStart:], "#}")
+			if closeAt < 0 {
+				return nil, fmt.Errorf("line %d: unclosed comment", line)
+			}
+			closeAt += bodyStart
+		} else {
+			end := "}}"
+			if kind == '%' {
+				end = "%}"
+			}
+			var err error
+			closeAt, err = findTagEnd(src, bodyStart, end)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+
+		body := src[bodyStart:closeAt]
+		if strings.HasSuffix(body, "-") {
+			body = body[:len(body)-1]
+			stripLeading = true
+		}
+		pos = closeAt + 2
+		trimNewline = kind != '{' && !stripLeading
+
+		switch kind {
+		case '{':
+			segs = append(segs, segment{kind: segOutput, body: strings.TrimSpace(body), line: line})
+		case '%':
+			if strings.TrimSpace(body) == "raw" {
+				loc := endRaw.FindStringIndex(src[pos:])
+				if loc == nil {
+					return nil, fmt.Errorf("line %d: unclosed raw block", line)
+				}
+				if raw := src[pos : pos+loc[0]]; raw != "" {
+					segs = append(segs, segment{kind: segText, body: raw, line: line})
+				}
+				pos += loc[1]
+				continue
+			}
+			segs = append(segs, segment{kind: segStatement, body: strings.TrimSpace(body), line: line})
+		}
+	}
+	return segs, nil
+}
+
+// nextTag returns the index of the next "{{", "{%" or "{#" at or after pos,
+// or -1 if there is none.
+func nextTag(src string, pos int) int {
+	for {
+		i := strings.IndexByte(src[pos:], '{')
+		if i < 0 || pos+i+1 >= len(src) {
+			return -1
+		}
+		i += pos
+		switch src[i+1] {
+		case '{', '%', '#':
+			return i
+		}
+		pos = i + 1
+	}
+}
+
+// findTagEnd returns the index of the closing delimiter of an output or
+// statement tag, skipping string literals and nested brackets.
+func findTagEnd(src string, pos int, end string) (int, error) {
+	depth := 0
+	for i := pos; i < len(src); i++ {
+		switch c := src[i]; c {
+		case '\'', '"':
+			j := i + 1
+			for j < len(src) && src[j] != c {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return 0, fmt.Errorf("unterminated string literal")
+			}
+			i = j
+		case '(', '[', '{':
+			depth++
+		case ')', ']':
+			depth--
+		case '}':
+			if depth == 0 && end == "}}" && strings.HasPrefix(src[i:], end) {
+				return i, nil
+			}
+			depth--
+		case '%':
+			if depth <= 0 && end == "%}" && strings.HasPrefix(src[i:], end) {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unclosed tag, expected %q", end)
+}
+
+// lstripBlock removes the spaces and tabs between the start of a line and a
+// block tag, as lstrip_blocks does. Text that starts mid-line is only
+// stripped when it contains a newline.
+func lstripBlock(src string, start int, text string) string {
+	nl := strings.LastIndexByte(text, '\n')
+	tail := text[nl+1:]
+	if strings.Trim(tail, " \t") != "" {
+		return text
+	}
+	if nl < 0 && start > 0 && src[start-1] != '\n' {
+		return text
+	}
+	return text[:nl+1]
+}
+
+// lineAt returns the 1-based line number of offset pos.
+func lineAt(src string, pos int) int {
+	return strings.Count(src[:pos], "\n") + 1
+}
+
+// tokenType identifies an expression token.
+type tokenType int
+
+const (
+	tokEOF tokenType = iota
+	tokName
+	tokString
+	tokInt
+	tokFloat
+	tokOp
+)
+
+// token is a single lexical token of an expression.
+type token struct {
+	typ tokenType
+	val string
+}
+
+// operators lists expression operators, longest first.
+var operators = []string{
+	"//", "==", "!=", "<=", ">=",
+	"+", "-", "*", "/", "%", "~", "<", ">", "=",
+	"(", ")", "[", "]", "{", "}", ",", ".", ":", "|",
+}
+
+// lexExpr splits the body of a tag into expression tokens.
+func lexExpr(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'' || c == '"':
+			s, n, err := lexString(src[i:])
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{tokString, s})
+			i += n
+		case isDigit(c):
+			j := i
+			typ := tokInt
+			for j < len(src) && (isDigit(src[j]) || src[j] == '_') {
+				j++
+			}
+			if j+1 < len(src) && src[j] == '.' && isDigit(src[j+1]) {
+				typ = tokFloat
+				j++
+				for j < len(src) && (isDigit(src[j]) || src[j] == '_') {
+					j++
+				}
+			}
+			if j < len(src) && (src[j] == 'e' || src[j] == 'E') {
+				k := j + 1
+				if k < len(src) && (src[k] == '+' || src[k] == '-') {
+					k++
+				}
+				if k < len(src) && isDigit(src[k]) {
+					typ = tokFloat
+					for j = k; j < len(src) && isDigit(src[j]); j++ {
+					}
+				}
+			}
+			toks = append(toks, token{typ, strings.ReplaceAll(src[i:j], "_", "")})
+			i = j
+		case isNameStart(c):
+			j := i + 1
+			for j < len(src) && (isNameStart(src[j]) || isDigit(src[j])) {
+				j++
+			}
+			toks = append(toks, token{tokName, src[i:j]})
+			i = j
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					toks = append(toks, token{tokOp, op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+		}
+	}
+	return append(toks, token{typ: tokEOF}), nil
+}
+
+// lexString decodes a quoted string literal at the start of src and returns
+// its value and length.
+func lexString(src string) (string, int, error) {
+	quote := src[0]
+	var b strings.Builder
+	for i := 1; i < len(src); i++ {
+		c := src[i]
+		if c == quote {
+			return b.String(), i + 1, nil
+		}
+		if c != '\\' || i+1 >= len(src) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch src[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '\\', '\'', '"':
+			b.WriteByte(src[i])
+		case 'u':
+			var r rune
+			if i+4 < len(src) {
+				if _, err := fmt.Sscanf(src[i+1:i+5], "%04x", &r); err == nil {
+					b.WriteRune(r)
+					i += 4
+					continue
+				}
+			}
+			b.WriteString(`\u`)
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(src[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string literal")
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,152 @@
+package jinja
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []segment
+	}{
+		{
+			name: "text only",
+			src:  "hello",
+			want: []segment{{segText, "hello", 1}},
+		},
+		{
+			name: "output",
+			src:  "a{{ x }}b",
+			want: []segment{{segText, "a", 1}, {segOutput, "x", 1}, {segText, "b", 1}},
+		},
+		{
+			name: "trim_blocks drops the newline after a block tag",
+			src:  "{% if x %}\nyes",
+			want: []segment{{segStatement, "if x", 1}, {segText, "yes", 2}},
+		},
+		{
+			name: "lstrip_blocks strips indentation before a block tag",
+			src:  "a\n  {% if x %}",
+			want: []segment{{segText, "a\n", 2}, {segStatement, "if x", 2}},
+		},
+		{
+			name: "output tags keep surrounding whitespace",
+			src:  "  {{ x }}\n",
+			want: []segment{{segText, "  ", 1}, {segOutput, "x", 1}, {segText, "\n", 2}},
+		},
+		{
+			name: "minus strips whitespace on both sides",
+			src:  "a \n{{- x -}}\n b",
+			want: []segment{{segText, "a", 2}, {segOutput, "x", 2}, {segText, "b", 3}},
+		},
+		{
+			name: "plus disables lstrip_blocks",
+			src:  "  {%+ if x %}",
+			want: []segment{{segText, "  ", 1}, {segStatement, "if x", 1}},
+		},
+		{
+			name: "comments are dropped",
+			src:  "a{# note #}\nb",
+			want: []segment{{segText, "a", 1}, {segText, "b", 2}},
+		},
+		{
+			name: "raw blocks are literal",
+			src:  "{% raw %}{{ x }}{% endraw %}",
+			want: []segment{{segText, "{{ x }}", 1}},
+		},
+		{
+			name: "closing delimiters inside strings and dicts",
+			src:  `{{ "}}" }}{{ {"a": 1} }}`,
+			want: []segment{{segOutput, `"}}"`, 1}, {segOutput, `{"a": 1}`, 1}},
+		},
+		{
+			name: "lone brace is text",
+			src:  "{ x }",
+			want: []segment{{segText, "{ x }", 1}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := splitTemplate(tc.src)
+			if err != nil {
+				t.Fatalf("splitTemplate(%q) error: %v", tc.src, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("splitTemplate(%q) = %+v, want %+v", tc.src, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSplitTemplateErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"{{ x ", "unclosed tag"},
+		{"{% if x ", "unclosed tag"},
+		{"{# note", "unclosed comment"},
+		{"{% raw %}x", "unclosed raw block"},
+		{`{{ "x }}`, "unterminated string literal"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.src, func(t *testing.T) {
+			_, err := splitTemplate(tc.src)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("splitTemplate(%q) error = %v, want %q", tc.src, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestLexExpr(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []token
+	}{
+		{"x", []token{{tokName, "x"}}},
+		{"loop.index0", []token{{tokName, "loop"}, {tokOp, "."}, {tokName, "index0"}}},
+		{"1_000 2.5 1e3", []token{{tokInt, "1000"}, {tokFloat, "2.5"}, {tokFloat, "1e3"}}},
+		{`'a\n' "b\"c" 'é'`, []token{{tokString, "a\n"}, {tokString, `b"c`}, {tokString, "é"}}},
+		{"a // b == c", []token{{tokName, "a"}, {tokOp, "//"}, {tokName, "b"}, {tokOp, "=="}, {tokName, "c"}}},
+		{"x[::-1]", []token{{tokName, "x"}, {tokOp, "["}, {tokOp, ":"}, {tokOp, ":"}, {tokOp, "-"}, {tokInt, "1"}, {tokOp, "]"}}},
+		{"s|trim ~ 'x'", []token{{tokName, "s"}, {tokOp, "|"}, {tokName, "trim"}, {tokOp, "~"}, {tokString, "x"}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.src, func(t *testing.T) {
+			got, err := lexExpr(tc.src)
+			if err != nil {
+				t.Fatalf("lexExpr(%q) error: %v", tc.src, err)
+			}
+			want := append(tc.want, token{typ: tokEOF})
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("lexExpr(%q) = %+v, want %+v", tc.src, got, want)
+			}
+		})
+	}
+}
+
+func TestLexExprErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"'abc", "unterminated string literal"},
+		{"a ? b", "unexpected character"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.src, func(t *testing.T) {
+			_, err := lexExpr(tc.src)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("lexExpr(%q) error = %v, want %q", tc.src, err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,890 @@
+package jinja
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Template is a parsed Jinja template.
+type Template struct {
+	body []node
+}
+
+// Parse parses a template source.
+func Parse(src string) (*Template, error) {
+	segs, err := splitTemplate(src)
+	if err != nil {
+		return nil, fmt.Errorf("jinja: %w", err)
+	}
+	p := &parser{segs: segs}
+	body, end, err := p.parseBody()
+	if err != nil {
+		return nil, fmt.Errorf("jinja: %w", err)
+	}
+	if end != "" {
+		return nil, fmt.Errorf("jinja: line %d: unexpected {%% %s %%}", p.line, end)
+	}
+	return &Template{body: body}, nil
+}
+
+// MustParse is like Parse but panics if the template cannot be parsed. It
+// simplifies initialization of built-in templates.
+func MustParse(src string) *Template {
+	t, err := Parse(src)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// maxNesting bounds how deeply blocks and expressions may nest, so a
+// pathological template fails to parse instead of exhausting the stack.
+const maxNesting = 100
+
+// parser builds a node tree from template segments. toks holds the tokens
+// of the statement or expression currently being parsed, and depth the
+// current nesting of blocks and expressions.
+type parser struct {
+	segs  []segment
+	pos   int
+	toks  []token
+	tpos  int
+	line  int
+	depth int
+}
+
+// parseBody parses nodes until one of the end tags is reached, returning the
+// tag that ended the body with the parser positioned after its keyword.
+// Without end tags it parses to the end of the template.
+func (p *parser) parseBody(ends ...string) ([]node, string, error) {
+	var nodes []node
+	for p.pos < len(p.segs) {
+		seg := p.segs[p.pos]
+		p.pos++
+		p.line = seg.line
+
+		switch seg.kind {
+		case segText:
+			nodes = append(nodes, &textNode{text: seg.body})
+
+		case segOutput:
+			if err := p.start(seg.body); err != nil {
+				return nil, "", err
+			}
+			e, err := p.parseTuple()
+			if err != nil {
+				return nil, "", err
+			}
+			if err := p.expectEnd(); err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, &outputNode{expr: e})
+
+		case segStatement:
+			if err := p.start(seg.body); err != nil {
+				return nil, "", err
+			}
+			kw := p.next()
+			if kw.typ != tokName {
+				return nil, "", p.errorf("expected statement name")
+			}
+			for _, end := range ends {
+				if kw.val == end {
+					return nodes, end, nil
+				}
+			}
+			n, err := p.parseStatement(kw.val)
+			if err != nil {
+				return nil, "", err
+			}
+			if n != nil {
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	if len(ends) > 0 {
+		return nil, "", p.errorf("unexpected end of template, expected {%% %s %%}", strings.Join(ends, " %} or {% "))
+	}
+	return nodes, "", nil
+}
+
+// parseStatement parses the statement introduced by keyword kw.
+func (p *parser) parseStatement(kw string) (node, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	switch kw {
+	case "if":
+		return p.parseIf()
+	case "for":
+		return p.parseFor()
+	case "set":
+		return p.parseSet()
+	case "macro":
+		return p.parseMacro()
+	case "break", "continue":
+		if err := p.expectEnd(); err != nil {
+			return nil, err
+		}
+		if kw == "break" {
+			return &controlNode{ctrl: ctrlBreak}, nil
+		}
+		return &controlNode{ctrl: ctrlContinue}, nil
+	case "generation":
+		// HuggingFace marks assistant output for training masks; it renders
+		// its body unchanged.
+		if err := p.expectEnd(); err != nil {
+			return nil, err
+		}
+		body, _, err := p.parseBody("endgeneration")
+		if err != nil {
+			return nil, err
+		}
+		return &groupNode{body: body}, nil
+	}
+	return nil, p.errorf("unsupported tag {%% %s %%}", kw)
+}
+
+func (p *parser) parseIf() (node, error) {
+	n := &ifNode{}
+	for {
+		cond, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectEnd(); err != nil {
+			return nil, err
+		}
+		body, end, err := p.parseBody("elif", "else", "endif")
+		if err != nil {
+			return nil, err
+		}
+		n.conds = append(n.conds, cond)
+		n.bodies = append(n.bodies, body)
+
+		switch end {
+		case "elif":
+			continue
+		case "else":
+			if err := p.expectEnd(); err != nil {
+				return nil, err
+			}
+			n.elseBody, _, err = p.parseBody("endif")
+			if err != nil {
+				return nil, err
+			}
+		}
+		return n, nil
+	}
+}
+
+func (p *parser) parseFor() (node, error) {
+	targets, err := p.parseTargets()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.next(); t.typ != tokName || t.val != "in" {
+		return nil, p.errorf("expected 'in' in for loop")
+	}
+	n := &forNode{targets: targets}
+	if n.iter, err = p.parseOr(); err != nil {
+		return nil, err
+	}
+	if p.acceptName("if") {
+		if n.filter, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek().typ == tokName && p.peek().val == "recursive" {
+		return nil, p.errorf("recursive loops are not supported")
+	}
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+
+	body, end, err := p.parseBody("else", "endfor")
+	if err != nil {
+		return nil, err
+	}
+	n.body = body
+	if end == "else" {
+		if err := p.expectEnd(); err != nil {
+			return nil, err
+		}
+		if n.elseBody, _, err = p.parseBody("endfor"); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func (p *parser) parseSet() (node, error) {
+	n := &setNode{}
+	name := p.next()
+	if name.typ != tokName {
+		return nil, p.errorf("expected variable name after set")
+	}
+	n.names = []string{name.val}
+	switch {
+	case p.acceptOp("."):
+		attr := p.next()
+		if attr.typ != tokName {
+			return nil, p.errorf("expected attribute name")
+		}
+		n.attr = attr.val
+	case p.peek().typ == tokOp && p.peek().val == ",":
+		p.tpos--
+		targets, err := p.parseTargets()
+		if err != nil {
+			return nil, err
+		}
+		n.names = targets
+	}
+
+	if p.acceptOp("=") {
+		value, err := p.parseTuple()
+		if err != nil {
+			return nil, err
+		}
+		n.value = value
+		return n, p.expectEnd()
+	}
+
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	body, _, err := p.parseBody("endset")
+	if err != nil {
+		return nil, err
+	}
+	n.body = body
+	return n, nil
+}
+
+func (p *parser) parseMacro() (node, error) {
+	name := p.next()
+	if name.typ != tokName {
+		return nil, p.errorf("expected macro name")
+	}
+	n := &macroNode{name: name.val}
+	if !p.acceptOp("(") {
+		return nil, p.errorf("expected '(' after macro name")
+	}
+	for !p.acceptOp(")") {
+		if len(n.params) > 0 && !p.acceptOp(",") {
+			return nil, p.errorf("expected ',' in macro parameters")
+		}
+		param := p.next()
+		if param.typ != tokName {
+			return nil, p.errorf("expected parameter name")
+		}
+		var def expr
+		if p.acceptOp("=") {
+			var err error
+			if def, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+		}
+		n.params = append(n.params, param.val)
+		n.defaults = append(n.defaults, def)
+	}
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	body, _, err := p.parseBody("endmacro")
+	if err != nil {
+		return nil, err
+	}
+	n.body = body
+	return n, nil
+}
+
+// parseTargets parses one or more comma-separated assignment targets,
+// optionally wrapped in parentheses.
+func (p *parser) parseTargets() ([]string, error) {
+	paren := p.acceptOp("(")
+	var names []string
+	for {
+		t := p.next()
+		if t.typ != tokName {
+			return nil, p.errorf("expected variable name")
+		}
+		names = append(names, t.val)
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	if paren && !p.acceptOp(")") {
+		return nil, p.errorf("expected ')'")
+	}
+	return names, nil
+}
+
+// parseTuple parses an expression, or a comma-separated list of expressions
+// as a tuple.
+func (p *parser) parseTuple() (expr, error) {
+	first, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOp(",") {
+		return first, nil
+	}
+	items := []expr{first}
+	for p.acceptOp(",") {
+		if p.atEnd() {
+			break
+		}
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+	}
+	return &listExpr{items: items}, nil
+}
+
+// parseExpr parses a full expression, including conditional expressions.
+func (p *parser) parseExpr() (expr, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptName("if") {
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		c := &condExpr{cond: cond, then: e}
+		if p.acceptName("else") {
+			if c.els, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+		}
+		e = c
+	}
+	return e, nil
+}
+
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptName("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicExpr{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptName("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicExpr{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.acceptName("not") {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{x: x}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (expr, error) {
+	first, err := p.parseMath1()
+	if err != nil {
+		return nil, err
+	}
+	c := &compareExpr{operands: []expr{first}}
+	for {
+		var op string
+		t := p.peek()
+		switch {
+		case t.typ == tokOp && (t.val == "==" || t.val == "!=" || t.val == "<" || t.val == ">" || t.val == "<=" || t.val == ">="):
+			op = t.val
+			p.tpos++
+		case t.typ == tokName && t.val == "in":
+			op = "in"
+			p.tpos++
+		case t.typ == tokName && t.val == "not" && p.peekAt(1).typ == tokName && p.peekAt(1).val == "in":
+			op = "not in"
+			p.tpos += 2
+		default:
+			if len(c.ops) == 0 {
+				return first, nil
+			}
+			return c, nil
+		}
+		operand, err := p.parseMath1()
+		if err != nil {
+			return nil, err
+		}
+		c.ops = append(c.ops, op)
+		c.operands = append(c.operands, operand)
+	}
+}
+
+func (p *parser) parseMath1() (expr, error) {
+	return p.parseBinary([]string{"+", "-"}, p.parseConcat)
+}
+
+func (p *parser) parseConcat() (expr, error) {
+	return p.parseBinary([]string{"~"}, p.parseMath2)
+}
+
+func (p *parser) parseMath2() (expr, error) {
+	return p.parseBinary([]string{"*", "/", "//", "%"}, p.parseUnary)
+}
+
+// parseBinary parses a left-associative chain of the given operators.
+func (p *parser) parseBinary(ops []string, operand func() (expr, error)) (expr, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		matched := ""
+		if t.typ == tokOp {
+			for _, op := range ops {
+				if t.val == op {
+					matched = op
+					break
+				}
+			}
+		}
+		if matched == "" {
+			return left, nil
+		}
+		p.tpos++
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: matched, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.isOp("-") || p.isOp("+") {
+		op := p.next().val
+		x, err := p.parseUnaryNoFilter()
+		if err != nil {
+			return nil, err
+		}
+		return p.parseFilters(&unaryExpr{op: op, x: x})
+	}
+	e, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if e, err = p.parsePostfix(e); err != nil {
+		return nil, err
+	}
+	return p.parseFilters(e)
+}
+
+// parseUnaryNoFilter parses the operand of a unary sign, which binds tighter
+// than filters.
+func (p *parser) parseUnaryNoFilter() (expr, error) {
+	if p.isOp("-") || p.isOp("+") {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		op := p.next().val
+		x, err := p.parseUnaryNoFilter()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: op, x: x}, nil
+	}
+	e, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return p.parsePostfix(e)
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.next()
+	switch t.typ {
+	case tokString:
+		s := t.val
+		// Adjacent string literals are concatenated.
+		for p.peek().typ == tokString {
+			s += p.next().val
+		}
+		return &literalExpr{value: s}, nil
+	case tokInt:
+		n, err := strconv.Atoi(t.val)
+		if err != nil {
+			return nil, p.errorf("invalid integer %q", t.val)
+		}
+		return &literalExpr{value: n}, nil
+	case tokFloat:
+		f, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", t.val)
+		}
+		return &literalExpr{value: f}, nil
+	case tokName:
+		switch t.val {
+		case "true", "True":
+			return &literalExpr{value: true}, nil
+		case "false", "False":
+			return &literalExpr{value: false}, nil
+		case "none", "None":
+			return &literalExpr{value: nil}, nil
+		}
+		return &nameExpr{name: t.val}, nil
+	case tokOp:
+		switch t.val {
+		case "(":
+			if p.acceptOp(")") {
+				return &listExpr{}, nil
+			}
+			e, err := p.parseTuple()
+			if err != nil {
+				return nil, err
+			}
+			if !p.acceptOp(")") {
+				return nil, p.errorf("expected ')'")
+			}
+			return e, nil
+		case "[":
+			l := &listExpr{}
+			for !p.acceptOp("]") {
+				if len(l.items) > 0 && !p.acceptOp(",") {
+					return nil, p.errorf("expected ',' in list")
+				}
+				if p.acceptOp("]") {
+					break
+				}
+				e, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				l.items = append(l.items, e)
+			}
+			return l, nil
+		case "{":
+			d := &dictExpr{}
+			for !p.acceptOp("}") {
+				if len(d.keys) > 0 && !p.acceptOp(",") {
+					return nil, p.errorf("expected ',' in dict")
+				}
+				if p.acceptOp("}") {
+					break
+				}
+				k, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				if !p.acceptOp(":") {
+					return nil, p.errorf("expected ':' in dict")
+				}
+				v, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				d.keys = append(d.keys, k)
+				d.values = append(d.values, v)
+			}
+			return d, nil
+		}
+	}
+	if t.typ == tokEOF {
+		return nil, p.errorf("unexpected end of expression")
+	}
+	return nil, p.errorf("unexpected %q", t.val)
+}
+
+// parsePostfix parses attribute access, subscripts, slices and calls.
+func (p *parser) parsePostfix(e expr) (expr, error) {
+	for {
+		switch {
+		case p.acceptOp("."):
+			t := p.next()
+			switch t.typ {
+			case tokName:
+				e = &attrExpr{obj: e, name: t.val}
+			case tokInt:
+				n, _ := strconv.Atoi(t.val)
+				e = &indexExpr{obj: e, index: &literalExpr{value: n}}
+			default:
+				return nil, p.errorf("expected attribute name after '.'")
+			}
+		case p.acceptOp("["):
+			sub, err := p.parseSubscript(e)
+			if err != nil {
+				return nil, err
+			}
+			e = sub
+		case p.isOp("("):
+			call, err := p.parseCall(e)
+			if err != nil {
+				return nil, err
+			}
+			e = call
+		default:
+			return e, nil
+		}
+	}
+}
+
+// parseSubscript parses an index or slice after '['.
+func (p *parser) parseSubscript(obj expr) (expr, error) {
+	var parts [3]expr
+	part, colons := 0, 0
+	for !p.acceptOp("]") {
+		if p.acceptOp(":") {
+			colons++
+			part++
+			if part > 2 {
+				return nil, p.errorf("invalid slice")
+			}
+			continue
+		}
+		if parts[part] != nil {
+			return nil, p.errorf("expected ']'")
+		}
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		parts[part] = e
+	}
+	if colons == 0 {
+		if parts[0] == nil {
+			return nil, p.errorf("empty subscript")
+		}
+		return &indexExpr{obj: obj, index: parts[0]}, nil
+	}
+	return &sliceExpr{obj: obj, start: parts[0], stop: parts[1], step: parts[2]}, nil
+}
+
+// parseArgs parses a parenthesized argument list with optional keyword
+// arguments.
+func (p *parser) parseArgs() ([]expr, []string, []expr, error) {
+	var args, kwvals []expr
+	var kwnames []string
+	if !p.acceptOp("(") {
+		return nil, nil, nil, p.errorf("expected '('")
+	}
+	for !p.acceptOp(")") {
+		if len(args)+len(kwvals) > 0 && !p.acceptOp(",") {
+			return nil, nil, nil, p.errorf("expected ',' in arguments")
+		}
+		if p.acceptOp(")") {
+			break
+		}
+		if p.peek().typ == tokName && p.peekAt(1).typ == tokOp && p.peekAt(1).val == "=" {
+			name := p.next().val
+			p.tpos++
+			v, err := p.parseExpr()
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			kwnames = append(kwnames, name)
+			kwvals = append(kwvals, v)
+			continue
+		}
+		if len(kwvals) > 0 {
+			return nil, nil, nil, p.errorf("positional argument follows keyword argument")
+		}
+		v, err := p.parseExpr()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		args = append(args, v)
+	}
+	return args, kwnames, kwvals, nil
+}
+
+func (p *parser) parseCall(fn expr) (expr, error) {
+	args, kwnames, kwvals, err := p.parseArgs()
+	if err != nil {
+		return nil, err
+	}
+	return &callExpr{fn: fn, args: args, kwnames: kwnames, kwvals: kwvals}, nil
+}
+
+// parseFilters parses trailing filters ("| name(args)"), tests
+// ("is [not] name args") and calls.
+func (p *parser) parseFilters(e expr) (expr, error) {
+	for {
+		switch {
+		case p.acceptOp("|"):
+			name, err := p.parseDottedName()
+			if err != nil {
+				return nil, err
+			}
+			f := &filterExpr{obj: e, name: name}
+			if p.isOp("(") {
+				if f.args, f.kwnames, f.kwvals, err = p.parseArgs(); err != nil {
+					return nil, err
+				}
+			}
+			e = f
+		case p.acceptName("is"):
+			t := &testExpr{obj: e, negate: p.acceptName("not")}
+			name, err := p.parseDottedName()
+			if err != nil {
+				return nil, err
+			}
+			t.name = name
+			switch {
+			case p.isOp("("):
+				if t.args, _, _, err = p.parseArgs(); err != nil {
+					return nil, err
+				}
+			case p.startsTestArg():
+				arg, err := p.parseUnaryNoFilter()
+				if err != nil {
+					return nil, err
+				}
+				t.args = []expr{arg}
+			}
+			e = t
+		case p.isOp("("):
+			call, err := p.parseCall(e)
+			if err != nil {
+				return nil, err
+			}
+			e = call
+		default:
+			return e, nil
+		}
+	}
+}
+
+// parseDottedName parses a filter or test name. Test names may be the
+// operator aliases "==" and "!=".
+func (p *parser) parseDottedName() (string, error) {
+	t := p.next()
+	if t.typ == tokName || (t.typ == tokOp && (t.val == "==" || t.val == "!=")) {
+		return t.val, nil
+	}
+	return "", p.errorf("expected filter or test name")
+}
+
+// startsTestArg reports whether the next token can begin an unparenthesized
+// test argument, as in "x is equalto 3".
+func (p *parser) startsTestArg() bool {
+	t := p.peek()
+	switch t.typ {
+	case tokString, tokInt, tokFloat:
+		return true
+	case tokName:
+		switch t.val {
+		case "and", "or", "not", "in", "is", "if", "else":
+			return false
+		}
+		return true
+	case tokOp:
+		return t.val == "[" || t.val == "{"
+	}
+	return false
+}
+
+// start begins parsing the body of a tag.
+func (p *parser) start(body string) error {
+	toks, err := lexExpr(body)
+	if err != nil {
+		return p.errorf("%v", err)
+	}
+	p.toks, p.tpos = toks, 0
+	return nil
+}
+
+func (p *parser) peek() token { return p.peekAt(0) }
+
+func (p *parser) peekAt(n int) token {
+	if p.tpos+n < len(p.toks) {
+		return p.toks[p.tpos+n]
+	}
+	return token{typ: tokEOF}
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	if p.tpos < len(p.toks) {
+		p.tpos++
+	}
+	return t
+}
+
+func (p *parser) atEnd() bool { return p.peek().typ == tokEOF }
+
+func (p *parser) isOp(op string) bool {
+	t := p.peek()
+	return t.typ == tokOp && t.val == op
+}
+
+func (p *parser) acceptOp(op string) bool {
+	if p.isOp(op) {
+		p.tpos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) acceptName(name string) bool {
+	t := p.peek()
+	if t.typ == tokName && t.val == name {
+		p.tpos++
+		return true
+	}
+	return false
+}
+
+// enter records one more level of nesting, failing past maxNesting. Each
+// successful call is paired with a deferred leave.
+func (p *parser) enter() error {
+	if p.depth >= maxNesting {
+		return p.errorf("nesting exceeds %d levels", maxNesting)
+	}
+	p.depth++
+	return nil
+}
+
+func (p *parser) leave() { p.depth-- }
+
+func (p *parser) expectEnd() error {
+	if t := p.peek(); t.typ != tokEOF {
+		return p.errorf("unexpected %q", t.val)
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,137 @@
+package jinja
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseExpr(t *testing.T) {
+	a, b, c := &nameExpr{name: "a"}, &nameExpr{name: "b"}, &nameExpr{name: "c"}
+	tests := []struct {
+		src  string
+		want expr
+	}{
+		{"a + b * c", &binaryExpr{op: "+", left: a, right: &binaryExpr{op: "*", left: b, right: c}}},
+		{"a ~ b + c", &binaryExpr{op: "+", left: &binaryExpr{op: "~", left: a, right: b}, right: c}},
+		{"not a or b", &logicExpr{and: false, left: &notExpr{x: a}, right: b}},
+		{"a and b or c", &logicExpr{and: false, left: &logicExpr{and: true, left: a, right: b}, right: c}},
+		{"a not in b", &compareExpr{operands: []expr{a, b}, ops: []string{"not in"}}},
+		{"a < b <= c", &compareExpr{operands: []expr{a, b, c}, ops: []string{"<", "<="}}},
+		{"a if b else c", &condExpr{cond: b, then: a, els: c}},
+		{"-a | b", &filterExpr{obj: &unaryExpr{op: "-", x: a}, name: "b"}},
+		{"a.b[0]", &indexExpr{obj: &attrExpr{obj: a, name: "b"}, index: &literalExpr{value: 0}}},
+		{"a[::-1]", &sliceExpr{obj: a, step: &unaryExpr{op: "-", x: &literalExpr{value: 1}}}},
+		{"a is not b", &testExpr{obj: a, name: "b", negate: true}},
+		{"a is equalto 'x'", &testExpr{obj: a, name: "equalto", args: []expr{&literalExpr{value: "x"}}}},
+		{"a(b, c=1)", &callExpr{fn: a, args: []expr{b}, kwnames: []string{"c"}, kwvals: []expr{&literalExpr{value: 1}}}},
+		{"'x' 'y'", &literalExpr{value: "xy"}},
+		{"[a, b,]", &listExpr{items: []expr{a, b}}},
+		{"{'k': a}", &dictExpr{keys: []expr{&literalExpr{value: "k"}}, values: []expr{a}}},
+		{"None", &literalExpr{value: nil}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.src, func(t *testing.T) {
+			tmpl, err := Parse("{{ " + tc.src + " }}")
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			want := []node{&outputNode{expr: tc.want}}
+			if !reflect.DeepEqual(tmpl.body, want) {
+				t.Errorf("Parse(%q) = %#v, want %#v", tc.src, tmpl.body[0].(*outputNode).expr, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseStatements(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []node
+	}{
+		{
+			src: "{% if a %}x{% elif b %}y{% else %}z{% endif %}",
+			want: []node{&ifNode{
+				conds:    []expr{&nameExpr{name: "a"}, &nameExpr{name: "b"}},
+				bodies:   [][]node{{&textNode{text: "x"}}, {&textNode{text: "y"}}},
+				elseBody: []node{&textNode{text: "z"}},
+			}},
+		},
+		{
+			src: "{% for k, v in d if v %}{% break %}{% else %}e{% endfor %}",
+			want: []node{&forNode{
+				targets:  []string{"k", "v"},
+				iter:     &nameExpr{name: "d"},
+				filter:   &nameExpr{name: "v"},
+				body:     []node{&controlNode{ctrl: ctrlBreak}},
+				elseBody: []node{&textNode{text: "e"}},
+			}},
+		},
+		{
+			src:  "{% set ns.found = true %}",
+			want: []node{&setNode{names: []string{"ns"}, attr: "found", value: &literalExpr{value: true}}},
+		},
+		{
+			src:  "{% set x %}body{% endset %}",
+			want: []node{&setNode{names: []string{"x"}, body: []node{&textNode{text: "body"}}}},
+		},
+		{
+			src: "{% macro m(a, b=1) %}{{ a }}{% endmacro %}",
+			want: []node{&macroNode{
+				name:     "m",
+				params:   []string{"a", "b"},
+				defaults: []expr{nil, &literalExpr{value: 1}},
+				body:     []node{&outputNode{expr: &nameExpr{name: "a"}}},
+			}},
+		},
+		{
+			src:  "{% generation %}g{% endgeneration %}",
+			want: []node{&groupNode{body: []node{&textNode{text: "g"}}}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.src, func(t *testing.T) {
+			tmpl, err := Parse(tc.src)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			if !reflect.DeepEqual(tmpl.body, tc.want) {
+				t.Errorf("Parse(%q) = %#v, want %#v", tc.src, tmpl.body, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"missing endif", "{% if a %}x", "expected {% elif %} or {% else %} or {% endif %}"},
+		{"stray end tag", "{% endfor %}", "unsupported tag {% endfor %}"},
+		{"unknown tag", "{% include 'x' %}", "unsupported tag {% include %}"},
+		{"recursive loop", "{% for x in y recursive %}{% endfor %}", "recursive loops are not supported"},
+		{"missing in", "{% for x y %}{% endfor %}", "expected 'in'"},
+		{"trailing tokens", "{{ a b }}", `unexpected "b"`},
+		{"empty expression", "{{ }}", "unexpected end of expression"},
+		{"power operator", "{{ a ** b }}", `unexpected "*"`},
+		{"keyword before positional", "{{ f(a=1, b) }}", "positional argument follows keyword argument"},
+		{"empty subscript", "{{ a[] }}", "empty subscript"},
+		{"error line", "x\n\n{{ ) }}", "line 3:"},
+		{"nested parentheses", "{{ " + strings.Repeat("(", 200) + "1" + strings.Repeat(")", 200) + " }}", "nesting exceeds 100 levels"},
+		{"nested blocks", strings.Repeat("{% if a %}", 200) + strings.Repeat("{% endif %}", 200), "nesting exceeds 100 levels"},
+		{"chained not", "{{ " + strings.Repeat("not ", 200) + "a }}", "nesting exceeds 100 levels"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.src)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Parse(%q) error = %v, want %q", tc.src, err, tc.want)
+			}
+		})
+	}
+}
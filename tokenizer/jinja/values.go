@@ -0,0 +1,574 @@
+package jinja
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// truthy reports whether a value is true in a boolean context.
+func truthy(v any) bool {
+	switch v := v.(type) {
+	case nil, undefined:
+		return false
+	case bool:
+		return v
+	case int:
+		return v != 0
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []any:
+		return len(v) > 0
+	case *dict:
+		return len(v.keys) > 0
+	}
+	return true
+}
+
+// toString converts a value to a string the way Python's str does.
+// Undefined values render as the empty string.
+func toString(v any) string {
+	switch v := v.(type) {
+	case undefined:
+		return ""
+	case string:
+		return v
+	}
+	return repr(v)
+}
+
+// repr formats a value the way Python's repr does.
+func repr(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "None"
+	case undefined:
+		return ""
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return formatFloat(v)
+	case string:
+		return quotePython(v)
+	case []any:
+		parts := make([]string, len(v))
+		for i, x := range v {
+			parts[i] = repr(x)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case *dict:
+		parts := make([]string, len(v.keys))
+		for i, k := range v.keys {
+			parts[i] = quotePython(k) + ": " + repr(v.values[k])
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case function:
+		return "<function>"
+	}
+	return fmt.Sprint(v)
+}
+
+// formatFloat formats a float like Python's repr.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1e16 {
+		return strconv.FormatFloat(f, 'f', 1, 64)
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if strings.Contains(s, "e") && !strings.Contains(s, "e-") && !strings.Contains(s, "e+") {
+		s = strings.Replace(s, "e", "e+", 1)
+	}
+	return s
+}
+
+// quotePython quotes a string the way Python's repr does, preferring single
+// quotes.
+func quotePython(s string) string {
+	quote := byte('\'')
+	if strings.Contains(s, "'") && !strings.Contains(s, `"`) {
+		quote = '"'
+	}
+	var b strings.Builder
+	b.WriteByte(quote)
+	for _, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == rune(quote):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte(quote)
+	return b.String()
+}
+
+// typeName returns the Python type name of a value for error messages.
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "NoneType"
+	case undefined:
+		return "Undefined"
+	case bool:
+		return "bool"
+	case int:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "str"
+	case []any:
+		return "list"
+	case *dict:
+		return "dict"
+	case function:
+		return "function"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// toFloat converts a numeric value to float64.
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// equal compares values with Python equality semantics.
+func equal(a, b any) bool {
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			return af == bf
+		}
+		return false
+	}
+	switch a := a.(type) {
+	case undefined:
+		_, ok := b.(undefined)
+		return ok
+	case []any:
+		bl, ok := b.([]any)
+		if !ok || len(a) != len(bl) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], bl[i]) {
+				return false
+			}
+		}
+		return true
+	case *dict:
+		bd, ok := b.(*dict)
+		if !ok || len(a.keys) != len(bd.keys) {
+			return false
+		}
+		for _, k := range a.keys {
+			bv, ok := bd.values[k]
+			if !ok || !equal(a.values[k], bv) {
+				return false
+			}
+		}
+		return true
+	case function:
+		return false
+	}
+	if _, ok := b.(function); ok {
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compare orders two numbers or two strings.
+func compare(a, b any) (int, error) {
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			switch {
+			case af < bf:
+				return -1, nil
+			case af > bf:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	if as, ok := a.(string); ok {
+		if bs, ok := b.(string); ok {
+			return strings.Compare(as, bs), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %s and %s", typeName(a), typeName(b))
+}
+
+// contains implements the "in" operator: substring, list membership or
+// dict key.
+func contains(container, item any) (bool, error) {
+	switch c := container.(type) {
+	case string:
+		s, ok := item.(string)
+		if !ok {
+			return false, fmt.Errorf("'in <string>' requires string as left operand, not %s", typeName(item))
+		}
+		return strings.Contains(c, s), nil
+	case []any:
+		for _, x := range c {
+			if equal(x, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case *dict:
+		s, ok := item.(string)
+		if !ok {
+			return false, nil
+		}
+		_, found := c.values[s]
+		return found, nil
+	case undefined:
+		return false, nil
+	}
+	return false, fmt.Errorf("argument of type %s is not iterable", typeName(container))
+}
+
+// iterate returns the items of an iterable value: list elements, dict keys
+// or string characters. Undefined values iterate as empty.
+func iterate(v any) ([]any, error) {
+	switch v := v.(type) {
+	case []any:
+		return v, nil
+	case *dict:
+		out := make([]any, len(v.keys))
+		for i, k := range v.keys {
+			out[i] = k
+		}
+		return out, nil
+	case string:
+		out := make([]any, 0, len(v))
+		for _, r := range v {
+			out = append(out, string(r))
+		}
+		return out, nil
+	case undefined:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%s is not iterable", typeName(v))
+}
+
+// length returns the length of a string, list or dict.
+func length(v any) (int, error) {
+	switch v := v.(type) {
+	case string:
+		return len([]rune(v)), nil
+	case []any:
+		return len(v), nil
+	case *dict:
+		return len(v.keys), nil
+	case undefined:
+		return 0, nil
+	}
+	return 0, fmt.Errorf("object of type %s has no len()", typeName(v))
+}
+
+// getItem looks up a dict key or sequence index, returning undefined when
+// it is missing.
+func getItem(obj, key any) any {
+	switch o := obj.(type) {
+	case *dict:
+		if k, ok := key.(string); ok {
+			if v, ok := o.values[k]; ok {
+				return v
+			}
+		}
+	case []any:
+		if i, ok := key.(int); ok {
+			if i < 0 {
+				i += len(o)
+			}
+			if i >= 0 && i < len(o) {
+				return o[i]
+			}
+		}
+	case string:
+		if i, ok := key.(int); ok {
+			runes := []rune(o)
+			if i < 0 {
+				i += len(runes)
+			}
+			if i >= 0 && i < len(runes) {
+				return string(runes[i])
+			}
+		}
+	}
+	return undefined{name: toString(key)}
+}
+
+// slice applies Python slice semantics to a list or string.
+func slice(obj any, start, stop, step *int) (any, error) {
+	var n int
+	var runes []rune
+	isString := false
+	switch o := obj.(type) {
+	case []any:
+		n = len(o)
+	case string:
+		runes = []rune(o)
+		n = len(runes)
+		isString = true
+	case undefined:
+		return o, nil
+	default:
+		return nil, fmt.Errorf("%s is not subscriptable", typeName(obj))
+	}
+
+	st := 1
+	if step != nil {
+		st = *step
+	}
+	if st == 0 {
+		return nil, fmt.Errorf("slice step cannot be zero")
+	}
+
+	clamp := func(p *int, def int) int {
+		if p == nil {
+			return def
+		}
+		i := *p
+		if i < 0 {
+			i += n
+		}
+		if st > 0 {
+			return min(max(i, 0), n)
+		}
+		return min(max(i, -1), n-1)
+	}
+
+	var lo, hi int
+	if st > 0 {
+		lo, hi = clamp(start, 0), clamp(stop, n)
+	} else {
+		lo, hi = clamp(start, n-1), clamp(stop, -1)
+	}
+
+	var idx []int
+	for i := lo; (st > 0 && i < hi) || (st < 0 && i > hi); i += st {
+		idx = append(idx, i)
+	}
+
+	if isString {
+		out := make([]rune, len(idx))
+		for j, i := range idx {
+			out[j] = runes[i]
+		}
+		return string(out), nil
+	}
+	list := obj.([]any)
+	out := make([]any, len(idx))
+	for j, i := range idx {
+		out[j] = list[i]
+	}
+	return out, nil
+}
+
+// method returns the bound method name of a string or dict, or nil if the
+// value has no such method.
+func method(obj any, name string) function {
+	switch o := obj.(type) {
+	case string:
+		return stringMethod(o, name)
+	case *dict:
+		if o.namespace {
+			return nil
+		}
+		return dictMethod(o, name)
+	}
+	return nil
+}
+
+func dictMethod(d *dict, name string) function {
+	switch name {
+	case "items":
+		return func([]any, map[string]any) (any, error) {
+			out := make([]any, len(d.keys))
+			for i, k := range d.keys {
+				out[i] = []any{k, d.values[k]}
+			}
+			return out, nil
+		}
+	case "keys":
+		return func([]any, map[string]any) (any, error) {
+			out := make([]any, len(d.keys))
+			for i, k := range d.keys {
+				out[i] = k
+			}
+			return out, nil
+		}
+	case "values":
+		return func([]any, map[string]any) (any, error) {
+			out := make([]any, len(d.keys))
+			for i, k := range d.keys {
+				out[i] = d.values[k]
+			}
+			return out, nil
+		}
+	case "get":
+		return func(args []any, _ map[string]any) (any, error) {
+			if len(args) == 0 {
+				return nil, fmt.Errorf("get expected at least 1 argument")
+			}
+			if k, ok := args[0].(string); ok {
+				if v, ok := d.values[k]; ok {
+					return v, nil
+				}
+			}
+			if len(args) > 1 {
+				return args[1], nil
+			}
+			return nil, nil
+		}
+	}
+	return nil
+}
+
+func stringMethod(s, name string) function {
+	switch name {
+	case "strip", "lstrip", "rstrip":
+		return func(args []any, _ map[string]any) (any, error) {
+			return stripString(s, name, args), nil
+		}
+	case "upper":
+		return func([]any, map[string]any) (any, error) { return strings.ToUpper(s), nil }
+	case "lower":
+		return func([]any, map[string]any) (any, error) { return strings.ToLower(s), nil }
+	case "startswith", "endswith":
+		return func(args []any, _ map[string]any) (any, error) {
+			if len(args) == 0 {
+				return nil, fmt.Errorf("%s expected 1 argument", name)
+			}
+			affixes := []any{args[0]}
+			if list, ok := args[0].([]any); ok {
+				affixes = list
+			}
+			for _, a := range affixes {
+				affix := toString(a)
+				if (name == "startswith" && strings.HasPrefix(s, affix)) || (name == "endswith" && strings.HasSuffix(s, affix)) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+	case "split":
+		return func(args []any, _ map[string]any) (any, error) {
+			var parts []string
+			switch {
+			case len(args) == 0 || args[0] == nil:
+				parts = strings.Fields(s)
+			case len(args) > 1:
+				n, _ := args[1].(int)
+				if n < 0 {
+					parts = strings.Split(s, toString(args[0]))
+				} else {
+					parts = strings.SplitN(s, toString(args[0]), n+1)
+				}
+			default:
+				parts = strings.Split(s, toString(args[0]))
+			}
+			out := make([]any, len(parts))
+			for i, p := range parts {
+				out[i] = p
+			}
+			return out, nil
+		}
+	case "replace":
+		return func(args []any, _ map[string]any) (any, error) {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("replace expected 2 arguments")
+			}
+			n := -1
+			if len(args) > 2 {
+				n, _ = args[2].(int)
+			}
+			return strings.Replace(s, toString(args[0]), toString(args[1]), n), nil
+		}
+	case "join":
+		return func(args []any, _ map[string]any) (any, error) {
+			if len(args) == 0 {
+				return nil, fmt.Errorf("join expected 1 argument")
+			}
+			items, err := iterate(args[0])
+			if err != nil {
+				return nil, err
+			}
+			parts := make([]string, len(items))
+			for i, item := range items {
+				parts[i] = toString(item)
+			}
+			return strings.Join(parts, s), nil
+		}
+	}
+	return nil
+}
+
+// stripString implements str.strip, lstrip and rstrip.
+func stripString(s, name string, args []any) string {
+	if len(args) > 0 && args[0] != nil {
+		chars := toString(args[0])
+		switch name {
+		case "lstrip":
+			return strings.TrimLeft(s, chars)
+		case "rstrip":
+			return strings.TrimRight(s, chars)
+		}
+		return strings.Trim(s, chars)
+	}
+	switch name {
+	case "lstrip":
+		return strings.TrimLeftFunc(s, unicode.IsSpace)
+	case "rstrip":
+		return strings.TrimRightFunc(s, unicode.IsSpace)
+	}
+	return strings.TrimSpace(s)
+}
+
+func capitalize(s string) string {
+	runes := []rune(strings.ToLower(s))
+	if len(runes) > 0 {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+	return string(runes)
+}
@@ -1,6 +1,9 @@
 package tokenizer
 
-import "sort"
+import (
+	"sort"
+	"time"
+)
 
 // Provider represents an LLM provider.
 type Provider string
@@ -15,6 +18,35 @@ const (
 	ProviderGoogle    Provider = "google"    // Google (Gemma)
 )
 
+// Modality is a kind of input a model accepts.
+type Modality string
+
+const (
+	ModalityText  Modality = "text"
+	ModalityImage Modality = "image"
+	ModalityAudio Modality = "audio"
+	ModalityPDF   Modality = "pdf" // PDF documents as file inputs
+)
+
+// ModelStatus is where a model is in its provider's lifecycle.
+type ModelStatus string
+
+const (
+	ModelStatusActive     ModelStatus = "active"     // generally available
+	ModelStatusDeprecated ModelStatus = "deprecated" // still served, with a retirement date announced
+	ModelStatusRetired    ModelStatus = "retired"    // no longer served by the provider
+)
+
+// ServiceTier is a provider processing tier with its own pricing.
+type ServiceTier string
+
+const (
+	ServiceTierStandard ServiceTier = "standard" // Synchronous requests at list prices
+	ServiceTierBatch    ServiceTier = "batch"    // Asynchronous batch jobs (OpenAI Batch API, Anthropic Message Batches)
+	ServiceTierFlex     ServiceTier = "flex"     // OpenAI flex processing: slower, discounted
+	ServiceTierPriority ServiceTier = "priority" // OpenAI priority processing: faster, premium
+)
+
 // ModelMetadata contains comprehensive information about an LLM model.
 type ModelMetadata struct {
 	Name          string   // Model identifier (e.g., "gpt-4o", "claude-sonnet-4.6")
@@ -22,6 +54,36 @@ type ModelMetadata struct {
 	Encoding      string   // BPE encoding name (e.g., "o200k_base", "cl100k_base")
 	ContextWindow int      // Maximum context window size in tokens
 
+	// MaxOutputTokens is the most tokens a single response can contain.
+	// A value of 0 means the limit is not tracked (typically open-source
+	// models, whose limit depends on the host).
+	MaxOutputTokens int
+
+	// LongContextWindow is the larger window a model offers on request,
+	// such as Anthropic's 1M-token context beta. Prompts that need more
+	// than ContextWindow are checked against it and billed at the
+	// PriceTiers rates. A value of 0 means the model has no such option.
+	LongContextWindow int
+
+	// Modalities lists the input kinds the model accepts. Nil means text
+	// only.
+	Modalities []Modality
+
+	// KnowledgeCutoff is the month of the provider's reliable knowledge
+	// cutoff. The zero time means it is not tracked.
+	KnowledgeCutoff time.Time
+
+	// ReleaseDate is when the model became available through the
+	// provider's API. Historical prices start on it. The zero time means it
+	// is not tracked.
+	ReleaseDate time.Time
+
+	// DeprecationDate is when the provider deprecated the model and
+	// RetirementDate when it stops (or stopped) serving it. The zero time
+	// means no date has been announced.
+	DeprecationDate time.Time
+	RetirementDate  time.Time
+
 	// InputPricePer1M is the input price per 1M tokens in USD.
 	// A value of 0.0 indicates pricing is not tracked (typically open-source self-hosted models).
 	InputPricePer1M float64
@@ -29,243 +91,663 @@ type ModelMetadata struct {
 	// OutputPricePer1M is the output price per 1M tokens in USD.
 	// A value of 0.0 indicates pricing is not tracked (typically open-source self-hosted models).
 	OutputPricePer1M float64
+
+	// AudioTokensPerSecond is the rate at which audio input is converted to tokens.
+	// A value of 0.0 indicates the model does not accept audio input.
+	AudioTokensPerSecond float64
+
+	// AudioInputPricePer1M is the audio input price per 1M audio tokens in USD.
+	AudioInputPricePer1M float64
+
+	// CacheWritePricePer1M is the price per 1M input tokens written to the prompt cache in USD.
+	// OpenAI caches automatically at the input price; Anthropic charges a premium.
+	CacheWritePricePer1M float64
+
+	// CacheReadPricePer1M is the price per 1M input tokens read from the prompt cache in USD.
+	// A value of 0.0 indicates the model has no prompt caching or pricing is not tracked.
+	CacheReadPricePer1M float64
+
+	// PriceTiers raise the prices once the prompt exceeds a size, as with
+	// long-context pricing. Tiers are ordered by AboveTokens; the base
+	// prices apply to prompts up to the first tier.
+	PriceTiers []PriceTier
+
+	// PriceHistory lists the model's earlier prices, oldest first. Each
+	// period ends the day before its Until date and starts where the
+	// previous one ends; the first starts at ReleaseDate.
+	// The current prices apply from the last Until on.
+	PriceHistory []PricePeriod
+
+	// TrainingPricePer1M is the fine-tuning price per 1M training tokens in USD.
+	// A value of 0.0 indicates the model cannot be fine-tuned or pricing is not tracked.
+	TrainingPricePer1M float64
+
+	// TrainingContextWindow is the maximum number of tokens in a fine-tuning example.
+	// Longer examples are truncated. A value of 0 means the ContextWindow applies.
+	TrainingContextWindow int
+
+	// ServiceTiers maps the non-standard service tiers a model offers to
+	// the multiplier they apply to every standard price. Tiers not listed
+	// are unavailable for the model.
+	ServiceTiers map[ServiceTier]float64
+
+	// ChatTemplate names the built-in chat template (see ChatTemplateNames)
+	// applied when counting chat messages for open-weight models.
+	ChatTemplate string
+
+	// VocabFile is the SentencePiece .model file of a custom model whose
+	// Encoding is "spm". Pass it as CounterOptions.VocabFile to count the
+	// model exactly.
+	VocabFile string
 }
 
-// modelRegistry is the central registry of all supported models.
-// Pricing data last updated: 2026-02-17.
+// PriceTier holds the prices charged per 1M tokens in USD for requests whose
+// prompt exceeds AboveTokens. The tier applies to the whole request, output
+// and cache prices included.
+type PriceTier struct {
+	AboveTokens          int
+	InputPricePer1M      float64
+	OutputPricePer1M     float64
+	CacheWritePricePer1M float64
+	CacheReadPricePer1M  float64
+}
+
+// PricePeriod holds the prices per 1M tokens in USD that a model was sold
+// at until a price change on Until.
+type PricePeriod struct {
+	Until                time.Time
+	InputPricePer1M      float64
+	OutputPricePer1M     float64
+	CacheWritePricePer1M float64
+	CacheReadPricePer1M  float64
+}
+
+// AsOf returns a copy of the metadata with the prices in force on day t.
+// Prices from an earlier period replace the current ones and drop the
+// long-context tiers, which the history does not record. Before the
+// model's ReleaseDate it has no prices. The zero time means the current
+// prices.
+func (m *ModelMetadata) AsOf(t time.Time) ModelMetadata {
+	meta := m.clone()
+	if t.IsZero() {
+		return meta
+	}
+	if t.Before(m.ReleaseDate) {
+		meta.InputPricePer1M, meta.OutputPricePer1M = 0, 0
+		meta.CacheWritePricePer1M, meta.CacheReadPricePer1M = 0, 0
+		meta.PriceTiers = nil
+		return meta
+	}
+	for _, period := range m.PriceHistory {
+		if t.Before(period.Until) {
+			meta.InputPricePer1M = period.InputPricePer1M
+			meta.OutputPricePer1M = period.OutputPricePer1M
+			meta.CacheWritePricePer1M = period.CacheWritePricePer1M
+			meta.CacheReadPricePer1M = period.CacheReadPricePer1M
+			meta.PriceTiers = nil
+			break
+		}
+	}
+	return meta
+}
+
+// PricesFor returns the prices for a request with the given prompt size:
+// the highest tier whose threshold the prompt exceeds, or the base prices
+// with AboveTokens 0.
+func (m *ModelMetadata) PricesFor(promptTokens int) PriceTier {
+	prices := PriceTier{
+		InputPricePer1M:      m.InputPricePer1M,
+		OutputPricePer1M:     m.OutputPricePer1M,
+		CacheWritePricePer1M: m.CacheWritePricePer1M,
+		CacheReadPricePer1M:  m.CacheReadPricePer1M,
+	}
+	for _, tier := range m.PriceTiers {
+		if promptTokens > tier.AboveTokens {
+			prices = tier
+		}
+	}
+	return prices
+}
+
+// ServiceTierMultiplier returns the multiplier a service tier applies to the
+// model's standard prices, and false when the model does not offer the tier.
+func (m *ModelMetadata) ServiceTierMultiplier(tier ServiceTier) (float64, bool) {
+	if tier == "" || tier == ServiceTierStandard {
+		return 1, true
+	}
+	multiplier, ok := m.ServiceTiers[tier]
+	return multiplier, ok
+}
+
+// SupportsModality reports whether the model accepts an input kind.
+func (m *ModelMetadata) SupportsModality(modality Modality) bool {
+	if m.Modalities == nil {
+		return modality == ModalityText
+	}
+	for _, mod := range m.Modalities {
+		if mod == modality {
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns the model's lifecycle status at now: retired from its
+// retirement date, deprecated from its deprecation date, and active
+// otherwise.
+func (m *ModelMetadata) Status(now time.Time) ModelStatus {
+	switch {
+	case !m.RetirementDate.IsZero() && !now.Before(m.RetirementDate):
+		return ModelStatusRetired
+	case !m.DeprecationDate.IsZero() && !now.Before(m.DeprecationDate):
+		return ModelStatusDeprecated
+	}
+	return ModelStatusActive
+}
+
+// ContextWindowFor returns the context window a request of tokens is
+// checked against: the long-context window when the model has one and the
+// request does not fit the standard window, otherwise ContextWindow.
+func (m *ModelMetadata) ContextWindowFor(tokens int) int {
+	if tokens > m.ContextWindow && m.LongContextWindow > m.ContextWindow {
+		return m.LongContextWindow
+	}
+	return m.ContextWindow
+}
+
+// OutputHeadroom returns how many tokens the model can still generate after
+// a prompt of inputTokens: the rest of the context window (see
+// ContextWindowFor), capped at the maximum output tokens when they are
+// tracked. It returns false when the context window is not tracked.
+func (m *ModelMetadata) OutputHeadroom(inputTokens int) (int, bool) {
+	if m.ContextWindow == 0 {
+		return 0, false
+	}
+	headroom := max(m.ContextWindowFor(inputTokens)-inputTokens, 0)
+	if m.MaxOutputTokens > 0 {
+		headroom = min(headroom, m.MaxOutputTokens)
+	}
+	return headroom, true
+}
+
+// Tokenizer backends that count a model's tokens (see
+// ModelMetadata.TokenizerBackend).
+const (
+	BackendTiktoken      = "tiktoken"      // an OpenAI BPE encoding
+	BackendClaudeApprox  = "claude-approx" // the Claude approximation
+	BackendSentencePiece = "sentencepiece" // a SentencePiece vocab file
+)
+
+// openWeightProviders are the built-in providers whose models are counted
+// with an OpenAI encoding in place of their own tokenizer.
+var openWeightProviders = []Provider{ProviderMeta, ProviderDeepSeek, ProviderAlibaba, ProviderMicrosoft, ProviderGoogle}
+
+// TokenizerBackend reports the backend that counts the model's tokens and
+// how exact the counts are, as a token source: exact for OpenAI encodings
+// of OpenAI and custom models and for SentencePiece, proxy for open-weight
+// models counted with an OpenAI encoding, and estimated for the Claude
+// approximation.
+func (m *ModelMetadata) TokenizerBackend() (backend, source string) {
+	switch m.Encoding {
+	case "claude_approx":
+		return BackendClaudeApprox, TokenSourceEstimated
+	case "spm":
+		return BackendSentencePiece, TokenSourceExact
+	}
+	for _, p := range openWeightProviders {
+		if m.Provider == p {
+			return BackendTiktoken, TokenSourceProxy
+		}
+	}
+	return BackendTiktoken, TokenSourceExact
+}
+
+// Input modalities shared by the built-in models.
+var (
+	modalitiesText      = []Modality{ModalityText}
+	modalitiesVision    = []Modality{ModalityText, ModalityImage}
+	modalitiesDocuments = []Modality{ModalityText, ModalityImage, ModalityPDF}
+	modalitiesAudio     = []Modality{ModalityText, ModalityAudio}
+	modalitiesRealtime  = []Modality{ModalityText, ModalityImage, ModalityAudio}
+)
+
+// date returns midnight UTC on a day, for registry dates.
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// modelRegistry is the built-in table of all supported models that every
+// Registry starts from (see DefaultRegistry and NewRegistry).
+// Pricing data last updated: 2026-02-17 (keep PricingUpdated in sync).
+// Claude long-context tiers apply to prompts over 200K tokens (1M context).
+// Service tier multipliers: batch halves all prices; OpenAI flex halves and
+// priority raises them (priority rate / standard rate).
+// Knowledge cutoffs, release and lifecycle dates come from the providers'
+// model, announcement and deprecation pages. Price history records list-price changes: gpt-4o moved
+// to the 2024-08-06 snapshot's prices on 2024-10-02 and o3 was cut 80% on
+// 2025-06-10.
 // Sources: OpenAI (openai.com/api/pricing), Anthropic (platform.claude.com/docs/en/about-claude/pricing).
 var modelRegistry = map[string]ModelMetadata{
 	// OpenAI Models - GPT-5 series (o200k_base)
 	"gpt-5": {
 		Name: "gpt-5", Provider: ProviderOpenAI, Encoding: "o200k_base",
-		ContextWindow: 400000, InputPricePer1M: 1.25, OutputPricePer1M: 10.00,
+		ContextWindow: 400000, MaxOutputTokens: 128000, InputPricePer1M: 1.25, OutputPricePer1M: 10.00,
+		CacheWritePricePer1M: 1.25, CacheReadPricePer1M: 0.125,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5, ServiceTierFlex: 0.5, ServiceTierPriority: 2.0},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2024, time.September, 1), ReleaseDate: date(2025, time.August, 7),
 	},
 	"gpt-5-mini": {
 		Name: "gpt-5-mini", Provider: ProviderOpenAI, Encoding: "o200k_base",
-		ContextWindow: 400000, InputPricePer1M: 0.25, OutputPricePer1M: 2.00,
+		ContextWindow: 400000, MaxOutputTokens: 128000, InputPricePer1M: 0.25, OutputPricePer1M: 2.00,
+		CacheWritePricePer1M: 0.25, CacheReadPricePer1M: 0.025,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5, ServiceTierFlex: 0.5, ServiceTierPriority: 1.8},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2024, time.May, 1), ReleaseDate: date(2025, time.August, 7),
 	},
 	"gpt-5-nano": {
 		Name: "gpt-5-nano", Provider: ProviderOpenAI, Encoding: "o200k_base",
-		ContextWindow: 400000, InputPricePer1M: 0.05, OutputPricePer1M: 0.40,
+		ContextWindow: 400000, MaxOutputTokens: 128000, InputPricePer1M: 0.05, OutputPricePer1M: 0.40,
+		CacheWritePricePer1M: 0.05, CacheReadPricePer1M: 0.005,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5, ServiceTierFlex: 0.5},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2024, time.May, 1), ReleaseDate: date(2025, time.August, 7),
 	},
 
 	// OpenAI Models - GPT-5.1/5.2 series (o200k_base)
 	"gpt-5.1": {
 		Name: "gpt-5.1", Provider: ProviderOpenAI, Encoding: "o200k_base",
-		ContextWindow: 400000, InputPricePer1M: 1.25, OutputPricePer1M: 10.00,
+		ContextWindow: 400000, MaxOutputTokens: 128000, InputPricePer1M: 1.25, OutputPricePer1M: 10.00,
+		CacheWritePricePer1M: 1.25, CacheReadPricePer1M: 0.125,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5, ServiceTierFlex: 0.5, ServiceTierPriority: 2.0},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2024, time.September, 1),
 	},
 	"gpt-5.2": {
 		Name: "gpt-5.2", Provider: ProviderOpenAI, Encoding: "o200k_base",
-		ContextWindow: 400000, InputPricePer1M: 1.75, OutputPricePer1M: 14.00,
+		ContextWindow: 400000, MaxOutputTokens: 128000, InputPricePer1M: 1.75, OutputPricePer1M: 14.00,
+		CacheWritePricePer1M: 1.75, CacheReadPricePer1M: 0.175,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5, ServiceTierFlex: 0.5, ServiceTierPriority: 2.0},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2025, time.August, 1),
 	},
 
 	// OpenAI Models - GPT-4.1 series (o200k_base, 1M context)
 	"gpt-4.1": {
 		Name: "gpt-4.1", Provider: ProviderOpenAI, Encoding: "o200k_base",
-		ContextWindow: 1047576, InputPricePer1M: 2.00, OutputPricePer1M: 8.00,
+		ContextWindow: 1047576, MaxOutputTokens: 32768, InputPricePer1M: 2.00, OutputPricePer1M: 8.00,
+		CacheWritePricePer1M: 2.00, CacheReadPricePer1M: 0.50,
+		TrainingPricePer1M: 25.00, TrainingContextWindow: 65536,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5, ServiceTierPriority: 1.75},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2024, time.June, 1), ReleaseDate: date(2025, time.April, 14),
 	},
 	"gpt-4.1-mini": {
 		Name: "gpt-4.1-mini", Provider: ProviderOpenAI, Encoding: "o200k_base",
-		ContextWindow: 1047576, InputPricePer1M: 0.40, OutputPricePer1M: 1.60,
+		ContextWindow: 1047576, MaxOutputTokens: 32768, InputPricePer1M: 0.40, OutputPricePer1M: 1.60,
+		CacheWritePricePer1M: 0.40, CacheReadPricePer1M: 0.10,
+		TrainingPricePer1M: 5.00, TrainingContextWindow: 65536,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5, ServiceTierPriority: 1.75},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2024, time.June, 1), ReleaseDate: date(2025, time.April, 14),
 	},
 	"gpt-4.1-nano": {
 		Name: "gpt-4.1-nano", Provider: ProviderOpenAI, Encoding: "o200k_base",
-		ContextWindow: 1047576, InputPricePer1M: 0.10, OutputPricePer1M: 0.40,
+		ContextWindow: 1047576, MaxOutputTokens: 32768, InputPricePer1M: 0.10, OutputPricePer1M: 0.40,
+		CacheWritePricePer1M: 0.10, CacheReadPricePer1M: 0.025,
+		TrainingPricePer1M: 1.50, TrainingContextWindow: 65536,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5, ServiceTierPriority: 2.0},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2024, time.June, 1), ReleaseDate: date(2025, time.April, 14),
 	},
 
 	// OpenAI Models - GPT-4o series (o200k_base)
 	"gpt-4o": {
 		Name: "gpt-4o", Provider: ProviderOpenAI, Encoding: "o200k_base",
-		ContextWindow: 128000, InputPricePer1M: 2.50, OutputPricePer1M: 10.00,
+		ContextWindow: 128000, MaxOutputTokens: 16384, InputPricePer1M: 2.50, OutputPricePer1M: 10.00,
+		CacheWritePricePer1M: 2.50, CacheReadPricePer1M: 1.25,
+		TrainingPricePer1M: 25.00, TrainingContextWindow: 65536,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5, ServiceTierPriority: 1.7},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2023, time.October, 1), ReleaseDate: date(2024, time.May, 13),
+		PriceHistory: []PricePeriod{{Until: date(2024, time.October, 2), InputPricePer1M: 5.00, OutputPricePer1M: 15.00, CacheWritePricePer1M: 5.00}},
 	},
 	"gpt-4o-mini": {
 		Name: "gpt-4o-mini", Provider: ProviderOpenAI, Encoding: "o200k_base",
-		ContextWindow: 128000, InputPricePer1M: 0.15, OutputPricePer1M: 0.60,
+		ContextWindow: 128000, MaxOutputTokens: 16384, InputPricePer1M: 0.15, OutputPricePer1M: 0.60,
+		CacheWritePricePer1M: 0.15, CacheReadPricePer1M: 0.075,
+		TrainingPricePer1M: 3.00, TrainingContextWindow: 65536,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5, ServiceTierPriority: 5.0 / 3},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2023, time.October, 1), ReleaseDate: date(2024, time.July, 18),
+	},
+
+	// OpenAI Models - Audio (o200k_base text, audio billed at ~10 tokens/second)
+	"gpt-4o-audio-preview": {
+		Name: "gpt-4o-audio-preview", Provider: ProviderOpenAI, Encoding: "o200k_base",
+		ContextWindow: 128000, MaxOutputTokens: 16384, InputPricePer1M: 2.50, OutputPricePer1M: 10.00,
+		AudioTokensPerSecond: 10, AudioInputPricePer1M: 40.00,
+		Modalities: modalitiesAudio, KnowledgeCutoff: date(2023, time.October, 1),
+	},
+	"gpt-4o-mini-audio-preview": {
+		Name: "gpt-4o-mini-audio-preview", Provider: ProviderOpenAI, Encoding: "o200k_base",
+		ContextWindow: 128000, MaxOutputTokens: 16384, InputPricePer1M: 0.15, OutputPricePer1M: 0.60,
+		AudioTokensPerSecond: 10, AudioInputPricePer1M: 10.00,
+		Modalities: modalitiesAudio, KnowledgeCutoff: date(2023, time.October, 1),
+	},
+	"gpt-4o-transcribe": {
+		Name: "gpt-4o-transcribe", Provider: ProviderOpenAI, Encoding: "o200k_base",
+		ContextWindow: 16000, MaxOutputTokens: 2000, InputPricePer1M: 2.50, OutputPricePer1M: 10.00,
+		AudioTokensPerSecond: 10, AudioInputPricePer1M: 6.00,
+		Modalities: modalitiesAudio,
+	},
+	"gpt-4o-mini-transcribe": {
+		Name: "gpt-4o-mini-transcribe", Provider: ProviderOpenAI, Encoding: "o200k_base",
+		ContextWindow: 16000, MaxOutputTokens: 2000, InputPricePer1M: 1.25, OutputPricePer1M: 5.00,
+		AudioTokensPerSecond: 10, AudioInputPricePer1M: 3.00,
+		Modalities: modalitiesAudio,
+	},
+	"gpt-realtime": {
+		Name: "gpt-realtime", Provider: ProviderOpenAI, Encoding: "o200k_base",
+		ContextWindow: 32000, MaxOutputTokens: 4096, InputPricePer1M: 4.00, OutputPricePer1M: 16.00,
+		CacheWritePricePer1M: 4.00, CacheReadPricePer1M: 0.40,
+		AudioTokensPerSecond: 10, AudioInputPricePer1M: 32.00,
+		Modalities: modalitiesRealtime,
 	},
 
 	// OpenAI Models - o-series (o200k_base)
 	"o3": {
 		Name: "o3", Provider: ProviderOpenAI, Encoding: "o200k_base",
-		ContextWindow: 200000, InputPricePer1M: 2.00, OutputPricePer1M: 8.00,
+		ContextWindow: 200000, MaxOutputTokens: 100000, InputPricePer1M: 2.00, OutputPricePer1M: 8.00,
+		CacheWritePricePer1M: 2.00, CacheReadPricePer1M: 0.50,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5, ServiceTierFlex: 0.5, ServiceTierPriority: 1.75},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2024, time.June, 1), ReleaseDate: date(2025, time.April, 16),
+		PriceHistory: []PricePeriod{{Until: date(2025, time.June, 10), InputPricePer1M: 10.00, OutputPricePer1M: 40.00, CacheWritePricePer1M: 10.00, CacheReadPricePer1M: 2.50}},
 	},
 	"o3-mini": {
 		Name: "o3-mini", Provider: ProviderOpenAI, Encoding: "o200k_base",
-		ContextWindow: 200000, InputPricePer1M: 1.10, OutputPricePer1M: 4.40,
+		ContextWindow: 200000, MaxOutputTokens: 100000, InputPricePer1M: 1.10, OutputPricePer1M: 4.40,
+		CacheWritePricePer1M: 1.10, CacheReadPricePer1M: 0.55,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesText, KnowledgeCutoff: date(2023, time.October, 1), ReleaseDate: date(2025, time.January, 31),
 	},
 	"o4-mini": {
 		Name: "o4-mini", Provider: ProviderOpenAI, Encoding: "o200k_base",
-		ContextWindow: 200000, InputPricePer1M: 1.10, OutputPricePer1M: 4.40,
+		ContextWindow: 200000, MaxOutputTokens: 100000, InputPricePer1M: 1.10, OutputPricePer1M: 4.40,
+		CacheWritePricePer1M: 1.10, CacheReadPricePer1M: 0.275,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5, ServiceTierFlex: 0.5, ServiceTierPriority: 2.0 / 1.1},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2024, time.June, 1), ReleaseDate: date(2025, time.April, 16),
 	},
 
 	// OpenAI Models - Legacy (cl100k_base)
 	"gpt-4": {
 		Name: "gpt-4", Provider: ProviderOpenAI, Encoding: "cl100k_base",
-		ContextWindow: 8192, InputPricePer1M: 30.00, OutputPricePer1M: 60.00,
+		ContextWindow: 8192, MaxOutputTokens: 8192, InputPricePer1M: 30.00, OutputPricePer1M: 60.00,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesText, KnowledgeCutoff: date(2021, time.September, 1), ReleaseDate: date(2023, time.March, 14),
 	},
 	"gpt-4-turbo": {
 		Name: "gpt-4-turbo", Provider: ProviderOpenAI, Encoding: "cl100k_base",
-		ContextWindow: 128000, InputPricePer1M: 10.00, OutputPricePer1M: 30.00,
+		ContextWindow: 128000, MaxOutputTokens: 4096, InputPricePer1M: 10.00, OutputPricePer1M: 30.00,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesVision, KnowledgeCutoff: date(2023, time.December, 1),
 	},
 	"gpt-3.5-turbo": {
 		Name: "gpt-3.5-turbo", Provider: ProviderOpenAI, Encoding: "cl100k_base",
-		ContextWindow: 16385, InputPricePer1M: 0.50, OutputPricePer1M: 1.50,
+		ContextWindow: 16385, MaxOutputTokens: 4096, InputPricePer1M: 0.50, OutputPricePer1M: 1.50,
+		TrainingPricePer1M: 8.00, TrainingContextWindow: 16385,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesText, KnowledgeCutoff: date(2021, time.September, 1), ReleaseDate: date(2023, time.March, 1),
+	},
+
+	// OpenAI Models - Embeddings (cl100k_base, input only)
+	"text-embedding-3-small": {
+		Name: "text-embedding-3-small", Provider: ProviderOpenAI, Encoding: "cl100k_base",
+		ContextWindow: 8191, InputPricePer1M: 0.02,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesText, ReleaseDate: date(2024, time.January, 25),
+	},
+	"text-embedding-3-large": {
+		Name: "text-embedding-3-large", Provider: ProviderOpenAI, Encoding: "cl100k_base",
+		ContextWindow: 8191, InputPricePer1M: 0.13,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesText, ReleaseDate: date(2024, time.January, 25),
 	},
 
 	// Anthropic Models - Claude Opus (approximation)
 	"claude-opus-4.6": {
 		Name: "claude-opus-4.6", Provider: ProviderAnthropic, Encoding: "claude_approx",
-		ContextWindow: 200000, InputPricePer1M: 5.00, OutputPricePer1M: 25.00,
+		ContextWindow: 200000, LongContextWindow: 1000000, MaxOutputTokens: 128000, InputPricePer1M: 5.00, OutputPricePer1M: 25.00,
+		CacheWritePricePer1M: 6.25, CacheReadPricePer1M: 0.50,
+		PriceTiers:   []PriceTier{{AboveTokens: 200000, InputPricePer1M: 10.00, OutputPricePer1M: 37.50, CacheWritePricePer1M: 12.50, CacheReadPricePer1M: 1.00}},
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesDocuments,
 	},
 	"claude-opus-4.5": {
 		Name: "claude-opus-4.5", Provider: ProviderAnthropic, Encoding: "claude_approx",
-		ContextWindow: 200000, InputPricePer1M: 5.00, OutputPricePer1M: 25.00,
+		ContextWindow: 200000, MaxOutputTokens: 64000, InputPricePer1M: 5.00, OutputPricePer1M: 25.00,
+		CacheWritePricePer1M: 6.25, CacheReadPricePer1M: 0.50,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2025, time.May, 1), ReleaseDate: date(2025, time.November, 24),
 	},
 	"claude-opus-4.1": {
 		Name: "claude-opus-4.1", Provider: ProviderAnthropic, Encoding: "claude_approx",
-		ContextWindow: 200000, InputPricePer1M: 15.00, OutputPricePer1M: 75.00,
+		ContextWindow: 200000, MaxOutputTokens: 32000, InputPricePer1M: 15.00, OutputPricePer1M: 75.00,
+		CacheWritePricePer1M: 18.75, CacheReadPricePer1M: 1.50,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2025, time.January, 1), ReleaseDate: date(2025, time.August, 5),
 	},
 	"claude-opus-4": {
 		Name: "claude-opus-4", Provider: ProviderAnthropic, Encoding: "claude_approx",
-		ContextWindow: 200000, InputPricePer1M: 15.00, OutputPricePer1M: 75.00,
+		ContextWindow: 200000, MaxOutputTokens: 32000, InputPricePer1M: 15.00, OutputPricePer1M: 75.00,
+		CacheWritePricePer1M: 18.75, CacheReadPricePer1M: 1.50,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2025, time.January, 1), ReleaseDate: date(2025, time.May, 22),
 	},
 
 	// Anthropic Models - Claude Sonnet (approximation)
 	"claude-sonnet-4.6": {
 		Name: "claude-sonnet-4.6", Provider: ProviderAnthropic, Encoding: "claude_approx",
-		ContextWindow: 200000, InputPricePer1M: 3.00, OutputPricePer1M: 15.00,
+		ContextWindow: 200000, LongContextWindow: 1000000, MaxOutputTokens: 64000, InputPricePer1M: 3.00, OutputPricePer1M: 15.00,
+		CacheWritePricePer1M: 3.75, CacheReadPricePer1M: 0.30,
+		PriceTiers:   []PriceTier{{AboveTokens: 200000, InputPricePer1M: 6.00, OutputPricePer1M: 22.50, CacheWritePricePer1M: 7.50, CacheReadPricePer1M: 0.60}},
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesDocuments,
 	},
 	"claude-sonnet-4.5": {
 		Name: "claude-sonnet-4.5", Provider: ProviderAnthropic, Encoding: "claude_approx",
-		ContextWindow: 200000, InputPricePer1M: 3.00, OutputPricePer1M: 15.00,
+		ContextWindow: 200000, LongContextWindow: 1000000, MaxOutputTokens: 64000, InputPricePer1M: 3.00, OutputPricePer1M: 15.00,
+		CacheWritePricePer1M: 3.75, CacheReadPricePer1M: 0.30,
+		PriceTiers:   []PriceTier{{AboveTokens: 200000, InputPricePer1M: 6.00, OutputPricePer1M: 22.50, CacheWritePricePer1M: 7.50, CacheReadPricePer1M: 0.60}},
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2025, time.January, 1), ReleaseDate: date(2025, time.September, 29),
 	},
 	"claude-sonnet-4": {
 		Name: "claude-sonnet-4", Provider: ProviderAnthropic, Encoding: "claude_approx",
-		ContextWindow: 200000, InputPricePer1M: 3.00, OutputPricePer1M: 15.00,
+		ContextWindow: 200000, LongContextWindow: 1000000, MaxOutputTokens: 64000, InputPricePer1M: 3.00, OutputPricePer1M: 15.00,
+		CacheWritePricePer1M: 3.75, CacheReadPricePer1M: 0.30,
+		PriceTiers:   []PriceTier{{AboveTokens: 200000, InputPricePer1M: 6.00, OutputPricePer1M: 22.50, CacheWritePricePer1M: 7.50, CacheReadPricePer1M: 0.60}},
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2025, time.January, 1), ReleaseDate: date(2025, time.May, 22),
 	},
 
 	// Anthropic Models - Claude Haiku (approximation)
 	"claude-haiku-4.5": {
 		Name: "claude-haiku-4.5", Provider: ProviderAnthropic, Encoding: "claude_approx",
-		ContextWindow: 200000, InputPricePer1M: 1.00, OutputPricePer1M: 5.00,
+		ContextWindow: 200000, MaxOutputTokens: 64000, InputPricePer1M: 1.00, OutputPricePer1M: 5.00,
+		CacheWritePricePer1M: 1.25, CacheReadPricePer1M: 0.10,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2025, time.February, 1), ReleaseDate: date(2025, time.October, 15),
 	},
 	"claude-haiku-3.5": {
 		Name: "claude-haiku-3.5", Provider: ProviderAnthropic, Encoding: "claude_approx",
-		ContextWindow: 200000, InputPricePer1M: 0.80, OutputPricePer1M: 4.00,
+		ContextWindow: 200000, MaxOutputTokens: 8192, InputPricePer1M: 0.80, OutputPricePer1M: 4.00,
+		CacheWritePricePer1M: 1.00, CacheReadPricePer1M: 0.08,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2024, time.July, 1),
+		DeprecationDate: date(2025, time.December, 19), RetirementDate: date(2026, time.February, 19),
 	},
 	"claude-haiku-3": {
 		Name: "claude-haiku-3", Provider: ProviderAnthropic, Encoding: "claude_approx",
-		ContextWindow: 200000, InputPricePer1M: 0.25, OutputPricePer1M: 1.25,
+		ContextWindow: 200000, MaxOutputTokens: 4096, InputPricePer1M: 0.25, OutputPricePer1M: 1.25,
+		CacheWritePricePer1M: 0.30, CacheReadPricePer1M: 0.03,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2023, time.August, 1), ReleaseDate: date(2024, time.March, 13),
 	},
 
-	// Anthropic Models - Legacy (deprecated)
+	// Anthropic Models - Legacy (deprecated or retired)
 	"claude-opus-3": {
 		Name: "claude-opus-3", Provider: ProviderAnthropic, Encoding: "claude_approx",
-		ContextWindow: 200000, InputPricePer1M: 15.00, OutputPricePer1M: 75.00,
+		ContextWindow: 200000, MaxOutputTokens: 4096, InputPricePer1M: 15.00, OutputPricePer1M: 75.00,
+		CacheWritePricePer1M: 18.75, CacheReadPricePer1M: 1.50,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2023, time.August, 1), ReleaseDate: date(2024, time.March, 4),
+		DeprecationDate: date(2025, time.June, 30), RetirementDate: date(2026, time.January, 5),
+	},
+
+	"claude-sonnet-3.7": {
+		Name: "claude-sonnet-3.7", Provider: ProviderAnthropic, Encoding: "claude_approx",
+		ContextWindow: 200000, MaxOutputTokens: 64000, InputPricePer1M: 3.00, OutputPricePer1M: 15.00,
+		CacheWritePricePer1M: 3.75, CacheReadPricePer1M: 0.30,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2024, time.October, 1), ReleaseDate: date(2025, time.February, 24),
+		DeprecationDate: date(2025, time.October, 28), RetirementDate: date(2026, time.February, 19),
+	},
+	"claude-sonnet-3.5": {
+		Name: "claude-sonnet-3.5", Provider: ProviderAnthropic, Encoding: "claude_approx",
+		ContextWindow: 200000, MaxOutputTokens: 8192, InputPricePer1M: 3.00, OutputPricePer1M: 15.00,
+		CacheWritePricePer1M: 3.75, CacheReadPricePer1M: 0.30,
+		ServiceTiers: map[ServiceTier]float64{ServiceTierBatch: 0.5},
+		Modalities:   modalitiesDocuments, KnowledgeCutoff: date(2024, time.April, 1), ReleaseDate: date(2024, time.June, 20),
+		DeprecationDate: date(2025, time.August, 13), RetirementDate: date(2025, time.October, 22),
 	},
 
 	// Meta Models - Llama series (cl100k_base BPE approximation)
 	// Pricing: 0.0 = open-source, self-hosted (no API pricing tracked)
 	"llama-3.1-8b": {
 		Name: "llama-3.1-8b", Provider: ProviderMeta, Encoding: "cl100k_base",
-		ContextWindow: 128000,
+		ContextWindow: 128000, ChatTemplate: "llama3",
+		Modalities: modalitiesText, KnowledgeCutoff: date(2023, time.December, 1),
 	},
 	"llama-3.1-70b": {
 		Name: "llama-3.1-70b", Provider: ProviderMeta, Encoding: "cl100k_base",
-		ContextWindow: 128000,
+		ContextWindow: 128000, ChatTemplate: "llama3",
+		Modalities: modalitiesText, KnowledgeCutoff: date(2023, time.December, 1),
 	},
 	"llama-3.1-405b": {
 		Name: "llama-3.1-405b", Provider: ProviderMeta, Encoding: "cl100k_base",
-		ContextWindow: 128000,
+		ContextWindow: 128000, ChatTemplate: "llama3",
+		Modalities: modalitiesText, KnowledgeCutoff: date(2023, time.December, 1),
 	},
 	"llama-4-scout": {
 		Name: "llama-4-scout", Provider: ProviderMeta, Encoding: "cl100k_base",
-		ContextWindow: 128000,
+		ContextWindow: 128000, ChatTemplate: "llama4",
+		Modalities: modalitiesVision, KnowledgeCutoff: date(2024, time.August, 1),
 	},
 	"llama-4-maverick": {
 		Name: "llama-4-maverick", Provider: ProviderMeta, Encoding: "cl100k_base",
-		ContextWindow: 128000,
+		ContextWindow: 128000, ChatTemplate: "llama4",
+		Modalities: modalitiesVision, KnowledgeCutoff: date(2024, time.August, 1),
 	},
 
 	// DeepSeek Models (cl100k_base BPE approximation)
 	"deepseek-v2": {
 		Name: "deepseek-v2", Provider: ProviderDeepSeek, Encoding: "cl100k_base",
-		ContextWindow: 128000,
+		ContextWindow: 128000, ChatTemplate: "deepseek-v2",
+		Modalities: modalitiesText,
 	},
 	"deepseek-v3": {
 		Name: "deepseek-v3", Provider: ProviderDeepSeek, Encoding: "cl100k_base",
-		ContextWindow: 128000,
+		ContextWindow: 128000, ChatTemplate: "deepseek-v3",
+		Modalities: modalitiesText,
 	},
 	"deepseek-coder-v2": {
 		Name: "deepseek-coder-v2", Provider: ProviderDeepSeek, Encoding: "cl100k_base",
-		ContextWindow: 128000,
+		ContextWindow: 128000, ChatTemplate: "deepseek-v2",
+		Modalities: modalitiesText,
 	},
 
 	// Alibaba Models - Qwen 2/3 series (cl100k_base BPE compatible)
 	"qwen-2.5-7b": {
 		Name: "qwen-2.5-7b", Provider: ProviderAlibaba, Encoding: "cl100k_base",
-		ContextWindow: 32768,
+		ContextWindow: 32768, ChatTemplate: "qwen2.5",
+		Modalities: modalitiesText,
 	},
 	"qwen-2.5-14b": {
 		Name: "qwen-2.5-14b", Provider: ProviderAlibaba, Encoding: "cl100k_base",
-		ContextWindow: 32768,
+		ContextWindow: 32768, ChatTemplate: "qwen2.5",
+		Modalities: modalitiesText,
 	},
 	"qwen-2.5-72b": {
 		Name: "qwen-2.5-72b", Provider: ProviderAlibaba, Encoding: "cl100k_base",
-		ContextWindow: 32768,
+		ContextWindow: 32768, ChatTemplate: "qwen2.5",
+		Modalities: modalitiesText,
 	},
 	"qwen-3-72b": {
 		Name: "qwen-3-72b", Provider: ProviderAlibaba, Encoding: "cl100k_base",
-		ContextWindow: 32768,
+		ContextWindow: 32768, ChatTemplate: "chatml",
+		Modalities: modalitiesText,
 	},
 
 	// Microsoft Models - Phi-3 series (cl100k_base BPE compatible)
 	"phi-3-mini": {
 		Name: "phi-3-mini", Provider: ProviderMicrosoft, Encoding: "cl100k_base",
-		ContextWindow: 128000,
+		ContextWindow: 128000, ChatTemplate: "phi3",
+		Modalities: modalitiesText,
 	},
 	"phi-3-small": {
 		Name: "phi-3-small", Provider: ProviderMicrosoft, Encoding: "cl100k_base",
-		ContextWindow: 128000,
+		ContextWindow: 128000, ChatTemplate: "phi3",
+		Modalities: modalitiesText,
 	},
 	"phi-3-medium": {
 		Name: "phi-3-medium", Provider: ProviderMicrosoft, Encoding: "cl100k_base",
-		ContextWindow: 128000,
+		ContextWindow: 128000, ChatTemplate: "phi3",
+		Modalities: modalitiesText,
 	},
 }
 
-// GetModelMetadata retrieves metadata for a given model name.
-// Returns nil if model is not found in the registry.
+// GetModelMetadata retrieves metadata for a given model name or alias from
+// the default registry. Names are normalized with NormalizeModelName when
+// there is no exact match. Returns nil if model is not found in the registry.
 func GetModelMetadata(modelName string) *ModelMetadata {
-	if meta, ok := modelRegistry[modelName]; ok {
-		return &meta
-	}
-	return nil
+	return defaultRegistry.get(modelName)
+}
+
+// ResolveModelName returns the registered name of a model in the default
+// registry, as matched by GetModelMetadata, and whether it was found.
+func ResolveModelName(modelName string) (string, bool) {
+	return defaultRegistry.Resolve(modelName)
 }
 
 // ListModels returns all registered model names in sorted order.
 func ListModels() []string {
-	models := make([]string, 0, len(modelRegistry))
-	for name := range modelRegistry {
-		models = append(models, name)
+	all := defaultRegistry.Models()
+	models := make([]string, 0, len(all))
+	for _, meta := range all {
+		models = append(models, meta.Name)
 	}
-	sort.Strings(models)
 	return models
 }
 
 // ListModelsByProvider returns all models from a specific provider, sorted by name.
 func ListModelsByProvider(provider Provider) []ModelMetadata {
 	models := make([]ModelMetadata, 0)
-	for _, meta := range modelRegistry {
+	for _, meta := range defaultRegistry.Models() {
 		if meta.Provider == provider {
 			models = append(models, meta)
 		}
 	}
-	sort.Slice(models, func(i, j int) bool {
-		return models[i].Name < models[j].Name
-	})
 	return models
 }
 
+// ListProviders returns the providers with registered models, sorted by name.
+// It includes custom providers added by registry overrides.
+func ListProviders() []Provider {
+	seen := make(map[Provider]bool)
+	providers := make([]Provider, 0)
+	for _, meta := range defaultRegistry.Models() {
+		if meta.Provider != "" && !seen[meta.Provider] {
+			seen[meta.Provider] = true
+			providers = append(providers, meta.Provider)
+		}
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i] < providers[j]
+	})
+	return providers
+}
+
 // GetProviderForModel returns the provider for a given model name.
 // Returns empty string if model is not registered.
 func GetProviderForModel(modelName string) Provider {
@@ -284,14 +766,27 @@ func IsOpenSourceModel(modelName string) bool {
 		provider != ProviderAnthropic
 }
 
+// ListAudioModels returns all models that accept audio input, sorted by name.
+func ListAudioModels() []ModelMetadata {
+	return audioModels(defaultRegistry)
+}
+
+// audioModels returns the models of r that accept audio input, sorted by name.
+func audioModels(r *Registry) []ModelMetadata {
+	models := make([]ModelMetadata, 0)
+	for _, meta := range r.Models() {
+		if meta.AudioTokensPerSecond > 0 {
+			models = append(models, meta)
+		}
+	}
+	return models
+}
+
 // ModelsByEncoding returns a map of encoding name to sorted model names.
 func ModelsByEncoding() map[string][]string {
 	result := make(map[string][]string)
-	for name, meta := range modelRegistry {
-		result[meta.Encoding] = append(result[meta.Encoding], name)
-	}
-	for enc := range result {
-		sort.Strings(result[enc])
+	for _, meta := range defaultRegistry.Models() {
+		result[meta.Encoding] = append(result[meta.Encoding], meta.Name)
 	}
 	return result
 }
@@ -0,0 +1,376 @@
+package tokenizer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PricingUpdated is the date the built-in registry prices were last checked.
+var PricingUpdated = time.Date(2026, time.February, 17, 0, 0, 0, 0, time.UTC)
+
+// RegistryOverrides changes or extends the model registry without a new
+// release. Models maps model names to the fields to override; a name not
+// in the registry adds a custom model, such as a fine-tune or a gateway
+// alias, which needs an encoding or a SentencePiece vocab file.
+//
+// Overrides are read from YAML or JSON:
+//
+//	models:
+//	  gpt-4o:
+//	    input_price_per_1m: 2.0
+//	    price_history:
+//	      - until: 2024-10-02
+//	        input_price_per_1m: 5.0
+//	        output_price_per_1m: 15.0
+//	  my-finetune:
+//	    provider: openai
+//	    encoding: o200k_base
+//	    context_window: 128000
+//	    input_price_per_1m: 3.75
+//	    output_price_per_1m: 15
+//	  my-llama:
+//	    provider: acme
+//	    vocab_file: tokenizer.model
+//	    context_window: 131072
+type RegistryOverrides struct {
+	Models map[string]ModelOverride `yaml:"models" json:"models"`
+}
+
+// ModelOverride holds the registry fields set by an override. Nil fields
+// keep the registry value, so prices can be overridden to zero. A vocab
+// file without an encoding selects the "spm" encoding. Dates are written
+// as YYYY-MM-DD; an empty date clears it. A price history replaces the
+// model's whole history, and an empty one clears it.
+type ModelOverride struct {
+	Provider             *Provider `yaml:"provider" json:"provider,omitempty"`
+	Encoding             *string   `yaml:"encoding" json:"encoding,omitempty"`
+	VocabFile            *string   `yaml:"vocab_file" json:"vocab_file,omitempty"`
+	ContextWindow        *int      `yaml:"context_window" json:"context_window,omitempty"`
+	MaxOutputTokens      *int      `yaml:"max_output_tokens" json:"max_output_tokens,omitempty"`
+	LongContextWindow    *int      `yaml:"long_context_window" json:"long_context_window,omitempty"`
+	InputPricePer1M      *float64  `yaml:"input_price_per_1m" json:"input_price_per_1m,omitempty"`
+	OutputPricePer1M     *float64  `yaml:"output_price_per_1m" json:"output_price_per_1m,omitempty"`
+	CacheWritePricePer1M *float64  `yaml:"cache_write_price_per_1m" json:"cache_write_price_per_1m,omitempty"`
+	CacheReadPricePer1M  *float64  `yaml:"cache_read_price_per_1m" json:"cache_read_price_per_1m,omitempty"`
+	TrainingPricePer1M   *float64  `yaml:"training_price_per_1m" json:"training_price_per_1m,omitempty"`
+	ReleaseDate          *string   `yaml:"release_date" json:"release_date,omitempty"`
+	DeprecationDate      *string   `yaml:"deprecation_date" json:"deprecation_date,omitempty"`
+	RetirementDate       *string   `yaml:"retirement_date" json:"retirement_date,omitempty"`
+
+	PriceHistory *[]PricePeriodOverride `yaml:"price_history" json:"price_history,omitempty"`
+}
+
+// PricePeriodOverride is an earlier price period of a model, as written in
+// an override file. Until is the YYYY-MM-DD day the next prices took
+// effect; periods are listed oldest first.
+type PricePeriodOverride struct {
+	Until                string  `yaml:"until" json:"until"`
+	InputPricePer1M      float64 `yaml:"input_price_per_1m" json:"input_price_per_1m"`
+	OutputPricePer1M     float64 `yaml:"output_price_per_1m" json:"output_price_per_1m"`
+	CacheWritePricePer1M float64 `yaml:"cache_write_price_per_1m" json:"cache_write_price_per_1m,omitempty"`
+	CacheReadPricePer1M  float64 `yaml:"cache_read_price_per_1m" json:"cache_read_price_per_1m,omitempty"`
+}
+
+// overrideEncodings lists the encodings an override may select.
+var overrideEncodings = []string{"o200k_base", "cl100k_base", "claude_approx", "spm"}
+
+// LoadRegistryOverrides reads registry overrides from a YAML or JSON file.
+// Relative vocab file paths are resolved against the file's directory.
+func LoadRegistryOverrides(path string) (*RegistryOverrides, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := ParseRegistryOverrides(data)
+	if err != nil {
+		return nil, err
+	}
+	for name, o := range overrides.Models {
+		if o.VocabFile != nil && *o.VocabFile != "" && !filepath.IsAbs(*o.VocabFile) {
+			resolved := filepath.Join(filepath.Dir(path), *o.VocabFile)
+			o.VocabFile = &resolved
+			overrides.Models[name] = o
+		}
+	}
+	return overrides, nil
+}
+
+// ParseRegistryOverrides parses registry overrides from YAML or JSON and
+// checks their values. Whether a model exists, and so whether the override
+// is complete, is checked by the registry they are applied to.
+func ParseRegistryOverrides(data []byte) (*RegistryOverrides, error) {
+	overrides := &RegistryOverrides{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(overrides); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing registry overrides: %w", err)
+	}
+
+	names := make([]string, 0, len(overrides.Models))
+	for name := range overrides.Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := overrides.Models[name].checkValues(name); err != nil {
+			return nil, err
+		}
+	}
+	return overrides, nil
+}
+
+// validate checks an override against the model's current metadata, nil
+// for a new model. New models need an encoding or a vocab file, and the
+// "spm" encoding needs a vocab file.
+func (o ModelOverride) validate(name string, meta *ModelMetadata) error {
+	if err := o.checkValues(name); err != nil {
+		return err
+	}
+	hasVocab := o.VocabFile != nil && *o.VocabFile != ""
+	if o.Encoding == nil && !hasVocab && meta == nil {
+		return fmt.Errorf("model %s: new models need an encoding or a vocab_file", name)
+	}
+	if o.Encoding != nil && *o.Encoding == "spm" && !hasVocab && (meta == nil || meta.VocabFile == "") {
+		return fmt.Errorf("model %s: the spm encoding needs a vocab_file: %w", name, ErrVocabFileRequired)
+	}
+	return nil
+}
+
+// checkValues checks the override's values on their own: known encodings,
+// non-negative limits and prices, and valid dates.
+func (o ModelOverride) checkValues(name string) error {
+	if o.Encoding != nil && !containsString(overrideEncodings, *o.Encoding) {
+		return fmt.Errorf("model %s: unknown encoding %q: %w", name, *o.Encoding, ErrEncodingNotFound)
+	}
+	if o.ContextWindow != nil && *o.ContextWindow < 0 {
+		return fmt.Errorf("model %s: context window must not be negative", name)
+	}
+	if o.MaxOutputTokens != nil && *o.MaxOutputTokens < 0 {
+		return fmt.Errorf("model %s: max output tokens must not be negative", name)
+	}
+	if o.LongContextWindow != nil && *o.LongContextWindow < 0 {
+		return fmt.Errorf("model %s: long context window must not be negative", name)
+	}
+	for _, d := range []*string{o.ReleaseDate, o.DeprecationDate, o.RetirementDate} {
+		if _, err := parseOverrideDate(d); err != nil {
+			return fmt.Errorf("model %s: %w", name, err)
+		}
+	}
+	for _, price := range []*float64{o.InputPricePer1M, o.OutputPricePer1M, o.CacheWritePricePer1M, o.CacheReadPricePer1M, o.TrainingPricePer1M} {
+		if price != nil && *price < 0 {
+			return fmt.Errorf("model %s: prices must not be negative", name)
+		}
+	}
+	if o.PriceHistory != nil {
+		if _, err := parsePriceHistory(*o.PriceHistory); err != nil {
+			return fmt.Errorf("model %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ApplyRegistryOverrides merges overrides into the default registry, so
+// they apply to the package-level functions and to every Counter without
+// its own registry.
+func ApplyRegistryOverrides(overrides *RegistryOverrides) error {
+	return defaultRegistry.ApplyOverrides(overrides)
+}
+
+// ApplyOverrides merges overrides into the registry and records the fields
+// they set (see OverriddenFields). Later overrides of the same field win.
+// Every override is validated against the registry before any is applied,
+// so an invalid override leaves the registry unchanged.
+func (r *Registry) ApplyOverrides(overrides *RegistryOverrides) error {
+	if overrides == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(overrides.Models))
+	for name := range overrides.Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		resolved := r.resolve(name)
+		var current *ModelMetadata
+		if meta, ok := r.models[resolved]; ok {
+			current = &meta
+		}
+		if err := overrides.Models[name].validate(resolved, current); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		o := overrides.Models[name]
+		name = r.resolve(name)
+		meta, ok := r.models[name]
+		if !ok {
+			meta = ModelMetadata{Name: name}
+		} else {
+			meta = meta.clone()
+		}
+		var fields []string
+		set := func(field string) { fields = append(fields, field) }
+
+		if o.Provider != nil {
+			meta.Provider = *o.Provider
+			set("provider")
+		}
+		if o.VocabFile != nil {
+			meta.VocabFile = *o.VocabFile
+			set("vocab_file")
+			if o.Encoding == nil && meta.VocabFile != "" {
+				meta.Encoding = "spm"
+				set("encoding")
+			}
+		}
+		if o.Encoding != nil {
+			meta.Encoding = *o.Encoding
+			set("encoding")
+		}
+		if o.ContextWindow != nil {
+			meta.ContextWindow = *o.ContextWindow
+			set("context_window")
+		}
+		if o.MaxOutputTokens != nil {
+			meta.MaxOutputTokens = *o.MaxOutputTokens
+			set("max_output_tokens")
+		}
+		if o.LongContextWindow != nil {
+			meta.LongContextWindow = *o.LongContextWindow
+			set("long_context_window")
+		}
+		if o.InputPricePer1M != nil {
+			meta.InputPricePer1M = *o.InputPricePer1M
+			set("input_price_per_1m")
+		}
+		if o.OutputPricePer1M != nil {
+			meta.OutputPricePer1M = *o.OutputPricePer1M
+			set("output_price_per_1m")
+		}
+		if o.CacheWritePricePer1M != nil {
+			meta.CacheWritePricePer1M = *o.CacheWritePricePer1M
+			set("cache_write_price_per_1m")
+		}
+		if o.CacheReadPricePer1M != nil {
+			meta.CacheReadPricePer1M = *o.CacheReadPricePer1M
+			set("cache_read_price_per_1m")
+		}
+		if o.TrainingPricePer1M != nil {
+			meta.TrainingPricePer1M = *o.TrainingPricePer1M
+			set("training_price_per_1m")
+		}
+
+		if o.ReleaseDate != nil {
+			meta.ReleaseDate, _ = parseOverrideDate(o.ReleaseDate)
+			set("release_date")
+		}
+		if o.DeprecationDate != nil {
+			meta.DeprecationDate, _ = parseOverrideDate(o.DeprecationDate)
+			set("deprecation_date")
+		}
+		if o.RetirementDate != nil {
+			meta.RetirementDate, _ = parseOverrideDate(o.RetirementDate)
+			set("retirement_date")
+		}
+		if o.PriceHistory != nil {
+			meta.PriceHistory, _ = parsePriceHistory(*o.PriceHistory)
+			set("price_history")
+		}
+
+		r.models[name] = meta
+		if !ok {
+			fields = append([]string{"model"}, fields...)
+		}
+		r.overridden[name] = mergeFields(r.overridden[name], fields)
+	}
+	return nil
+}
+
+// OverriddenFields returns the fields of a model in the default registry set
+// by registry overrides, in snake_case, or nil for built-in values. A model
+// added by an override reports "model" first.
+func OverriddenFields(model string) []string {
+	return defaultRegistry.OverriddenFields(model)
+}
+
+// PricingAge returns how long ago the built-in prices were checked.
+func PricingAge(now time.Time) time.Duration {
+	return now.Sub(PricingUpdated)
+}
+
+// parseOverrideDate parses a YYYY-MM-DD override date. Nil and empty dates
+// are the zero time.
+func parseOverrideDate(d *string) (time.Time, error) {
+	if d == nil || *d == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.DateOnly, *d)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, want YYYY-MM-DD", *d)
+	}
+	return t, nil
+}
+
+// parsePriceHistory converts override price periods to a price history.
+// Each period needs a date after the previous one and non-negative prices.
+func parsePriceHistory(periods []PricePeriodOverride) ([]PricePeriod, error) {
+	history := make([]PricePeriod, 0, len(periods))
+	for i, p := range periods {
+		if p.Until == "" {
+			return nil, fmt.Errorf("price history entry %d needs an until date", i+1)
+		}
+		until, err := parseOverrideDate(&p.Until)
+		if err != nil {
+			return nil, fmt.Errorf("price history: %w", err)
+		}
+		if i > 0 && !until.After(history[i-1].Until) {
+			return nil, fmt.Errorf("price history dates must be in ascending order, %s follows %s", p.Until, history[i-1].Until.Format(time.DateOnly))
+		}
+		for _, price := range []float64{p.InputPricePer1M, p.OutputPricePer1M, p.CacheWritePricePer1M, p.CacheReadPricePer1M} {
+			if price < 0 {
+				return nil, errors.New("price history: prices must not be negative")
+			}
+		}
+		history = append(history, PricePeriod{
+			Until:                until,
+			InputPricePer1M:      p.InputPricePer1M,
+			OutputPricePer1M:     p.OutputPricePer1M,
+			CacheWritePricePer1M: p.CacheWritePricePer1M,
+			CacheReadPricePer1M:  p.CacheReadPricePer1M,
+		})
+	}
+	return history, nil
+}
+
+// mergeFields appends the fields not already in existing.
+func mergeFields(existing, fields []string) []string {
+	for _, f := range fields {
+		if !containsString(existing, f) {
+			existing = append(existing, f)
+		}
+	}
+	return existing
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
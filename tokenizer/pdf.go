@@ -0,0 +1,36 @@
+package tokenizer
+
+import (
+	"github.com/lancekrogers/go-token-counter/tokenizer/fileops"
+)
+
+// anthropicPDFPageTokens is the image overhead Claude adds for each PDF page.
+// Every page is rasterized alongside its extracted text, and a letter-sized
+// page at Claude's 1568px long edge reaches the ~1,600 token image cap.
+const anthropicPDFPageTokens = anthropicMaxImageTokens
+
+// AnthropicPDFPageTokens returns the image overhead Claude adds for a PDF
+// with the given number of pages, on top of its extracted text.
+func AnthropicPDFPageTokens(pages int) int {
+	return pages * anthropicPDFPageTokens
+}
+
+// EstimatePDFPages returns Claude's per-page overhead for each PDF as a
+// document media estimate. Returns nil unless PDFPageOverhead is set and the
+// provider filter includes Anthropic.
+func (c *Counter) EstimatePDFPages(pdfs []fileops.PDFPages) []MediaEstimate {
+	if !c.pdfPageOverhead || !c.allowsProvider(ProviderAnthropic) {
+		return nil
+	}
+	media := make([]MediaEstimate, 0, len(pdfs))
+	for _, p := range pdfs {
+		media = append(media, MediaEstimate{
+			Path:     p.Path,
+			Kind:     MediaKindDocument,
+			Provider: ProviderAnthropic,
+			Pages:    p.Pages,
+			Tokens:   AnthropicPDFPageTokens(p.Pages),
+		})
+	}
+	return media
+}
@@ -0,0 +1,252 @@
+package tokenizer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrModelExists is returned when registering a model or alias under a name
+// that is already taken.
+var ErrModelExists = errors.New("model already registered")
+
+// Registry is a set of model metadata that is safe for concurrent use.
+// Models are found by exact name, by a registered alias, or by the name
+// normalized with NormalizeModelName, so claude-sonnet-4-5-20250929 and
+// openai/gpt-4.1 find claude-sonnet-4.5 and gpt-4.1.
+//
+// The package-level functions such as GetModelMetadata and ListModels read
+// DefaultRegistry, which the CLI's override files update. NewRegistry
+// returns an isolated copy of the built-in models that can be changed
+// without affecting anything else and passed to NewCounter through
+// CounterOptions.Registry.
+type Registry struct {
+	mu         sync.RWMutex
+	models     map[string]ModelMetadata
+	aliases    map[string]string
+	overridden map[string][]string
+}
+
+// defaultRegistry backs the package-level registry functions.
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the registry used by the package-level functions
+// and by counters created without CounterOptions.Registry.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// NewRegistry returns a registry holding a copy of the built-in models and
+// aliases.
+func NewRegistry() *Registry {
+	r := &Registry{
+		models:     make(map[string]ModelMetadata, len(modelRegistry)),
+		aliases:    make(map[string]string, len(modelAliases)),
+		overridden: make(map[string][]string),
+	}
+	for name, meta := range modelRegistry {
+		r.models[name] = meta.clone()
+	}
+	for alias, model := range modelAliases {
+		r.aliases[alias] = model
+	}
+	return r
+}
+
+// copy returns an isolated registry holding r's current models, aliases
+// and overridden fields.
+func (r *Registry) copy() *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c := &Registry{
+		models:     make(map[string]ModelMetadata, len(r.models)),
+		aliases:    make(map[string]string, len(r.aliases)),
+		overridden: make(map[string][]string, len(r.overridden)),
+	}
+	for name, meta := range r.models {
+		c.models[name] = meta.clone()
+	}
+	for alias, model := range r.aliases {
+		c.aliases[alias] = model
+	}
+	for name, fields := range r.overridden {
+		c.overridden[name] = append([]string(nil), fields...)
+	}
+	return c
+}
+
+// Register adds a model. It returns ErrModelExists if the name is already
+// a model or an alias.
+func (r *Registry) Register(meta ModelMetadata) error {
+	if meta.Name == "" {
+		return fmt.Errorf("registering model: name is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.taken(meta.Name) {
+		return fmt.Errorf("registering %s: %w", meta.Name, ErrModelExists)
+	}
+	r.models[meta.Name] = meta.clone()
+	return nil
+}
+
+// Update changes a registered model in place: fn receives a copy of its
+// metadata, and the copy replaces the model when fn returns. The name
+// cannot be changed. Aliases and normalized names are resolved. It returns
+// ErrModelNotFound for unknown models.
+func (r *Registry) Update(name string, fn func(*ModelMetadata)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name = r.resolve(name)
+	meta, ok := r.models[name]
+	if !ok {
+		return fmt.Errorf("updating %s: %w", name, ErrModelNotFound)
+	}
+	meta = meta.clone()
+	fn(&meta)
+	meta.Name = name
+	r.models[name] = meta
+	return nil
+}
+
+// RegisterAlias makes alias another name for a registered model, as for a
+// gateway name or a dated snapshot. It returns ErrModelExists if alias is
+// already a model or an alias, and ErrModelNotFound for unknown models.
+func (r *Registry) RegisterAlias(alias, model string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.taken(alias) {
+		return fmt.Errorf("registering alias %s: %w", alias, ErrModelExists)
+	}
+	model = r.resolve(model)
+	if _, ok := r.models[model]; !ok {
+		return fmt.Errorf("registering alias %s for %s: %w", alias, model, ErrModelNotFound)
+	}
+	r.aliases[alias] = model
+	return nil
+}
+
+// Lookup returns the metadata of a model by name or alias. The metadata is
+// a copy; change models with Update.
+func (r *Registry) Lookup(name string) (ModelMetadata, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	meta, ok := r.models[r.resolve(name)]
+	if !ok {
+		return ModelMetadata{}, false
+	}
+	return meta.clone(), true
+}
+
+// Resolve returns the registered name of a model given by name, alias or
+// a name that normalizes to either, and whether it was found.
+func (r *Registry) Resolve(name string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	name = r.resolve(name)
+	_, ok := r.models[name]
+	return name, ok
+}
+
+// Models returns copies of all models, sorted by name.
+func (r *Registry) Models() []ModelMetadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	models := make([]ModelMetadata, 0, len(r.models))
+	for _, meta := range r.models {
+		models = append(models, meta.clone())
+	}
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].Name < models[j].Name
+	})
+	return models
+}
+
+// Aliases returns a map of alias to model name.
+func (r *Registry) Aliases() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	aliases := make(map[string]string, len(r.aliases))
+	for alias, model := range r.aliases {
+		aliases[alias] = model
+	}
+	return aliases
+}
+
+// OverriddenFields returns the fields of a model set by registry overrides
+// (see ApplyOverrides), or nil for built-in values.
+func (r *Registry) OverriddenFields(model string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fields := r.overridden[r.resolve(model)]
+	if fields == nil {
+		return nil
+	}
+	return append([]string(nil), fields...)
+}
+
+// get returns a copy of a model's metadata, or nil if it is not registered.
+func (r *Registry) get(name string) *ModelMetadata {
+	if meta, ok := r.Lookup(name); ok {
+		return &meta
+	}
+	return nil
+}
+
+// resolve maps a name to a registered model name: an exact model name
+// first, then an alias, then the normalized name as a model or alias.
+// Unknown names are returned unchanged. Callers hold r.mu.
+func (r *Registry) resolve(name string) string {
+	if _, ok := r.models[name]; ok {
+		return name
+	}
+	if model, ok := r.aliases[name]; ok {
+		return model
+	}
+	normalized := NormalizeModelName(name)
+	if _, ok := r.models[normalized]; ok {
+		return normalized
+	}
+	if model, ok := r.aliases[normalized]; ok {
+		return model
+	}
+	return name
+}
+
+// taken reports whether name is a model or an alias. Callers hold r.mu.
+func (r *Registry) taken(name string) bool {
+	_, isModel := r.models[name]
+	_, isAlias := r.aliases[name]
+	return isModel || isAlias
+}
+
+// clone returns a copy of the metadata that shares no slices or maps.
+func (m ModelMetadata) clone() ModelMetadata {
+	if m.PriceTiers != nil {
+		m.PriceTiers = append([]PriceTier(nil), m.PriceTiers...)
+	}
+	if m.PriceHistory != nil {
+		m.PriceHistory = append([]PricePeriod(nil), m.PriceHistory...)
+	}
+	if m.Modalities != nil {
+		m.Modalities = append([]Modality(nil), m.Modalities...)
+	}
+	if m.ServiceTiers != nil {
+		tiers := make(map[ServiceTier]float64, len(m.ServiceTiers))
+		for tier, multiplier := range m.ServiceTiers {
+			tiers[tier] = multiplier
+		}
+		m.ServiceTiers = tiers
+	}
+	return m
+}
@@ -0,0 +1,234 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// CacheMinTokens is the shortest prompt prefix that providers cache.
+const CacheMinTokens = 1024
+
+// Fallback prompt-cache price multipliers, relative to the input price, for
+// models without cache prices in the registry. Anthropic charges 1.25x to
+// write the cache and 0.1x to read it; OpenAI caches automatically at no
+// write premium and discounts reads by half.
+const (
+	anthropicCacheRead  = 0.1
+	anthropicCacheWrite = 1.25
+	openAICacheRead     = 0.5
+	openAICacheWrite    = 1.0
+)
+
+// ReplayOptions configures CountReplay.
+type ReplayOptions struct {
+	Model string
+
+	// Cache assumes each request's prompt is cached, so the next request
+	// reads the previous prompt from the cache and writes only the new
+	// tokens. Prompts shorter than CacheMinTokens are not cached.
+	Cache bool
+
+	// CacheRead and CacheWrite override the model's cache price
+	// multipliers. Zero selects the model's registered cache prices.
+	CacheRead  float64
+	CacheWrite float64
+}
+
+// ReplayResult is the cumulative cost of replaying a conversation one
+// assistant turn at a time.
+type ReplayResult struct {
+	Model             string       `json:"model"`
+	Encoding          string       `json:"encoding"`
+	IsExact           bool         `json:"is_exact"`
+	ContextWindow     int          `json:"context_window,omitempty"`
+	Messages          int          `json:"messages"`
+	TranscriptTokens  int          `json:"transcript_tokens"`
+	Steps             []ReplayStep `json:"steps"`
+	TotalInputTokens  int          `json:"total_input_tokens"`
+	TotalOutputTokens int          `json:"total_output_tokens"`
+	CachedTokens      int          `json:"cached_tokens,omitempty"`
+	InputCost         float64      `json:"input_cost"`
+	OutputCost        float64      `json:"output_cost"`
+	TotalCost         float64      `json:"total_cost"`
+	UncachedCost      float64      `json:"uncached_cost,omitempty"`
+	CacheRead         float64      `json:"cache_read,omitempty"`
+	CacheWrite        float64      `json:"cache_write,omitempty"`
+	OverflowStep      int          `json:"overflow_step,omitempty"`
+}
+
+// ReplayStep is one model call: the history before an assistant message is
+// sent as input and the assistant message is the output. Step is 1-based
+// and Message is the index of the assistant message.
+type ReplayStep struct {
+	Step             int     `json:"step"`
+	Message          int     `json:"message"`
+	InputTokens      int     `json:"input_tokens"`
+	CachedTokens     int     `json:"cached_tokens,omitempty"`
+	CacheWriteTokens int     `json:"cache_write_tokens,omitempty"`
+	OutputTokens     int     `json:"output_tokens"`
+	Cost             float64 `json:"cost"`
+	CumulativeInput  int     `json:"cumulative_input"`
+	CumulativeOutput int     `json:"cumulative_output"`
+	CumulativeCost   float64 `json:"cumulative_cost"`
+	Overflow         bool    `json:"overflow,omitempty"`
+}
+
+// ParseTranscript parses a chat log. It accepts a chat request body (see
+// ParseChatRequest) or JSONL with one message per line, where a message may
+// be wrapped in a "message" field as agent logs do. JSONL lines without a
+// role, such as log metadata, are skipped.
+func ParseTranscript(data []byte) (*ChatRequest, error) {
+	data = bytes.TrimSpace(data)
+	if json.Valid(data) {
+		return ParseChatRequest(data)
+	}
+
+	req := &ChatRequest{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var wrapper struct {
+			Role    string          `json:"role"`
+			Message json.RawMessage `json:"message"`
+		}
+		if err := json.Unmarshal(line, &wrapper); err != nil {
+			return nil, fmt.Errorf("parsing transcript line %d: %w", lineNum, err)
+		}
+		if wrapper.Role == "" && len(wrapper.Message) > 0 && wrapper.Message[0] == '{' {
+			line = wrapper.Message
+		}
+
+		var msg ChatMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			return nil, fmt.Errorf("parsing transcript line %d: %w", lineNum, err)
+		}
+		if msg.Role == "" {
+			continue
+		}
+		req.Messages = append(req.Messages, msg)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading transcript: %w", err)
+	}
+
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("transcript has no messages")
+	}
+	return req, nil
+}
+
+// CountReplay replays a conversation the way an agent sends it: every
+// assistant message is produced by a request carrying the whole history
+// before it, plus tools. It reports the input and output tokens of each
+// step, the cumulative spend, and the first step whose input and output no
+// longer fit the model's context window.
+func (c *Counter) CountReplay(ctx context.Context, req *ChatRequest, opts ReplayOptions) (*ReplayResult, error) {
+	counted, err := c.CountChatRequest(ctx, req, opts.Model)
+	if err != nil {
+		return nil, err
+	}
+	_, _, meta := c.tokenizerForModel(opts.Model)
+
+	result := &ReplayResult{
+		Model:            counted.Model,
+		Encoding:         counted.Encoding,
+		IsExact:          counted.IsExact,
+		ContextWindow:    counted.ContextWindow,
+		Messages:         len(counted.Messages),
+		TranscriptTokens: counted.TotalTokens,
+		Steps:            []ReplayStep{},
+	}
+	if opts.Cache {
+		result.CacheRead, result.CacheWrite = cacheMultipliers(meta, opts)
+	}
+
+	// Every request carries the tools and the reply priming; messages are
+	// added as the history grows.
+	input := counted.Breakdown.Tools + counted.ReplyPriming
+	cachedPrefix := 0
+	for _, msg := range counted.Messages {
+		if msg.Role != "assistant" {
+			input += msg.Tokens
+			continue
+		}
+
+		step := ReplayStep{
+			Step:         len(result.Steps) + 1,
+			Message:      msg.Index,
+			InputTokens:  input,
+			OutputTokens: msg.ContentTokens,
+		}
+
+		var inputPrice, outputPrice float64
+		if meta != nil {
+			prices := meta.PricesFor(input)
+			inputPrice = prices.InputPricePer1M / 1_000_000.0
+			outputPrice = prices.OutputPricePer1M / 1_000_000.0
+		}
+		inputCost := float64(input) * inputPrice
+		outputCost := float64(step.OutputTokens) * outputPrice
+		if opts.Cache {
+			result.UncachedCost += inputCost + outputCost
+		}
+		if opts.Cache && input >= CacheMinTokens {
+			step.CachedTokens = cachedPrefix
+			step.CacheWriteTokens = input - cachedPrefix
+			inputCost = float64(step.CachedTokens)*inputPrice*result.CacheRead +
+				float64(step.CacheWriteTokens)*inputPrice*result.CacheWrite
+			cachedPrefix = input
+		}
+
+		step.Cost = inputCost + outputCost
+		result.TotalInputTokens += input
+		result.TotalOutputTokens += step.OutputTokens
+		result.CachedTokens += step.CachedTokens
+		result.InputCost += inputCost
+		result.OutputCost += outputCost
+		result.TotalCost += step.Cost
+
+		step.CumulativeInput = result.TotalInputTokens
+		step.CumulativeOutput = result.TotalOutputTokens
+		step.CumulativeCost = result.TotalCost
+		if window := contextWindow(meta, input+step.OutputTokens); window > 0 && input+step.OutputTokens > window {
+			step.Overflow = true
+			if result.OverflowStep == 0 {
+				result.OverflowStep = step.Step
+			}
+		}
+		result.Steps = append(result.Steps, step)
+
+		// The response joins the history with its framing.
+		input += msg.Tokens
+	}
+
+	return result, nil
+}
+
+// cacheMultipliers returns the cache read and write price multipliers for a
+// model, applying any overrides from opts.
+func cacheMultipliers(meta *ModelMetadata, opts ReplayOptions) (read, write float64) {
+	read, write = openAICacheRead, openAICacheWrite
+	switch {
+	case meta == nil:
+	case meta.CacheReadPricePer1M > 0 && meta.InputPricePer1M > 0:
+		read = meta.CacheReadPricePer1M / meta.InputPricePer1M
+		write = meta.CacheWritePricePer1M / meta.InputPricePer1M
+	case meta.Provider == ProviderAnthropic:
+		read, write = anthropicCacheRead, anthropicCacheWrite
+	}
+	if opts.CacheRead > 0 {
+		read = opts.CacheRead
+	}
+	if opts.CacheWrite > 0 {
+		write = opts.CacheWrite
+	}
+	return read, write
+}
@@ -0,0 +1,111 @@
+package tokenizer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the number of model names Suggest returns at most.
+const maxSuggestions = 3
+
+// SuggestModels returns the default registry's closest matches for an
+// unknown model name (see Registry.Suggest).
+func SuggestModels(name string) []string {
+	return defaultRegistry.Suggest(name)
+}
+
+// Suggest returns up to three registered model names closest to name by
+// edit distance, comparing the normalized name against model names and
+// aliases. Aliases are reported as their model. Names too far from every
+// model return nil.
+func (r *Registry) Suggest(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	normalized := NormalizeModelName(strings.ToLower(name))
+	if normalized == "" {
+		return nil
+	}
+	limit := max(2, len(normalized)/3)
+
+	best := map[string]int{}
+	consider := func(candidate, model string) {
+		d := editDistance(normalized, candidate)
+		if d > limit {
+			return
+		}
+		if prev, ok := best[model]; !ok || d < prev {
+			best[model] = d
+		}
+	}
+	for model := range r.models {
+		consider(model, model)
+	}
+	for alias, model := range r.aliases {
+		consider(alias, model)
+	}
+
+	suggestions := make([]string, 0, len(best))
+	for model := range best {
+		suggestions = append(suggestions, model)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		di, dj := best[suggestions[i]], best[suggestions[j]]
+		if di != dj {
+			return di < dj
+		}
+		return suggestions[i] < suggestions[j]
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	if len(suggestions) == 0 {
+		return nil
+	}
+	return suggestions
+}
+
+// DidYouMean formats suggestions as "did you mean a, b or c?", or returns
+// "" when there are none.
+func DidYouMean(suggestions []string) string {
+	switch len(suggestions) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("did you mean %s?", suggestions[0])
+	default:
+		last := len(suggestions) - 1
+		return fmt.Sprintf("did you mean %s or %s?", strings.Join(suggestions[:last], ", "), suggestions[last])
+	}
+}
+
+// unknownModelError returns ErrModelNotFound for name with the registry's
+// suggestions appended.
+func (r *Registry) unknownModelError(name string) error {
+	if hint := DidYouMean(r.Suggest(name)); hint != "" {
+		return fmt.Errorf("%w (%s)", ErrModelNotFound, hint)
+	}
+	return ErrModelNotFound
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,574 @@
+package tokenizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lancekrogers/go-token-counter/tokenizer/jinja"
+)
+
+// ChatTemplate renders chat messages into the prompt text an open-weight
+// model is trained on, so the role headers and turn delimiters it adds can
+// be counted. Occurrences of SpecialTokens in the rendered prompt count as
+// one token each; the text between them is counted with the model's
+// tokenizer.
+type ChatTemplate struct {
+	Name          string
+	BOSToken      string
+	EOSToken      string
+	SpecialTokens []string
+	tmpl          *jinja.Template
+}
+
+// NewChatTemplate parses a Jinja chat template in the HuggingFace format.
+// The template receives messages, add_generation_prompt, bos_token and
+// eos_token.
+func NewChatTemplate(name, source, bosToken, eosToken string, specialTokens []string) (*ChatTemplate, error) {
+	tmpl, err := jinja.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("parsing chat template %s: %w", name, err)
+	}
+	return &ChatTemplate{
+		Name:          name,
+		BOSToken:      bosToken,
+		EOSToken:      eosToken,
+		SpecialTokens: specialTokens,
+		tmpl:          tmpl,
+	}, nil
+}
+
+// builtinChatTemplate is the source and special tokens of a built-in
+// template. Sources follow the models' published chat templates without
+// their tool-calling sections; tools are counted separately.
+type builtinChatTemplate struct {
+	source  string
+	bos     string
+	eos     string
+	special []string
+}
+
+var builtinChatTemplates = map[string]builtinChatTemplate{
+	// Llama 3.1 and later always open with a system header carrying the
+	// knowledge cutoff and date.
+	"llama3": {
+		source: `{{- bos_token }}
+{%- if messages[0]['role'] == 'system' %}
+    {%- set system_message = messages[0]['content'] | trim %}
+    {%- set messages = messages[1:] %}
+{%- else %}
+    {%- set system_message = "" %}
+{%- endif %}
+{%- if not date_string is defined %}
+    {%- set date_string = "26 Jul 2024" %}
+{%- endif %}
+{{- "<|start_header_id|>system<|end_header_id|>\n\n" }}
+{{- "Cutting Knowledge Date: December 2023\n" }}
+{{- "Today Date: " + date_string + "\n\n" }}
+{{- system_message }}
+{{- "<|eot_id|>" }}
+{%- for message in messages %}
+    {{- '<|start_header_id|>' + message['role'] + '<|end_header_id|>\n\n' + message['content'] | trim + '<|eot_id|>' }}
+{%- endfor %}
+{%- if add_generation_prompt %}
+    {{- '<|start_header_id|>assistant<|end_header_id|>\n\n' }}
+{%- endif %}`,
+		bos:     "<|begin_of_text|>",
+		eos:     "<|eot_id|>",
+		special: []string{"<|begin_of_text|>", "<|start_header_id|>", "<|end_header_id|>", "<|eot_id|>", "<|eom_id|>", "<|python_tag|>"},
+	},
+	"llama4": {
+		source: `{{- bos_token }}
+{%- for message in messages %}
+    {{- '<|header_start|>' + message['role'] + '<|header_end|>\n\n' + message['content'] | trim + '<|eot|>' }}
+{%- endfor %}
+{%- if add_generation_prompt %}
+    {{- '<|header_start|>assistant<|header_end|>\n\n' }}
+{%- endif %}`,
+		bos:     "<|begin_of_text|>",
+		eos:     "<|eot|>",
+		special: []string{"<|begin_of_text|>", "<|header_start|>", "<|header_end|>", "<|eot|>", "<|eom|>", "<|python_start|>", "<|python_end|>"},
+	},
+	"chatml": {
+		source: `{%- for message in messages %}
+    {{- '<|im_start|>' + message['role'] + '\n' + message['content'] + '<|im_end|>\n' }}
+{%- endfor %}
+{%- if add_generation_prompt %}
+    {{- '<|im_start|>assistant\n' }}
+{%- endif %}`,
+		eos:     "<|im_end|>",
+		special: []string{"<|im_start|>", "<|im_end|>", "<|endoftext|>"},
+	},
+	// Qwen 2.5 is ChatML with a default system prompt.
+	"qwen2.5": {
+		source: `{%- if messages[0]['role'] != 'system' %}
+    {{- '<|im_start|>system\nYou are Qwen, created by Alibaba Cloud. You are a helpful assistant.<|im_end|>\n' }}
+{%- endif %}
+{%- for message in messages %}
+    {{- '<|im_start|>' + message['role'] + '\n' + message['content'] + '<|im_end|>\n' }}
+{%- endfor %}
+{%- if add_generation_prompt %}
+    {{- '<|im_start|>assistant\n' }}
+{%- endif %}`,
+		eos:     "<|im_end|>",
+		special: []string{"<|im_start|>", "<|im_end|>", "<|endoftext|>"},
+	},
+	// Mistral has no system role; the system prompt is prepended to the
+	// first user turn.
+	"mistral": {
+		source: `{{- bos_token }}
+{%- if messages[0]['role'] == 'system' %}
+    {%- set system_message = messages[0]['content'] %}
+    {%- set messages = messages[1:] %}
+{%- endif %}
+{%- for message in messages %}
+    {%- if message['role'] == 'user' %}
+        {%- if loop.first and system_message is defined %}
+            {{- '[INST] ' + system_message + '\n\n' + message['content'] + ' [/INST]' }}
+        {%- else %}
+            {{- '[INST] ' + message['content'] + ' [/INST]' }}
+        {%- endif %}
+    {%- elif message['role'] == 'assistant' %}
+        {{- message['content'] + eos_token }}
+    {%- elif message['role'] == 'tool' %}
+        {{- '[TOOL_RESULTS] ' + message['content'] + '[/TOOL_RESULTS]' }}
+    {%- endif %}
+{%- endfor %}`,
+		bos:     "<s>",
+		eos:     "</s>",
+		special: []string{"<s>", "</s>", "[INST]", "[/INST]", "[TOOL_RESULTS]", "[/TOOL_RESULTS]"},
+	},
+	"phi3": {
+		source: `{%- for message in messages %}
+    {{- '<|' + message['role'] + '|>\n' + message['content'] + '<|end|>\n' }}
+{%- endfor %}
+{%- if add_generation_prompt %}
+    {{- '<|assistant|>\n' }}
+{%- else %}
+    {{- eos_token }}
+{%- endif %}`,
+		eos:     "<|endoftext|>",
+		special: []string{"<|system|>", "<|user|>", "<|assistant|>", "<|end|>", "<|endoftext|>"},
+	},
+	"deepseek-v2": {
+		source: `{{- bos_token }}
+{%- for message in messages %}
+    {%- if message['role'] == 'user' %}
+        {{- 'User: ' + message['content'] + '\n\n' }}
+    {%- elif message['role'] == 'assistant' %}
+        {{- 'Assistant: ' + message['content'] + eos_token }}
+    {%- elif message['role'] == 'system' %}
+        {{- message['content'] + '\n\n' }}
+    {%- endif %}
+{%- endfor %}
+{%- if add_generation_prompt %}
+    {{- 'Assistant:' }}
+{%- endif %}`,
+		bos:     "<｜begin▁of▁sentence｜>",
+		eos:     "<｜end▁of▁sentence｜>",
+		special: []string{"<｜begin▁of▁sentence｜>", "<｜end▁of▁sentence｜>"},
+	},
+	// DeepSeek V3 places all system prompts before the first turn.
+	"deepseek-v3": {
+		source: `{{- bos_token }}
+{%- for message in messages if message['role'] == 'system' %}
+    {{- message['content'] }}
+{%- endfor %}
+{%- for message in messages %}
+    {%- if message['role'] == 'user' %}
+        {{- '<｜User｜>' + message['content'] }}
+    {%- elif message['role'] == 'assistant' %}
+        {{- '<｜Assistant｜>' + message['content'] + eos_token }}
+    {%- elif message['role'] == 'tool' %}
+        {{- '<｜tool▁outputs▁begin｜><｜tool▁output▁begin｜>' + message['content'] + '<｜tool▁output▁end｜><｜tool▁outputs▁end｜>' }}
+    {%- endif %}
+{%- endfor %}
+{%- if add_generation_prompt %}
+    {{- '<｜Assistant｜>' }}
+{%- endif %}`,
+		bos: "<｜begin▁of▁sentence｜>",
+		eos: "<｜end▁of▁sentence｜>",
+		special: []string{"<｜begin▁of▁sentence｜>", "<｜end▁of▁sentence｜>", "<｜User｜>", "<｜Assistant｜>",
+			"<｜tool▁outputs▁begin｜>", "<｜tool▁outputs▁end｜>", "<｜tool▁output▁begin｜>", "<｜tool▁output▁end｜>"},
+	},
+}
+
+// chatTemplatePrefixes maps unregistered model name prefixes to built-in
+// templates.
+var chatTemplatePrefixes = map[string]string{
+	"mistral-":   "mistral",
+	"mixtral-":   "mistral",
+	"ministral-": "mistral",
+}
+
+// ChatTemplateNames returns the names of the built-in chat templates in
+// sorted order.
+func ChatTemplateNames() []string {
+	names := make([]string, 0, len(builtinChatTemplates))
+	for name := range builtinChatTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuiltinChatTemplate returns the built-in chat template with the given name.
+func BuiltinChatTemplate(name string) (*ChatTemplate, error) {
+	b, ok := builtinChatTemplates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown chat template %q, valid options: %s", name, strings.Join(ChatTemplateNames(), ", "))
+	}
+	return &ChatTemplate{
+		Name:          name,
+		BOSToken:      b.bos,
+		EOSToken:      b.eos,
+		SpecialTokens: b.special,
+		tmpl:          jinja.MustParse(b.source),
+	}, nil
+}
+
+// ChatTemplateForModel returns the built-in chat template for a model, or
+// nil if the model has none (OpenAI and Anthropic models, for example).
+func ChatTemplateForModel(model string) *ChatTemplate {
+	return chatTemplateForModel(defaultRegistry, model)
+}
+
+// chatTemplateForModel looks up the built-in chat template of a model in r.
+func chatTemplateForModel(r *Registry, model string) *ChatTemplate {
+	name := ""
+	if meta := r.get(model); meta != nil {
+		name = meta.ChatTemplate
+	} else {
+		for prefix, tmpl := range chatTemplatePrefixes {
+			if strings.HasPrefix(model, prefix) {
+				name = tmpl
+				break
+			}
+		}
+	}
+	if name == "" {
+		return nil
+	}
+	tmpl, err := BuiltinChatTemplate(name)
+	if err != nil {
+		return nil
+	}
+	return tmpl
+}
+
+// LoadChatTemplate loads the chat_template of a HuggingFace
+// tokenizer_config.json, or of the tokenizer_config.json inside a model
+// directory. When the config has no chat_template, a chat_template.jinja
+// file next to it is used. Special tokens are taken from the config's
+// added_tokens_decoder and bos/eos tokens.
+func LoadChatTemplate(path string) (*ChatTemplate, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "tokenizer_config.json")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg struct {
+		ChatTemplate       json.RawMessage `json:"chat_template"`
+		BOSToken           json.RawMessage `json:"bos_token"`
+		EOSToken           json.RawMessage `json:"eos_token"`
+		AddedTokensDecoder map[string]struct {
+			Content string `json:"content"`
+			Special bool   `json:"special"`
+		} `json:"added_tokens_decoder"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	source, err := configChatTemplate(cfg.ChatTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if source == "" {
+		jinjaPath := filepath.Join(filepath.Dir(path), "chat_template.jinja")
+		raw, err := os.ReadFile(jinjaPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s has no chat_template", path)
+		}
+		source = string(raw)
+	}
+
+	bos, eos := configToken(cfg.BOSToken), configToken(cfg.EOSToken)
+	var special []string
+	for _, t := range cfg.AddedTokensDecoder {
+		if t.Special && t.Content != "" {
+			special = append(special, t.Content)
+		}
+	}
+	for _, t := range []string{bos, eos} {
+		if t != "" {
+			special = append(special, t)
+		}
+	}
+	sort.Strings(special)
+
+	return NewChatTemplate(path, source, bos, eos, special)
+}
+
+// configChatTemplate reads a chat_template value, which is either a template
+// string or a list of named templates of which "default" is used.
+func configChatTemplate(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var source string
+	if json.Unmarshal(raw, &source) == nil {
+		return source, nil
+	}
+	var named []struct {
+		Name     string `json:"name"`
+		Template string `json:"template"`
+	}
+	if err := json.Unmarshal(raw, &named); err != nil {
+		return "", fmt.Errorf("chat_template must be a string or a list of named templates")
+	}
+	for _, t := range named {
+		if t.Name == "default" {
+			return t.Template, nil
+		}
+	}
+	if len(named) > 0 {
+		return named[0].Template, nil
+	}
+	return "", nil
+}
+
+// configToken reads a special token that is either a string or an added
+// token object with a "content" field.
+func configToken(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var obj struct {
+		Content string `json:"content"`
+	}
+	_ = json.Unmarshal(raw, &obj)
+	return obj.Content
+}
+
+// Render renders messages into a prompt. With addGenerationPrompt the
+// header that starts the assistant's reply is appended.
+func (t *ChatTemplate) Render(messages []ChatMessage, addGenerationPrompt bool) (string, error) {
+	items := make([]any, len(messages))
+	for i, msg := range messages {
+		items[i] = templateMessage(msg)
+	}
+	out, err := t.tmpl.Render(map[string]any{
+		"messages":              items,
+		"add_generation_prompt": addGenerationPrompt,
+		"bos_token":             t.BOSToken,
+		"eos_token":             t.EOSToken,
+	})
+	if err != nil {
+		return "", fmt.Errorf("rendering chat template %s: %w", t.Name, err)
+	}
+	return out, nil
+}
+
+// templateMessage converts a message into the dict shape HuggingFace
+// templates expect. Tool call arguments are decoded from JSON when possible.
+func templateMessage(msg ChatMessage) map[string]any {
+	m := map[string]any{"role": msg.Role, "content": msg.Content}
+	if msg.Name != "" {
+		m["name"] = msg.Name
+	}
+	if msg.ToolCallID != "" {
+		m["tool_call_id"] = msg.ToolCallID
+	}
+	if len(msg.ToolCalls) > 0 {
+		calls := make([]any, len(msg.ToolCalls))
+		for i, call := range msg.ToolCalls {
+			var args any = call.Arguments
+			var decoded map[string]any
+			if json.Unmarshal([]byte(call.Arguments), &decoded) == nil {
+				args = decoded
+			}
+			calls[i] = map[string]any{
+				"id":       call.ID,
+				"type":     "function",
+				"function": map[string]any{"name": call.Name, "arguments": args},
+			}
+		}
+		m["tool_calls"] = calls
+	}
+	return m
+}
+
+// CountTokens counts rendered template text, counting each special token as
+// a single token.
+func (t *ChatTemplate) CountTokens(tok Tokenizer, text string) (int, error) {
+	total := 0
+	for text != "" {
+		at, length := t.nextSpecial(text)
+		if at < 0 {
+			n, err := tok.CountTokens(text)
+			return total + n, err
+		}
+		if at > 0 {
+			n, err := tok.CountTokens(text[:at])
+			if err != nil {
+				return 0, err
+			}
+			total += n
+		}
+		total++
+		text = text[at+length:]
+	}
+	return total, nil
+}
+
+// nextSpecial finds the earliest, longest special token in text.
+func (t *ChatTemplate) nextSpecial(text string) (int, int) {
+	at, length := -1, 0
+	for _, sp := range t.SpecialTokens {
+		if sp == "" {
+			continue
+		}
+		i := strings.Index(text, sp)
+		if i < 0 {
+			continue
+		}
+		if at < 0 || i < at || (i == at && len(sp) > length) {
+			at, length = i, len(sp)
+		}
+	}
+	return at, length
+}
+
+// countAdded counts the tokens that rendering next adds over prev. Only the
+// text after their shared prefix, cut back to the end of a special token so
+// no token is split, is tokenized.
+func (t *ChatTemplate) countAdded(tok Tokenizer, prev, next string) (int, error) {
+	common := 0
+	for common < len(prev) && common < len(next) && prev[common] == next[common] {
+		common++
+	}
+	cut := 0
+	for _, sp := range t.SpecialTokens {
+		if sp == "" {
+			continue
+		}
+		if i := strings.LastIndex(next[:common], sp); i >= 0 && i+len(sp) > cut {
+			cut = i + len(sp)
+		}
+	}
+
+	added, err := t.CountTokens(tok, next[cut:])
+	if err != nil {
+		return 0, err
+	}
+	removed, err := t.CountTokens(tok, prev[cut:])
+	if err != nil {
+		return 0, err
+	}
+	return added - removed, nil
+}
+
+// chatTemplateFor returns the template applied to chat requests for model,
+// or nil when messages use the OpenAI framing overhead.
+func (c *Counter) chatTemplateFor(model string) *ChatTemplate {
+	if c.noChatTemplate {
+		return nil
+	}
+	if c.chatTemplate != nil {
+		return c.chatTemplate
+	}
+	return chatTemplateForModel(c.registry, model)
+}
+
+// countTemplatedChat counts a chat request by rendering it through a chat
+// template. Each message is charged the tokens its turn adds to the
+// rendered prompt, so any fixed preamble falls to the first message, and
+// the generation prompt is reported as reply priming. Templates that render
+// a turn differently once later turns follow (Qwen3 drops the reasoning of
+// earlier assistant turns, for example) shift tokens between neighbouring
+// messages; the total is unaffected.
+func (c *Counter) countTemplatedChat(ctx context.Context, req *ChatRequest, model string, tmpl *ChatTemplate) (*ChatCountResult, error) {
+	tok, encoding, meta := c.tokenizerForModel(model)
+	messages := req.Messages
+
+	result := &ChatCountResult{
+		Model:    model,
+		Encoding: encoding,
+		Template: tmpl.Name,
+		IsExact:  tok.IsExact(),
+		Messages: make([]MessageCount, 0, len(messages)),
+	}
+	total := 0
+	prev := ""
+	for i, msg := range messages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		rendered, err := tmpl.Render(messages[:i+1], false)
+		if err != nil {
+			return nil, err
+		}
+		turn, err := tmpl.countAdded(tok, prev, rendered)
+		if err != nil {
+			return nil, fmt.Errorf("counting message %d: %w", i, err)
+		}
+		prev = rendered
+
+		content, err := countMessageContent(tok, msg)
+		if err != nil {
+			return nil, fmt.Errorf("counting message %d: %w", i, err)
+		}
+		content = max(min(content, turn), 0)
+		framing := turn - content
+		images := result.countChatImages(msg.Images)
+
+		result.Messages = append(result.Messages, MessageCount{
+			Index:          i,
+			Role:           msg.Role,
+			ContentTokens:  content + images,
+			OverheadTokens: framing,
+			Tokens:         turn + images,
+		})
+		if isSystemRole(msg.Role) {
+			result.Breakdown.System += turn
+		} else {
+			result.Breakdown.Messages += turn
+		}
+		result.Breakdown.Images += images
+		total += turn + images
+	}
+
+	full, err := tmpl.Render(messages, true)
+	if err != nil {
+		return nil, err
+	}
+	priming, err := tmpl.countAdded(tok, prev, full)
+	if err != nil {
+		return nil, fmt.Errorf("counting generation prompt: %w", err)
+	}
+	result.ReplyPriming = priming
+	total += priming
+
+	toolTokens, err := countTools(tok, req, model, meta)
+	if err != nil {
+		return nil, fmt.Errorf("counting tools: %w", err)
+	}
+	result.Breakdown.Tools = toolTokens
+	total += toolTokens
+
+	result.TotalTokens = total
+	result.InputCost = inputCost(meta, total)
+	result.ContextWindow = contextWindow(meta, total)
+	result.OutputHeadroom = outputHeadroom(meta, total)
+	return result, nil
+}
@@ -104,6 +104,9 @@ func getEncodingForModel(model string) (string, bool) {
 	if strings.HasPrefix(model, "gpt-4") || strings.HasPrefix(model, "gpt-3.5") {
 		return "cl100k_base", true
 	}
+	if strings.HasPrefix(model, "text-embedding-") {
+		return "cl100k_base", true
+	}
 
 	if strings.HasPrefix(model, "llama-") ||
 		strings.HasPrefix(model, "deepseek-") ||
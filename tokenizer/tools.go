@@ -0,0 +1,324 @@
+package tokenizer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ToolDefinition is a tool or function schema offered to the model.
+// OpenAI "tools", legacy "functions", Responses API function tools and
+// Anthropic tools (input_schema) are all normalized to this form.
+type ToolDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// OpenAI renders function definitions into the system prompt as a TypeScript
+// namespace. These adjustments follow the widely used reverse-engineered
+// accounting of that rendering.
+const (
+	// openAIToolsOverhead covers the "# Tools" / "## functions" headers.
+	openAIToolsOverhead = 9
+
+	// openAIToolsSystemDiscount is removed when a system message already exists,
+	// since the tools are merged into it rather than framed separately.
+	openAIToolsSystemDiscount = 4
+)
+
+// anthropicToolSystemPrompt is the size of the tool-use system prompt Anthropic
+// injects when tools are present, per tool_choice mode.
+type anthropicToolSystemPrompt struct {
+	auto int // tool_choice auto or none
+	any  int // tool_choice any or tool
+}
+
+// anthropicToolPrompts lists per-model tool-use system prompt sizes from
+// Anthropic's tool use documentation. Models not listed use
+// defaultAnthropicToolPrompt.
+var anthropicToolPrompts = map[string]anthropicToolSystemPrompt{
+	"claude-haiku-3.5": {auto: 264, any: 340},
+	"claude-haiku-3":   {auto: 264, any: 340},
+	"claude-opus-3":    {auto: 530, any: 281},
+}
+
+// defaultAnthropicToolPrompt applies to Claude 4.x and later models.
+var defaultAnthropicToolPrompt = anthropicToolSystemPrompt{auto: 346, any: 313}
+
+// parseToolDefinitions normalizes a list of raw tool or function JSON objects.
+// Entries without a name (for example provider-hosted tools) are skipped.
+func parseToolDefinitions(raws []json.RawMessage) ([]ToolDefinition, error) {
+	tools := make([]ToolDefinition, 0, len(raws))
+	for i, raw := range raws {
+		var t struct {
+			Type        string          `json:"type"`
+			Name        string          `json:"name"`
+			Description string          `json:"description"`
+			Parameters  json.RawMessage `json:"parameters"`
+			InputSchema json.RawMessage `json:"input_schema"`
+			Function    *struct {
+				Name        string          `json:"name"`
+				Description string          `json:"description"`
+				Parameters  json.RawMessage `json:"parameters"`
+			} `json:"function"`
+		}
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return nil, fmt.Errorf("parsing tool %d: %w", i, err)
+		}
+
+		def := ToolDefinition{Name: t.Name, Description: t.Description, Parameters: t.Parameters}
+		if t.Function != nil {
+			def = ToolDefinition{Name: t.Function.Name, Description: t.Function.Description, Parameters: t.Function.Parameters}
+		}
+		if len(t.InputSchema) > 0 {
+			def.Parameters = t.InputSchema
+		}
+		if def.Name == "" {
+			continue
+		}
+		tools = append(tools, def)
+	}
+	return tools, nil
+}
+
+// parseToolChoice reduces a tool_choice value to its mode name
+// ("auto", "none", "any", "required", "tool", or "function").
+func parseToolChoice(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var obj struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(raw, &obj) == nil {
+		return obj.Type
+	}
+	return ""
+}
+
+// countOpenAITools counts tool definitions as OpenAI renders them into the prompt.
+func countOpenAITools(tok Tokenizer, tools []ToolDefinition, hasSystem bool) (int, error) {
+	if len(tools) == 0 {
+		return 0, nil
+	}
+	n, err := tok.CountTokens(formatOpenAIFunctions(tools))
+	if err != nil {
+		return 0, err
+	}
+	n += openAIToolsOverhead
+	if hasSystem {
+		n -= openAIToolsSystemDiscount
+	}
+	return n, nil
+}
+
+// countAnthropicTools counts tool definitions as Anthropic serializes them:
+// the JSON schemas plus the tool-use system prompt for the tool_choice mode.
+func countAnthropicTools(tok Tokenizer, tools []ToolDefinition, model, toolChoice string) (int, error) {
+	if len(tools) == 0 {
+		return 0, nil
+	}
+	data, err := json.Marshal(tools)
+	if err != nil {
+		return 0, fmt.Errorf("serializing tools: %w", err)
+	}
+	n, err := tok.CountTokens(string(data))
+	if err != nil {
+		return 0, err
+	}
+
+	prompt, ok := anthropicToolPrompts[model]
+	if !ok {
+		prompt = defaultAnthropicToolPrompt
+	}
+	if toolChoice == "any" || toolChoice == "tool" {
+		return n + prompt.any, nil
+	}
+	return n + prompt.auto, nil
+}
+
+// formatOpenAIFunctions renders tool definitions in OpenAI's TypeScript-like
+// namespace format.
+func formatOpenAIFunctions(tools []ToolDefinition) string {
+	lines := []string{"namespace functions {", ""}
+	for _, t := range tools {
+		if t.Description != "" {
+			lines = append(lines, "// "+t.Description)
+		}
+		schema := parseSchema(t.Parameters)
+		if len(schema.order) > 0 {
+			lines = append(lines, fmt.Sprintf("type %s = (_: {", t.Name))
+			lines = append(lines, formatObjectProperties(schema, 0))
+			lines = append(lines, "}) => any;")
+		} else {
+			lines = append(lines, fmt.Sprintf("type %s = () => any;", t.Name))
+		}
+		lines = append(lines, "")
+	}
+	lines = append(lines, "} // namespace functions")
+	return strings.Join(lines, "\n")
+}
+
+// jsonSchema is the subset of a JSON schema needed to render tool signatures.
+// Property order is preserved from the source document.
+type jsonSchema struct {
+	Type        string
+	Description string
+	Enum        []json.RawMessage
+	Required    []string
+	Items       *jsonSchema
+	properties  map[string]*jsonSchema
+	order       []string
+}
+
+// parseSchema decodes a JSON schema, tolerating malformed input by returning
+// an empty schema.
+func parseSchema(raw json.RawMessage) *jsonSchema {
+	s := &jsonSchema{}
+	if len(raw) == 0 {
+		return s
+	}
+
+	var head struct {
+		Type        json.RawMessage            `json:"type"`
+		Description string                     `json:"description"`
+		Enum        []json.RawMessage          `json:"enum"`
+		Required    []string                   `json:"required"`
+		Items       json.RawMessage            `json:"items"`
+		Properties  map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return s
+	}
+
+	s.Type = schemaType(head.Type)
+	s.Description = head.Description
+	s.Enum = head.Enum
+	s.Required = head.Required
+	if len(head.Items) > 0 {
+		s.Items = parseSchema(head.Items)
+	}
+	if len(head.Properties) > 0 {
+		s.properties = make(map[string]*jsonSchema, len(head.Properties))
+		for name, prop := range head.Properties {
+			s.properties[name] = parseSchema(prop)
+		}
+		s.order = propertyOrder(raw)
+	}
+	return s
+}
+
+// schemaType returns the first non-null type of a "type" value, which may be
+// a string or an array of strings.
+func schemaType(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var types []string
+	if json.Unmarshal(raw, &types) == nil {
+		for _, t := range types {
+			if t != "null" {
+				return t
+			}
+		}
+	}
+	return ""
+}
+
+// propertyOrder returns the keys of a schema's "properties" object in
+// document order.
+func propertyOrder(raw json.RawMessage) []string {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(raw, &obj) != nil {
+		return nil
+	}
+	props, ok := obj["properties"]
+	if !ok {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(props))
+	if _, err := dec.Token(); err != nil {
+		return nil
+	}
+
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return keys
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return keys
+		}
+		keys = append(keys, key)
+
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return keys
+		}
+	}
+	return keys
+}
+
+func formatObjectProperties(s *jsonSchema, indent int) string {
+	required := make(map[string]bool, len(s.Required))
+	for _, r := range s.Required {
+		required[r] = true
+	}
+
+	lines := []string{}
+	for _, name := range s.order {
+		prop := s.properties[name]
+		if prop.Description != "" && indent < 2 {
+			lines = append(lines, "// "+prop.Description)
+		}
+		if required[name] {
+			lines = append(lines, fmt.Sprintf("%s: %s,", name, formatSchemaType(prop, indent)))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s?: %s,", name, formatSchemaType(prop, indent)))
+		}
+	}
+
+	pad := strings.Repeat(" ", indent)
+	for i, line := range lines {
+		lines[i] = pad + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatSchemaType(s *jsonSchema, indent int) string {
+	switch s.Type {
+	case "string", "number", "integer":
+		if len(s.Enum) > 0 {
+			values := make([]string, len(s.Enum))
+			for i, v := range s.Enum {
+				values[i] = string(v)
+			}
+			return strings.Join(values, " | ")
+		}
+		if s.Type == "integer" {
+			return "number"
+		}
+		return s.Type
+	case "array":
+		if s.Items != nil {
+			return formatSchemaType(s.Items, indent) + "[]"
+		}
+		return "any[]"
+	case "boolean", "null":
+		return s.Type
+	case "object":
+		return strings.Join([]string{"{", formatObjectProperties(s, indent+2), "}"}, "\n")
+	}
+	return "any"
+}
@@ -1,6 +1,9 @@
 package tokenizer
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // Sentinel errors for common failure modes.
 var (
@@ -19,32 +22,163 @@ var (
 
 // CountResult represents the result of token counting.
 type CountResult struct {
-	FilePath    string         `json:"file_path"`
-	IsDirectory bool           `json:"is_directory,omitempty"`
-	FileCount   int            `json:"file_count,omitempty"`
-	FileSize    int            `json:"file_size"`
-	Characters  int            `json:"characters"`
-	Words       int            `json:"words"`
-	Lines       int            `json:"lines"`
-	Methods     []MethodResult `json:"methods"`
-	Costs       []CostEstimate `json:"costs,omitempty"`
-}
-
-// MethodResult represents token count for a specific method.
+	FilePath    string          `json:"file_path"`
+	IsDirectory bool            `json:"is_directory,omitempty"`
+	FileCount   int             `json:"file_count,omitempty"`
+	FileSize    int             `json:"file_size"`
+	Characters  int             `json:"characters"`
+	Words       int             `json:"words"`
+	Lines       int             `json:"lines"`
+	Pages       int             `json:"pages,omitempty"`
+	Methods     []MethodResult  `json:"methods"`
+	Media       []MediaEstimate `json:"media,omitempty"`
+	Costs       []CostEstimate  `json:"costs,omitempty"`
+	Budget      *BudgetResult   `json:"budget,omitempty"`
+}
+
+// MethodResult represents token count for a specific method. Encoding is
+// set for tokenizer methods and empty for approximations. ContextWindow and
+// OutputHeadroom are set for a registered model (see
+// ModelMetadata.OutputHeadroom).
 type MethodResult struct {
-	Name          string `json:"name"`
-	DisplayName   string `json:"display_name"`
-	Tokens        int    `json:"tokens"`
-	IsExact       bool   `json:"is_exact"`
-	ContextWindow int    `json:"context_window,omitempty"`
+	Name           string `json:"name"`
+	DisplayName    string `json:"display_name"`
+	Encoding       string `json:"encoding,omitempty"`
+	Tokens         int    `json:"tokens"`
+	IsExact        bool   `json:"is_exact"`
+	ContextWindow  int    `json:"context_window,omitempty"`
+	OutputHeadroom int    `json:"output_headroom,omitempty"`
+}
+
+// Media kinds for non-text inputs.
+const (
+	MediaKindImage    = "image"
+	MediaKindAudio    = "audio"
+	MediaKindDocument = "document"
+)
+
+// MediaEstimate is a token estimate for a non-text input file under one
+// provider's pricing rules. Audio estimates are per model and carry a cost;
+// document estimates carry the per-page overhead of a PDF.
+type MediaEstimate struct {
+	Path            string   `json:"path"`
+	Kind            string   `json:"kind"`
+	Provider        Provider `json:"provider"`
+	Model           string   `json:"model,omitempty"`
+	Width           int      `json:"width,omitempty"`
+	Height          int      `json:"height,omitempty"`
+	Detail          string   `json:"detail,omitempty"`
+	DurationSeconds float64  `json:"duration_seconds,omitempty"`
+	Pages           int      `json:"pages,omitempty"`
+	Tokens          int      `json:"tokens"`
+	Cost            float64  `json:"cost,omitempty"`
 }
 
-// CostEstimate represents cost estimation for a model.
+// Token sources of a cost estimate's input count.
+const (
+	TokenSourceExact     = "exact"     // the model's own exact tokenizer
+	TokenSourceProxy     = "proxy"     // an exact count from another encoding
+	TokenSourceEstimated = "estimated" // an approximation
+)
+
+// CostEstimate represents cost estimation for a model. Tokens, Cost and
+// RatePer1M describe the input without caching; the output fields are zero
+// unless an output estimate was requested. TokenSource tells how Tokens
+// was obtained for the model.
+//
+// When a cached prefix is given and the model supports prompt caching,
+// CachedTokens of the input are priced from the cache: UncachedCost covers
+// the rest of the input, CacheWriteCost is paid by the request that writes
+// the prefix and CacheReadCost by every request that reads it. CacheSavings
+// compares a cache hit with the uncached Cost.
+//
+// TotalCost is the input cost, priced as a cache hit when caching applies,
+// plus the output cost. WarmCache marks such a total: it assumes the prefix
+// is already cached, and the request that writes it pays CacheWriteCost in
+// place of CacheReadCost. TierAbove is the threshold of the long-context
+// price tier the input falls in, or 0 for the base prices.
+//
+// For a non-standard ServiceTier, ServiceTierCost is TotalCost at that
+// tier's prices. Both service tier fields are zero when the model does not
+// offer the tier.
 type CostEstimate struct {
-	Model     string  `json:"model"`
-	Tokens    int     `json:"tokens"`
-	Cost      float64 `json:"cost"`
-	RatePer1M float64 `json:"rate_per_1m"`
+	Model           string  `json:"model"`
+	Tokens          int     `json:"tokens"`
+	TokenSource     string  `json:"token_source"`
+	Cost            float64 `json:"cost"`
+	RatePer1M       float64 `json:"rate_per_1m"`
+	OutputTokens    int     `json:"output_tokens"`
+	OutputCost      float64 `json:"output_cost"`
+	OutputRatePer1M float64 `json:"output_rate_per_1m"`
+	CachedTokens    int     `json:"cached_tokens,omitempty"`
+	UncachedCost    float64 `json:"uncached_cost,omitempty"`
+	CacheWriteCost  float64 `json:"cache_write_cost,omitempty"`
+	CacheReadCost   float64 `json:"cache_read_cost,omitempty"`
+	CacheSavings    float64 `json:"cache_savings,omitempty"`
+	WarmCache       bool    `json:"warm_cache,omitempty"`
+	TotalCost       float64 `json:"total_cost"`
+	TierAbove       int     `json:"tier_above,omitempty"`
+	ServiceTier     string  `json:"service_tier,omitempty"`
+	ServiceTierCost float64 `json:"service_tier_cost,omitempty"`
+	PricesAsOf      string  `json:"prices_as_of,omitempty"`
+}
+
+// CostOptions configures the models priced by CalculateCostsWithOptions and
+// its output, prompt-caching and service tier estimates. OutputTokens takes
+// precedence over OutputRatio and CachedTokens over CacheHitRatio.
+//
+// Models lists the models to price. When it is empty, AllModels prices
+// every registry model with pricing, cheapest first; otherwise Model, if
+// set, is priced ahead of the default comparison models. Provider limits
+// the default and AllModels sets to one provider.
+type CostOptions struct {
+	Registry  *Registry // model metadata; nil uses DefaultRegistry
+	Models    []string  // explicit models to price, in order
+	AllModels bool      // price every priced registry model, cheapest first
+	Model     string    // selected model, priced first
+	Provider  Provider  // provider filter; empty or "all" includes every provider
+
+	OutputTokens  int         // expected output tokens per request
+	OutputRatio   float64     // expected output tokens as a multiple of the input tokens
+	CachedTokens  int         // input tokens in a cached prompt prefix
+	CacheHitRatio float64     // fraction of the input tokens read from the cache
+	ServiceTier   ServiceTier // tier priced alongside standard; empty means standard only
+	AsOf          time.Time   // day whose prices apply; the zero time means current prices
+}
+
+// ChatCountResult represents the token count of a chat request.
+type ChatCountResult struct {
+	Model          string         `json:"model"`
+	Encoding       string         `json:"encoding"`
+	Template       string         `json:"template,omitempty"`
+	IsExact        bool           `json:"is_exact"`
+	Messages       []MessageCount `json:"messages"`
+	ReplyPriming   int            `json:"reply_priming"`
+	Breakdown      ChatBreakdown  `json:"breakdown"`
+	TotalTokens    int            `json:"total_tokens"`
+	ContextWindow  int            `json:"context_window,omitempty"`
+	OutputHeadroom int            `json:"output_headroom,omitempty"`
+	InputCost      float64        `json:"input_cost,omitempty"`
+	Notes          []string       `json:"notes,omitempty"`
+}
+
+// ChatBreakdown splits a chat request's prompt tokens by source.
+// Reply priming is reported separately on ChatCountResult.
+type ChatBreakdown struct {
+	System    int `json:"system"`
+	Messages  int `json:"messages"`
+	Tools     int `json:"tools"`
+	Images    int `json:"images,omitempty"`
+	Documents int `json:"documents,omitempty"`
+}
+
+// MessageCount represents the token count of a single chat message.
+type MessageCount struct {
+	Index          int    `json:"index"`
+	Role           string `json:"role"`
+	ContentTokens  int    `json:"content_tokens"`
+	OverheadTokens int    `json:"overhead_tokens"`
+	Tokens         int    `json:"tokens"`
 }
 
 // CounterOptions configures the counter.
@@ -53,4 +187,47 @@ type CounterOptions struct {
 	WordsPerToken float64
 	VocabFile     string
 	Provider      Provider
+
+	// IncludeImages estimates image files in CountFile and CountDirectory
+	// instead of rejecting or skipping them as binary.
+	IncludeImages bool
+
+	// ImageDetail is the OpenAI detail level used for image estimates
+	// (auto, low, high). Defaults to auto, which is priced as high.
+	ImageDetail string
+
+	// IncludeAudio estimates audio files in CountFile and CountDirectory
+	// for audio-capable models instead of rejecting or skipping them.
+	IncludeAudio bool
+
+	// PDFPageOverhead adds Claude's per-page image overhead for PDF files
+	// to Media, on top of the extracted text.
+	PDFPageOverhead bool
+
+	// NoExtract treats PDF, Office, ODF and EPUB documents as binary files
+	// instead of extracting their text.
+	NoExtract bool
+
+	// ChatTemplate overrides the model's built-in chat template when
+	// counting chat messages.
+	ChatTemplate *ChatTemplate
+
+	// NoChatTemplate counts chat messages of open-weight models with the
+	// OpenAI framing overhead instead of their chat templates.
+	NoChatTemplate bool
+
+	// StrictModels makes Count, CountFile and CountDirectory return
+	// ErrModelNotFound, with suggestions, for a model that is not in the
+	// registry instead of falling back to approximations.
+	StrictModels bool
+
+	// Registry supplies the model metadata the counter uses. Defaults to
+	// DefaultRegistry.
+	Registry *Registry
+
+	// RegistryOverrides are merged into a private copy of the counter's
+	// registry when the counter is created (see Registry.ApplyOverrides),
+	// so they affect only this counter. Use ApplyRegistryOverrides to
+	// change the default registry instead.
+	RegistryOverrides *RegistryOverrides
 }